@@ -7,14 +7,14 @@
 package pb
 
 import (
-	reflect "reflect"
-	sync "sync"
-	unsafe "unsafe"
-
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
 )
 
 const (
@@ -24,6 +24,58 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type EventChange_Type int32
+
+const (
+	EventChange_TYPE_UNSPECIFIED EventChange_Type = 0
+	EventChange_CREATED          EventChange_Type = 1
+	EventChange_UPDATED          EventChange_Type = 2
+	EventChange_DELETED          EventChange_Type = 3
+)
+
+// Enum value maps for EventChange_Type.
+var (
+	EventChange_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "CREATED",
+		2: "UPDATED",
+		3: "DELETED",
+	}
+	EventChange_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"CREATED":          1,
+		"UPDATED":          2,
+		"DELETED":          3,
+	}
+)
+
+func (x EventChange_Type) Enum() *EventChange_Type {
+	p := new(EventChange_Type)
+	*p = x
+	return p
+}
+
+func (x EventChange_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventChange_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_CalendarService_proto_enumTypes[0].Descriptor()
+}
+
+func (EventChange_Type) Type() protoreflect.EnumType {
+	return &file_CalendarService_proto_enumTypes[0]
+}
+
+func (x EventChange_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventChange_Type.Descriptor instead.
+func (EventChange_Type) EnumDescriptor() ([]byte, []int) {
+	return file_CalendarService_proto_rawDescGZIP(), []int{7, 0}
+}
+
 type CreateEventReq struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Event         *Event                 `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
@@ -344,11 +396,150 @@ func (x *GetEventsResp) GetEvents() []*Event {
 	return nil
 }
 
+type WatchEventsReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Empty user_id and unset bounds match any event.
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	From   *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	// Resume token of the last received change.
+	ResumeToken   string `protobuf:"bytes,4,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEventsReq) Reset() {
+	*x = WatchEventsReq{}
+	mi := &file_CalendarService_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEventsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEventsReq) ProtoMessage() {}
+
+func (x *WatchEventsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_CalendarService_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEventsReq.ProtoReflect.Descriptor instead.
+func (*WatchEventsReq) Descriptor() ([]byte, []int) {
+	return file_CalendarService_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WatchEventsReq) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *WatchEventsReq) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *WatchEventsReq) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *WatchEventsReq) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+type EventChange struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  EventChange_Type       `protobuf:"varint,1,opt,name=type,proto3,enum=calendar.EventChange_Type" json:"type,omitempty"`
+	// Only id is set for deleted events.
+	Event         *Event                 `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	ResumeToken   string                 `protobuf:"bytes,4,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventChange) Reset() {
+	*x = EventChange{}
+	mi := &file_CalendarService_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventChange) ProtoMessage() {}
+
+func (x *EventChange) ProtoReflect() protoreflect.Message {
+	mi := &file_CalendarService_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventChange.ProtoReflect.Descriptor instead.
+func (*EventChange) Descriptor() ([]byte, []int) {
+	return file_CalendarService_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *EventChange) GetType() EventChange_Type {
+	if x != nil {
+		return x.Type
+	}
+	return EventChange_TYPE_UNSPECIFIED
+}
+
+func (x *EventChange) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *EventChange) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *EventChange) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
 var File_CalendarService_proto protoreflect.FileDescriptor
 
 const file_CalendarService_proto_rawDesc = "" +
 	"\n" +
-	"\x15CalendarService.proto\x12\bcalendar\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bgoogle/protobuf/empty.proto\"7\n" +
+	"\x15CalendarService.proto\x12\bcalendar\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bgoogle/protobuf/empty.proto\"7\n" +
 	"\x0eCreateEventReq\x12%\n" +
 	"\x05event\x18\x01 \x01(\v2\x0f.calendar.EventR\x05event\"\xfb\x01\n" +
 	"\x05Event\x12\x0e\n" +
@@ -369,14 +560,31 @@ const file_CalendarService_proto_rawDesc = "" +
 	"\fGetEventsReq\x120\n" +
 	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\"8\n" +
 	"\rGetEventsResp\x12'\n" +
-	"\x06events\x18\x01 \x03(\v2\x0f.calendar.EventR\x06events2\x9f\x03\n" +
-	"\bCalendar\x12A\n" +
-	"\vCreateEvent\x12\x18.calendar.CreateEventReq\x1a\x16.google.protobuf.Empty\"\x00\x12A\n" +
-	"\vUpdateEvent\x12\x18.calendar.UpdateEventReq\x1a\x16.google.protobuf.Empty\"\x00\x12A\n" +
-	"\vDeleteEvent\x12\x18.calendar.DeleteEventReq\x1a\x16.google.protobuf.Empty\"\x00\x12A\n" +
-	"\fGetEventsDay\x12\x16.calendar.GetEventsReq\x1a\x17.calendar.GetEventsResp\"\x00\x12B\n" +
-	"\rGetEventsWeek\x12\x16.calendar.GetEventsReq\x1a\x17.calendar.GetEventsResp\"\x00\x12C\n" +
-	"\x0eGetEventsMonth\x12\x16.calendar.GetEventsReq\x1a\x17.calendar.GetEventsResp\"\x00B\aZ\x05./;pbb\x06proto3"
+	"\x06events\x18\x01 \x03(\v2\x0f.calendar.EventR\x06events\"\xa8\x01\n" +
+	"\x0eWatchEventsReq\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12.\n" +
+	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12!\n" +
+	"\fresume_token\x18\x04 \x01(\tR\vresumeToken\"\xfc\x01\n" +
+	"\vEventChange\x12.\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x1a.calendar.EventChange.TypeR\x04type\x12%\n" +
+	"\x05event\x18\x02 \x01(\v2\x0f.calendar.EventR\x05event\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12!\n" +
+	"\fresume_token\x18\x04 \x01(\tR\vresumeToken\"C\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\v\n" +
+	"\aCREATED\x10\x01\x12\v\n" +
+	"\aUPDATED\x10\x02\x12\v\n" +
+	"\aDELETED\x10\x032\xf4\x04\n" +
+	"\bCalendar\x12Z\n" +
+	"\vCreateEvent\x12\x18.calendar.CreateEventReq\x1a\x16.google.protobuf.Empty\"\x19\x82\xd3\xe4\x93\x02\x13:\x05event\"\n" +
+	"/v1/events\x12_\n" +
+	"\vUpdateEvent\x12\x18.calendar.UpdateEventReq\x1a\x16.google.protobuf.Empty\"\x1e\x82\xd3\xe4\x93\x02\x18:\x05event\x1a\x0f/v1/events/{id}\x12X\n" +
+	"\vDeleteEvent\x12\x18.calendar.DeleteEventReq\x1a\x16.google.protobuf.Empty\"\x17\x82\xd3\xe4\x93\x02\x11*\x0f/v1/events/{id}\x12W\n" +
+	"\fGetEventsDay\x12\x16.calendar.GetEventsReq\x1a\x17.calendar.GetEventsResp\"\x16\x82\xd3\xe4\x93\x02\x10\x12\x0e/v1/events/day\x12Y\n" +
+	"\rGetEventsWeek\x12\x16.calendar.GetEventsReq\x1a\x17.calendar.GetEventsResp\"\x17\x82\xd3\xe4\x93\x02\x11\x12\x0f/v1/events/week\x12[\n" +
+	"\x0eGetEventsMonth\x12\x16.calendar.GetEventsReq\x1a\x17.calendar.GetEventsResp\"\x18\x82\xd3\xe4\x93\x02\x12\x12\x10/v1/events/month\x12@\n" +
+	"\vWatchEvents\x12\x18.calendar.WatchEventsReq\x1a\x15.calendar.EventChange0\x01B\aZ\x05./;pbb\x06proto3"
 
 var (
 	file_CalendarService_proto_rawDescOnce sync.Once
@@ -390,41 +598,52 @@ func file_CalendarService_proto_rawDescGZIP() []byte {
 	return file_CalendarService_proto_rawDescData
 }
 
-var file_CalendarService_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_CalendarService_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_CalendarService_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_CalendarService_proto_goTypes = []any{
-	(*CreateEventReq)(nil),        // 0: calendar.CreateEventReq
-	(*Event)(nil),                 // 1: calendar.Event
-	(*UpdateEventReq)(nil),        // 2: calendar.UpdateEventReq
-	(*DeleteEventReq)(nil),        // 3: calendar.DeleteEventReq
-	(*GetEventsReq)(nil),          // 4: calendar.GetEventsReq
-	(*GetEventsResp)(nil),         // 5: calendar.GetEventsResp
-	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
-	(*emptypb.Empty)(nil),         // 7: google.protobuf.Empty
+	(EventChange_Type)(0),         // 0: calendar.EventChange.Type
+	(*CreateEventReq)(nil),        // 1: calendar.CreateEventReq
+	(*Event)(nil),                 // 2: calendar.Event
+	(*UpdateEventReq)(nil),        // 3: calendar.UpdateEventReq
+	(*DeleteEventReq)(nil),        // 4: calendar.DeleteEventReq
+	(*GetEventsReq)(nil),          // 5: calendar.GetEventsReq
+	(*GetEventsResp)(nil),         // 6: calendar.GetEventsResp
+	(*WatchEventsReq)(nil),        // 7: calendar.WatchEventsReq
+	(*EventChange)(nil),           // 8: calendar.EventChange
+	(*timestamppb.Timestamp)(nil), // 9: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),         // 10: google.protobuf.Empty
 }
 var file_CalendarService_proto_depIdxs = []int32{
-	1,  // 0: calendar.CreateEventReq.event:type_name -> calendar.Event
-	6,  // 1: calendar.Event.start_time:type_name -> google.protobuf.Timestamp
-	6,  // 2: calendar.Event.end_time:type_name -> google.protobuf.Timestamp
-	1,  // 3: calendar.UpdateEventReq.event:type_name -> calendar.Event
-	6,  // 4: calendar.GetEventsReq.start:type_name -> google.protobuf.Timestamp
-	1,  // 5: calendar.GetEventsResp.events:type_name -> calendar.Event
-	0,  // 6: calendar.Calendar.CreateEvent:input_type -> calendar.CreateEventReq
-	2,  // 7: calendar.Calendar.UpdateEvent:input_type -> calendar.UpdateEventReq
-	3,  // 8: calendar.Calendar.DeleteEvent:input_type -> calendar.DeleteEventReq
-	4,  // 9: calendar.Calendar.GetEventsDay:input_type -> calendar.GetEventsReq
-	4,  // 10: calendar.Calendar.GetEventsWeek:input_type -> calendar.GetEventsReq
-	4,  // 11: calendar.Calendar.GetEventsMonth:input_type -> calendar.GetEventsReq
-	7,  // 12: calendar.Calendar.CreateEvent:output_type -> google.protobuf.Empty
-	7,  // 13: calendar.Calendar.UpdateEvent:output_type -> google.protobuf.Empty
-	7,  // 14: calendar.Calendar.DeleteEvent:output_type -> google.protobuf.Empty
-	5,  // 15: calendar.Calendar.GetEventsDay:output_type -> calendar.GetEventsResp
-	5,  // 16: calendar.Calendar.GetEventsWeek:output_type -> calendar.GetEventsResp
-	5,  // 17: calendar.Calendar.GetEventsMonth:output_type -> calendar.GetEventsResp
-	12, // [12:18] is the sub-list for method output_type
-	6,  // [6:12] is the sub-list for method input_type
-	6,  // [6:6] is the sub-list for extension type_name
-	6,  // [6:6] is the sub-list for extension extendee
-	0,  // [0:6] is the sub-list for field type_name
+	2,  // 0: calendar.CreateEventReq.event:type_name -> calendar.Event
+	9,  // 1: calendar.Event.start_time:type_name -> google.protobuf.Timestamp
+	9,  // 2: calendar.Event.end_time:type_name -> google.protobuf.Timestamp
+	2,  // 3: calendar.UpdateEventReq.event:type_name -> calendar.Event
+	9,  // 4: calendar.GetEventsReq.start:type_name -> google.protobuf.Timestamp
+	2,  // 5: calendar.GetEventsResp.events:type_name -> calendar.Event
+	9,  // 6: calendar.WatchEventsReq.from:type_name -> google.protobuf.Timestamp
+	9,  // 7: calendar.WatchEventsReq.to:type_name -> google.protobuf.Timestamp
+	0,  // 8: calendar.EventChange.type:type_name -> calendar.EventChange.Type
+	2,  // 9: calendar.EventChange.event:type_name -> calendar.Event
+	9,  // 10: calendar.EventChange.time:type_name -> google.protobuf.Timestamp
+	1,  // 11: calendar.Calendar.CreateEvent:input_type -> calendar.CreateEventReq
+	3,  // 12: calendar.Calendar.UpdateEvent:input_type -> calendar.UpdateEventReq
+	4,  // 13: calendar.Calendar.DeleteEvent:input_type -> calendar.DeleteEventReq
+	5,  // 14: calendar.Calendar.GetEventsDay:input_type -> calendar.GetEventsReq
+	5,  // 15: calendar.Calendar.GetEventsWeek:input_type -> calendar.GetEventsReq
+	5,  // 16: calendar.Calendar.GetEventsMonth:input_type -> calendar.GetEventsReq
+	7,  // 17: calendar.Calendar.WatchEvents:input_type -> calendar.WatchEventsReq
+	10, // 18: calendar.Calendar.CreateEvent:output_type -> google.protobuf.Empty
+	10, // 19: calendar.Calendar.UpdateEvent:output_type -> google.protobuf.Empty
+	10, // 20: calendar.Calendar.DeleteEvent:output_type -> google.protobuf.Empty
+	6,  // 21: calendar.Calendar.GetEventsDay:output_type -> calendar.GetEventsResp
+	6,  // 22: calendar.Calendar.GetEventsWeek:output_type -> calendar.GetEventsResp
+	6,  // 23: calendar.Calendar.GetEventsMonth:output_type -> calendar.GetEventsResp
+	8,  // 24: calendar.Calendar.WatchEvents:output_type -> calendar.EventChange
+	18, // [18:25] is the sub-list for method output_type
+	11, // [11:18] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_CalendarService_proto_init() }
@@ -437,13 +656,14 @@ func file_CalendarService_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_CalendarService_proto_rawDesc), len(file_CalendarService_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   6,
+			NumEnums:      1,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_CalendarService_proto_goTypes,
 		DependencyIndexes: file_CalendarService_proto_depIdxs,
+		EnumInfos:         file_CalendarService_proto_enumTypes,
 		MessageInfos:      file_CalendarService_proto_msgTypes,
 	}.Build()
 	File_CalendarService_proto = out.File
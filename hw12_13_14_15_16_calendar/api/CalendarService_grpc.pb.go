@@ -27,6 +27,7 @@ const (
 	Calendar_GetEventsDay_FullMethodName   = "/calendar.Calendar/GetEventsDay"
 	Calendar_GetEventsWeek_FullMethodName  = "/calendar.Calendar/GetEventsWeek"
 	Calendar_GetEventsMonth_FullMethodName = "/calendar.Calendar/GetEventsMonth"
+	Calendar_WatchEvents_FullMethodName    = "/calendar.Calendar/WatchEvents"
 )
 
 // CalendarClient is the client API for Calendar service.
@@ -39,6 +40,9 @@ type CalendarClient interface {
 	GetEventsDay(ctx context.Context, in *GetEventsReq, opts ...grpc.CallOption) (*GetEventsResp, error)
 	GetEventsWeek(ctx context.Context, in *GetEventsReq, opts ...grpc.CallOption) (*GetEventsResp, error)
 	GetEventsMonth(ctx context.Context, in *GetEventsReq, opts ...grpc.CallOption) (*GetEventsResp, error)
+	// WatchEvents streams changes of events; the HTTP counterpart is the
+	// Server-Sent Events feed at /event/watch.
+	WatchEvents(ctx context.Context, in *WatchEventsReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EventChange], error)
 }
 
 type calendarClient struct {
@@ -109,6 +113,25 @@ func (c *calendarClient) GetEventsMonth(ctx context.Context, in *GetEventsReq, o
 	return out, nil
 }
 
+func (c *calendarClient) WatchEvents(ctx context.Context, in *WatchEventsReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EventChange], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Calendar_ServiceDesc.Streams[0], Calendar_WatchEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchEventsReq, EventChange]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Calendar_WatchEventsClient = grpc.ServerStreamingClient[EventChange]
+
 // CalendarServer is the server API for Calendar service.
 // All implementations must embed UnimplementedCalendarServer
 // for forward compatibility.
@@ -119,6 +142,9 @@ type CalendarServer interface {
 	GetEventsDay(context.Context, *GetEventsReq) (*GetEventsResp, error)
 	GetEventsWeek(context.Context, *GetEventsReq) (*GetEventsResp, error)
 	GetEventsMonth(context.Context, *GetEventsReq) (*GetEventsResp, error)
+	// WatchEvents streams changes of events; the HTTP counterpart is the
+	// Server-Sent Events feed at /event/watch.
+	WatchEvents(*WatchEventsReq, grpc.ServerStreamingServer[EventChange]) error
 	mustEmbedUnimplementedCalendarServer()
 }
 
@@ -147,6 +173,9 @@ func (UnimplementedCalendarServer) GetEventsWeek(context.Context, *GetEventsReq)
 func (UnimplementedCalendarServer) GetEventsMonth(context.Context, *GetEventsReq) (*GetEventsResp, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetEventsMonth not implemented")
 }
+func (UnimplementedCalendarServer) WatchEvents(*WatchEventsReq, grpc.ServerStreamingServer[EventChange]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
 func (UnimplementedCalendarServer) mustEmbedUnimplementedCalendarServer() {}
 func (UnimplementedCalendarServer) testEmbeddedByValue()                  {}
 
@@ -276,6 +305,17 @@ func _Calendar_GetEventsMonth_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Calendar_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CalendarServer).WatchEvents(m, &grpc.GenericServerStream[WatchEventsReq, EventChange]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Calendar_WatchEventsServer = grpc.ServerStreamingServer[EventChange]
+
 // Calendar_ServiceDesc is the grpc.ServiceDesc for Calendar service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -308,6 +348,12 @@ var Calendar_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _Calendar_GetEventsMonth_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _Calendar_WatchEvents_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "CalendarService.proto",
 }
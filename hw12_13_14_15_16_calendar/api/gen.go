@@ -1,2 +1,4 @@
-//go:generate protoc --go_out=. --go-grpc_out=. CalendarService.proto
+//go:generate protoc -I . --go_out=. --go-grpc_out=. --grpc-gateway_out=. CalendarService.proto
+//go:generate protoc --go_out=. Notification.proto
+//go:generate oapi-codegen -config httpclient/oapi-codegen.yaml openapi.yaml
 package pb
@@ -0,0 +1,8 @@
+package pb
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI 3 description of the HTTP API.
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
@@ -1,44 +1,174 @@
 package main
 
 import (
-	"github.com/BurntSushi/toml"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/admin"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/config"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
-	"github.com/caarlos0/env/v10"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/ratelimit"
+	internalhttp "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server/http"
+	sqlstorage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage/sql"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tlsconfig"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tracing"
 )
 
 // При желании конфигурацию можно вынести в internal/config.
 // Организация конфига в main принуждает нас сужать API компонентов, использовать
 // при их конструировании только необходимые параметры, а также уменьшает вероятность циклической зависимости.
 type Config struct {
-	Logger  logger.Config `toml:"logger" env-prefix:"LOGGER_"`
-	Storage StorageConf   `toml:"storage" env-prefix:"STORAGE_"`
-	HTTP    HTTPConf      `toml:"http" env-prefix:"HTTP_"`
-	GRPC    GRPCConf      `toml:"grpc" env-prefix:"GRPC_"`
+	Logger  logger.Config  `toml:"logger" envPrefix:"LOGGER_"`
+	Tracing tracing.Config `toml:"tracing" envPrefix:"TRACING_"`
+	Storage StorageConf    `toml:"storage" envPrefix:"STORAGE_"`
+	HTTP    HTTPConf       `toml:"http" envPrefix:"HTTP_"`
+	GRPC    GRPCConf       `toml:"grpc" envPrefix:"GRPC_"`
+	// Admin — служебный порт без TLS для проб Kubernetes и метрик, 0 отключает его.
+	Admin admin.Config `toml:"admin" envPrefix:"ADMIN_"`
+	// RateLimit ограничивает частоту запросов одного клиента к HTTP и gRPC API.
+	RateLimit ratelimit.Config `toml:"rate_limit" envPrefix:"RATE_LIMIT_"`
 }
 
 type StorageConf struct {
 	Mod       string `toml:"mod" env:"MOD"`
-	DSN       string `toml:"dsn" env:"DSN"`
+	DSN       string `toml:"dsn" env:"DSN" secret:"credentials"`
 	Migration string `toml:"migration" env:"MIGRATION"`
+	// Path — файл базы для mod = "sqlite".
+	Path string `toml:"path" env:"PATH"`
+	// AutoMigrate применяет миграции при старте; при false схемой управляет "calendar migrate".
+	AutoMigrate bool                  `toml:"auto_migrate" env:"AUTO_MIGRATE"`
+	Pool        sqlstorage.PoolConfig `toml:"pool" envPrefix:"POOL_"`
 }
 
 type HTTPConf struct {
 	Host string `toml:"host" env:"HOST"`
 	Port int    `toml:"port" env:"PORT"`
+	// TrustedProxies — адреса и подсети прокси (например, ingress), которым доверяются
+	// заголовки X-Forwarded-For и X-Real-IP при определении адреса клиента.
+	TrustedProxies []string         `toml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	TLS            tlsconfig.Config `toml:"tls" envPrefix:"TLS_"`
 }
 
 type GRPCConf struct {
 	Host string `toml:"host" env:"HOST"`
 	Port int    `toml:"port" env:"PORT"`
+	// Timeout ограничивает время обработки unary-вызовов, 0 — без ограничения.
+	Timeout time.Duration    `toml:"timeout" env:"TIMEOUT"`
+	TLS     tlsconfig.Config `toml:"tls" envPrefix:"TLS_"`
 }
 
+// Validate checks the configuration; errors name the offending key.
+func (c Config) Validate() error {
+	if err := c.Logger.Validate(); err != nil {
+		return fmt.Errorf("logger.%w", err)
+	}
+	if err := c.Tracing.Validate(); err != nil {
+		return fmt.Errorf("tracing.%w", err)
+	}
+	if err := c.Storage.Validate(); err != nil {
+		return fmt.Errorf("storage.%w", err)
+	}
+	if err := c.HTTP.Validate(); err != nil {
+		return fmt.Errorf("http.%w", err)
+	}
+	if err := c.GRPC.Validate(); err != nil {
+		return fmt.Errorf("grpc.%w", err)
+	}
+	if c.HTTP.Port == c.GRPC.Port {
+		return fmt.Errorf("grpc.port: must differ from http.port %d", c.HTTP.Port)
+	}
+	if err := c.Admin.Validate(); err != nil {
+		return fmt.Errorf("admin.%w", err)
+	}
+	if c.Admin.Port != 0 && (c.Admin.Port == c.HTTP.Port || c.Admin.Port == c.GRPC.Port) {
+		return fmt.Errorf("admin.port: must differ from http.port and grpc.port, got %d", c.Admin.Port)
+	}
+	if err := c.RateLimit.Validate(); err != nil {
+		return fmt.Errorf("rate_limit.%w", err)
+	}
+	return nil
+}
+
+// Validate checks that the storage is known and has a database for sql and sqlite,
+// which is always migrated at startup.
+func (c StorageConf) Validate() error {
+	switch c.Mod {
+	case "memory":
+	case "sql":
+		if c.DSN == "" {
+			return errors.New("dsn: required by sql storage")
+		}
+		if err := c.Pool.Validate(); err != nil {
+			return fmt.Errorf("pool.%w", err)
+		}
+	case "sqlite":
+		if c.Path == "" {
+			return errors.New("path: required by sqlite storage")
+		}
+		// Команды migrate для SQLite нет, без миграций при старте схема не появится
+		if !c.AutoMigrate {
+			return errors.New("auto_migrate: must be true for sqlite storage")
+		}
+	default:
+		return fmt.Errorf("mod: unknown storage %q, want memory, sql or sqlite", c.Mod)
+	}
+	return nil
+}
+
+// Validate checks the port, trusted proxies and TLS settings.
+func (c HTTPConf) Validate() error {
+	if err := validatePort(c.Port); err != nil {
+		return fmt.Errorf("port: %w", err)
+	}
+	if _, err := internalhttp.ParseTrustedProxies(c.TrustedProxies); err != nil {
+		return fmt.Errorf("trusted_proxies: %w", err)
+	}
+	if err := c.TLS.Validate(); err != nil {
+		return fmt.Errorf("tls.%w", err)
+	}
+	return nil
+}
+
+// Validate checks the port, the timeout and TLS settings.
+func (c GRPCConf) Validate() error {
+	if err := validatePort(c.Port); err != nil {
+		return fmt.Errorf("port: %w", err)
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("timeout: must not be negative, got %s", c.Timeout)
+	}
+	if err := c.TLS.Validate(); err != nil {
+		return fmt.Errorf("tls.%w", err)
+	}
+	return nil
+}
+
+func validatePort(port int) error {
+	if port <= 0 || port > 65535 {
+		return fmt.Errorf("must be between 1 and 65535, got %d", port)
+	}
+	return nil
+}
+
+// NewConfig reads the configuration file, applies environment overrides and validates the result.
 func NewConfig() (Config, error) {
-	var cfg Config
-	if _, err := toml.DecodeFile(configFile, &cfg); err != nil {
-		return Config{}, err
+	cfg := Config{
+		Tracing: tracing.Config{SampleRatio: tracing.DefaultSampleRatio},
+		Storage: StorageConf{AutoMigrate: true},
 	}
-	if err := env.Parse(&cfg); err != nil {
-		return cfg, err
+	if err := config.Load(configFile, &cfg); err != nil {
+		return Config{}, err
 	}
 	return cfg, nil
 }
+
+// checkConfig prints the effective configuration with secrets masked.
+func checkConfig(w io.Writer) error {
+	cfg, err := NewConfig()
+	if err != nil {
+		return err
+	}
+	return config.Write(w, cfg)
+}
@@ -6,13 +6,20 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/health"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/ratelimit"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/reload"
 	"golang.org/x/sync/errgroup"
 )
 
+// serviceName identifies the service in traces.
+const serviceName = "calendar"
+
 var configFile string
 
 func init() {
@@ -25,12 +32,25 @@ func main() {
 		printVersion()
 		return
 	}
+	if flag.Arg(0) == "config" {
+		// Проверка конфигурации без запуска сервиса, например перед выкладкой
+		if flag.Arg(1) != "check" {
+			log.Fatalf("unknown command %q, want \"config check\"", strings.Join(flag.Args(), " "))
+		}
+		if err := checkConfig(os.Stdout); err != nil {
+			log.Fatalf("config %s: %v", configFile, err)
+		}
+		return
+	}
+	// Опечатка в команде обслуживания не должна запускать сервис
+	if flag.NArg() > 0 && !isMaintenanceCommand(flag.Arg(0)) {
+		log.Fatalf("unknown command %q", strings.Join(flag.Args(), " "))
+	}
 
 	log.SetOutput(os.Stdout)
 	cfg, err := NewConfig()
 	if err != nil {
-		log.Printf("error initializing config: %v", err)
-		return
+		log.Fatalf("error initializing config: %v", err)
 	}
 	lg, closer, err := logger.NewLogger(cfg.Logger)
 	if err != nil {
@@ -43,14 +63,32 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	storage, closer, err := setupStorage(ctx, cfg, lg)
+	stopTracing := setupTracing(ctx, cfg.Tracing)
+	defer stopTracing()
+
+	if isMaintenanceCommand(flag.Arg(0)) {
+		if err := runMaintenance(ctx, cfg, lg, flag.Args()); err != nil {
+			log.Printf("%s: %v", flag.Arg(0), err)
+			stop()
+			os.Exit(1)
+		}
+		return
+	}
+
+	checker := health.New(0)
+
+	storage, storageCloser, err := setupStorage(ctx, cfg, lg, checker)
 	if err != nil {
 		log.Printf("error initializing storage: %v", err)
 		return
 	}
 
 	defer func() {
-		if err := closer.Close(); err != nil {
+		// Хранилище в памяти закрывать не нужно
+		if storageCloser == nil {
+			return
+		}
+		if err := storageCloser.Close(); err != nil {
 			log.Printf("error closing storage %s: %s", cfg.Storage.Mod, err)
 		} else {
 			log.Printf("storage %s successfully closed", cfg.Storage.Mod)
@@ -61,8 +99,22 @@ func main() {
 
 	g, ctx := errgroup.WithContext(ctx)
 
-	startHTTPServer(ctx, g, cfg, lg, calendar)
-	startGRPCServer(ctx, g, cfg, lg, calendar)
+	// Открытые подписки на изменения иначе задержат остановку серверов
+	context.AfterFunc(ctx, calendar.Close)
+
+	// Один ограничитель на оба транспорта: ключи маршрутов HTTP и методов gRPC не пересекаются
+	limiter := ratelimit.New(cfg.RateLimit)
+
+	// SIGHUP перечитывает уровень и формат логов и лимиты запросов
+	reload.Watch(ctx, lg, configReloader(cfg, lg, limiter))
+
+	startHTTPServer(ctx, g, cfg, lg, calendar, limiter, checker)
+	startGRPCServer(ctx, g, cfg, lg, calendar, limiter, checker)
+	startAdminServer(ctx, g, cfg.Admin, lg, checker)
+
+	// С началом остановки балансировщик должен перестать слать запросы
+	checker.SetReady(true)
+	context.AfterFunc(ctx, func() { checker.SetReady(false) })
 
 	if err := g.Wait(); err != nil {
 		log.Printf("service stopped with error: %v", err)
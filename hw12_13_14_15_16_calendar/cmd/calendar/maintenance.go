@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	sqlstorage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage/sql"
+)
+
+var errMaintenanceUsage = errors.New("usage: calendar migrate up|down|status|redo | seed FILE | dump [FILE]")
+
+// isMaintenanceCommand reports whether cmd is a database maintenance subcommand.
+func isMaintenanceCommand(cmd string) bool {
+	return cmd == "migrate" || cmd == "seed" || cmd == "dump"
+}
+
+// runMaintenance executes the "migrate", "seed" and "dump" subcommands against the SQL storage.
+func runMaintenance(ctx context.Context, cfg Config, lg *slog.Logger, args []string) error {
+	if len(args) == 0 {
+		return errMaintenanceUsage
+	}
+	if cfg.Storage.Mod != "sql" {
+		return fmt.Errorf("requires storage.mod = \"sql\", got %q", cfg.Storage.Mod)
+	}
+
+	s := sqlstorage.New(lg, cfg.Storage.DSN, cfg.Storage.Pool)
+	connectCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := s.Connect(connectCtx); err != nil {
+		return err
+	}
+	defer s.Close()
+
+	switch args[0] {
+	case "migrate":
+		if len(args) != 2 {
+			return errMaintenanceUsage
+		}
+		return runMigrate(ctx, s, cfg.Storage.Migration, args[1])
+	case "seed":
+		if len(args) != 2 {
+			return errMaintenanceUsage
+		}
+		return seed(ctx, s, args[1])
+	case "dump":
+		switch len(args) {
+		case 1:
+			return dump(ctx, s, os.Stdout)
+		case 2:
+			return dumpFile(ctx, s, args[1])
+		}
+		return errMaintenanceUsage
+	default:
+		return errMaintenanceUsage
+	}
+}
+
+func runMigrate(ctx context.Context, s *sqlstorage.Storage, dir, cmd string) error {
+	switch cmd {
+	case "up":
+		results, err := s.MigrateUp(ctx, dir)
+		for _, r := range results {
+			fmt.Println(r)
+		}
+		if err == nil && len(results) == 0 {
+			fmt.Println("no migrations to apply")
+		}
+		return err
+	case "down":
+		result, err := s.MigrateDown(ctx, dir)
+		if err != nil {
+			return err
+		}
+		fmt.Println(result)
+		return nil
+	case "redo":
+		results, err := s.MigrateRedo(ctx, dir)
+		for _, r := range results {
+			fmt.Println(r)
+		}
+		return err
+	case "status":
+		statuses, err := s.MigrationStatus(ctx, dir)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tSTATE\tAPPLIED AT\tSOURCE")
+		for _, st := range statuses {
+			appliedAt := "-"
+			if !st.AppliedAt.IsZero() {
+				appliedAt = st.AppliedAt.Local().Format(time.DateTime)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", st.Source.Version, st.State, appliedAt, st.Source.Path)
+		}
+		return tw.Flush()
+	default:
+		return errMaintenanceUsage
+	}
+}
+
+// seed loads a JSON array of events, keeping the stored events with the same ID.
+func seed(ctx context.Context, s *sqlstorage.Storage, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var dtos []storage.EventDTO
+	if err := json.Unmarshal(data, &dtos); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	events := make([]storage.Event, 0, len(dtos))
+	for _, dto := range dtos {
+		events = append(events, storage.FromDTO(dto))
+	}
+
+	n, err := s.ImportEvents(ctx, events)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d events, skipped %d existing\n", n, len(events)-n)
+	return nil
+}
+
+// dump writes all events as a JSON array accepted by seed.
+func dump(ctx context.Context, s *sqlstorage.Storage, w io.Writer) error {
+	events, err := s.AllEvents(ctx)
+	if err != nil {
+		return err
+	}
+	dtos := make([]storage.EventDTO, 0, len(events))
+	for _, e := range events {
+		dtos = append(dtos, storage.ToDTO(e))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(dtos)
+}
+
+func dumpFile(ctx context.Context, s *sqlstorage.Storage, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := dump(ctx, s, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
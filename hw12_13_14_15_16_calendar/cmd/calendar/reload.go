@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/ratelimit"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/reload"
+)
+
+// configReloader applies the settings that can change without a restart:
+// log level and format and rate limits.
+func configReloader(current Config, lg *slog.Logger, limiter *ratelimit.Limiter) reload.Func {
+	return func(ctx context.Context) error {
+		next, err := NewConfig()
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(current.fixed(), next.fixed()) {
+			lg.WarnContext(ctx, "only log settings and rate limits are reloaded, restart to apply other changes")
+		}
+
+		if err := logger.Reconfigure(lg, next.Logger.Level, next.Logger.JSON); err != nil {
+			return err
+		}
+		limiter.Update(next.RateLimit)
+
+		current.Logger.Level, current.Logger.JSON = next.Logger.Level, next.Logger.JSON
+		current.RateLimit = next.RateLimit
+		return nil
+	}
+}
+
+// fixed returns the configuration without the settings applied on reload.
+func (c Config) fixed() Config {
+	c.Logger.Level, c.Logger.JSON = "", false
+	c.RateLimit = ratelimit.Config{}
+	return c
+}
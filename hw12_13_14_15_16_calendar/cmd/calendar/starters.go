@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -12,16 +13,32 @@ import (
 	"time"
 
 	pb "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/admin"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/health"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/ratelimit"
 	grpcserver "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server/grpc"
 	internalhttp "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server/http"
 	memorystorage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage/memory"
 	sqlstorage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage/sql"
+	sqlitestorage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage/sqlite"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tlsconfig"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tracing"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
-func setupStorage(ctx context.Context, cfg Config, lg *slog.Logger) (app.Storage, io.Closer, error) {
+// healthInterval is how often the gRPC health service re-runs readiness checks.
+const healthInterval = 5 * time.Second
+
+func setupStorage(
+	ctx context.Context,
+	cfg Config,
+	lg *slog.Logger,
+	checker *health.Checker,
+) (app.Storage, io.Closer, error) {
 	switch cfg.Storage.Mod {
 	case "memory":
 		log.Print("using in-memory storage")
@@ -30,7 +47,7 @@ func setupStorage(ctx context.Context, cfg Config, lg *slog.Logger) (app.Storage
 	case "sql":
 		log.Print("initializing connection to PostgreSQL...")
 
-		sqlStorage := sqlstorage.New(lg, cfg.Storage.DSN)
+		sqlStorage := sqlstorage.New(lg, cfg.Storage.DSN, cfg.Storage.Pool)
 		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 
@@ -39,29 +56,78 @@ func setupStorage(ctx context.Context, cfg Config, lg *slog.Logger) (app.Storage
 			return nil, nil, err
 		}
 
-		log.Print("executing migrations...")
-		if err := sqlStorage.Migrate(ctx, cfg.Storage.Migration); err != nil {
-			log.Print(err)
-			return nil, nil, err
+		if cfg.Storage.AutoMigrate {
+			log.Print("executing migrations...")
+			if err := sqlStorage.Migrate(ctx, cfg.Storage.Migration); err != nil {
+				log.Print(err)
+				return nil, nil, err
+			}
 		}
 
+		checker.AddReadiness("postgres", sqlStorage.Ping)
 		log.Print("SQL storage successfully initialized and connected")
 		return sqlStorage, sqlStorage, nil
 
+	case "sqlite":
+		sqliteStorage, err := openSQLite(ctx, cfg.Storage.Path, lg)
+		if err != nil {
+			return nil, nil, err
+		}
+		checker.AddReadiness("sqlite", sqliteStorage.Ping)
+		return sqliteStorage, sqliteStorage, nil
+
 	default:
 		log.Printf("unknown storage type: %v", cfg.Storage.Mod)
 		return nil, nil, fmt.Errorf("unknown storage type: %v", cfg.Storage.Mod)
 	}
 }
 
+// openSQLite opens the database file and applies migrations; Config.Validate
+// requires auto_migrate for SQLite.
+func openSQLite(ctx context.Context, path string, lg *slog.Logger) (*sqlitestorage.Storage, error) {
+	log.Printf("opening SQLite database %s...", path)
+
+	sqliteStorage := sqlitestorage.New(lg, path)
+	if err := sqliteStorage.Connect(ctx); err != nil {
+		log.Printf("error opening SQLite database: %v", err)
+		return nil, err
+	}
+	if err := sqliteStorage.Migrate(ctx); err != nil {
+		log.Print(err)
+		sqliteStorage.Close()
+		return nil, err
+	}
+	log.Print("SQLite storage successfully initialized")
+	return sqliteStorage, nil
+}
+
 func startHTTPServer(
 	ctx context.Context,
 	g *errgroup.Group,
 	cfg Config,
 	lg *slog.Logger,
 	calendar *app.App,
+	limiter *ratelimit.Limiter,
+	checker *health.Checker,
 ) {
-	serverHTTP := internalhttp.NewServerHTTP(cfg.HTTP.Host, cfg.HTTP.Port, lg, calendar)
+	tlsCfg, err := setupTLS(ctx, cfg.HTTP.TLS, lg)
+	if err != nil {
+		g.Go(func() error {
+			return fmt.Errorf("HTTP TLS: %w", err)
+		})
+		return
+	}
+
+	// Список уже проверен в Config.Validate
+	proxies, _ := internalhttp.ParseTrustedProxies(cfg.HTTP.TrustedProxies)
+
+	serverHTTP := internalhttp.NewServerHTTP(cfg.HTTP.Host, cfg.HTTP.Port, lg, calendar, limiter)
+	serverHTTP.SetTrustedProxies(proxies)
+	checker.Register(serverHTTP)
+	if tlsCfg != nil {
+		serverHTTP.SetTLSConfig(tlsCfg)
+		log.Print("HTTP server uses TLS")
+	}
 	log.Print("HTTP server created")
 
 	addr := fmt.Sprintf("%s:%d", cfg.HTTP.Host, cfg.HTTP.Port)
@@ -90,15 +156,59 @@ func startHTTPServer(
 	})
 }
 
+// startAdminServer serves probes and metrics without TLS, so kubelet can reach them
+// even when the API requires client certificates.
+func startAdminServer(
+	ctx context.Context, g *errgroup.Group, cfg admin.Config, lg *slog.Logger, checker *health.Checker,
+) {
+	if cfg.Port == 0 {
+		log.Print("admin server disabled")
+		return
+	}
+
+	srv := admin.NewServer(cfg, lg)
+	checker.Register(srv)
+
+	g.Go(srv.Start)
+
+	g.Go(func() error {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		if err := srv.Stop(shutdownCtx); err != nil {
+			log.Printf("[shutdown] error stopping admin server: %s", err)
+		} else {
+			log.Print("[shutdown] admin server stopped")
+		}
+		return ctx.Err()
+	})
+}
+
 func startGRPCServer(
 	ctx context.Context,
 	g *errgroup.Group,
 	cfg Config,
 	lg *slog.Logger,
 	calendar *app.App,
+	limiter *ratelimit.Limiter,
+	checker *health.Checker,
 ) {
 	addr := fmt.Sprintf("%s:%d", cfg.GRPC.Host, cfg.GRPC.Port)
 
+	opts := grpcserver.ServerOptions(lg, cfg.GRPC.Timeout, limiter)
+	tlsCfg, err := setupTLS(ctx, cfg.GRPC.TLS, lg)
+	if err != nil {
+		g.Go(func() error {
+			return fmt.Errorf("gRPC TLS: %w", err)
+		})
+		return
+	}
+	if tlsCfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+		log.Print("gRPC server uses TLS")
+	}
+
 	lis, err := net.Listen("tcp", addr)
 	if err != nil {
 		g.Go(func() error {
@@ -108,8 +218,9 @@ func startGRPCServer(
 	}
 
 	serverGRPC := grpcserver.NewServerGRPC(lg, lis, calendar)
-	grpcSrv := grpc.NewServer()
+	grpcSrv := grpc.NewServer(opts...)
 	pb.RegisterCalendarServer(grpcSrv, serverGRPC)
+	healthpb.RegisterHealthServer(grpcSrv, grpcserver.NewHealthServer(ctx, lg, checker, healthInterval))
 
 	g.Go(func() error {
 		log.Printf("gRPC server starting %s...", lis.Addr().String())
@@ -140,3 +251,36 @@ func startGRPCServer(
 		return ctx.Err()
 	})
 }
+
+// setupTLS loads certificates and watches them for changes until ctx is done.
+// It returns nil when TLS is not configured.
+func setupTLS(ctx context.Context, cfg tlsconfig.Config, lg *slog.Logger) (*tls.Config, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	reloader, err := tlsconfig.NewReloader(cfg, lg)
+	if err != nil {
+		return nil, err
+	}
+	go reloader.Run(ctx)
+	return reloader.TLSConfig(), nil
+}
+
+func setupTracing(ctx context.Context, cfg tracing.Config) func() {
+	shutdown, err := tracing.Setup(ctx, cfg, serviceName)
+	if err != nil {
+		log.Printf("error initializing tracing, spans will not be exported: %v", err)
+		return func() {}
+	}
+	if cfg.Exporter != "" && cfg.Exporter != tracing.ExporterNone {
+		log.Printf("tracing enabled, exporter: %s", cfg.Exporter)
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			log.Printf("[shutdown] error flushing spans: %v", err)
+		}
+	}
+}
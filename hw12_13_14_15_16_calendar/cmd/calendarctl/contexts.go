@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/calendarctl"
+)
+
+// runContext executes the "context" subcommands, which edit the contexts file.
+func runContext(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	cfg, err := calendarctl.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "CURRENT\tNAME\tSERVER\tTLS")
+		for _, name := range cfg.Names() {
+			c := cfg.Contexts[name]
+			current := ""
+			if name == cfg.CurrentContext {
+				current = "*"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", current, name, c.Server, tlsMode(c))
+		}
+		return tw.Flush()
+	case "current":
+		name, c, err := cfg.Resolve(contextName)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s (%s)\n", name, c.Server)
+		return nil
+	case "use":
+		if len(args) != 2 {
+			return errUsage
+		}
+		if err := cfg.Use(args[1]); err != nil {
+			return err
+		}
+	case "delete":
+		if len(args) != 2 {
+			return errUsage
+		}
+		if err := cfg.Delete(args[1]); err != nil {
+			return err
+		}
+	case "set":
+		if err := setContext(cfg, args[1:]); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown context command %q", args[0])
+	}
+	return cfg.Save(configPath)
+}
+
+// setContext creates a context or changes the given settings of an existing one.
+func setContext(cfg *calendarctl.Config, args []string) error {
+	fs := newFlagSet("context set")
+	var c calendarctl.Context
+	fs.StringVar(&c.Server, "server", "", "gRPC address of the server")
+	fs.BoolVar(&c.TLS, "tls", false, "Use TLS verified against system roots")
+	fs.StringVar(&c.CAFile, "ca", "", "CA certificate verifying the server")
+	fs.StringVar(&c.CertFile, "cert", "", "Client certificate for mutual TLS")
+	fs.StringVar(&c.KeyFile, "key", "", "Client key for mutual TLS")
+	fs.StringVar(&c.ServerName, "server-name", "", "Name expected in the server certificate")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errUsage
+	}
+	name := positional[0]
+
+	// Заданные флаги меняют только свои поля существующего контекста
+	updated := cfg.Contexts[name]
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "server":
+			updated.Server = c.Server
+		case "tls":
+			updated.TLS = c.TLS
+		case "ca":
+			updated.CAFile = c.CAFile
+		case "cert":
+			updated.CertFile = c.CertFile
+		case "key":
+			updated.KeyFile = c.KeyFile
+		case "server-name":
+			updated.ServerName = c.ServerName
+		}
+	})
+	return cfg.Set(name, updated)
+}
+
+func tlsMode(c calendarctl.Context) string {
+	switch {
+	case c.CertFile != "":
+		return "mutual"
+	case c.TLS || c.CAFile != "":
+		return "yes"
+	default:
+		return "no"
+	}
+}
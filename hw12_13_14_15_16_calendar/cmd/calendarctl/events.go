@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/calendarctl"
+	"github.com/google/uuid"
+)
+
+// eventFlags define an event either by a file or by separate flags.
+type eventFlags struct {
+	file        string
+	title       string
+	user        string
+	start       string
+	end         string
+	description string
+	notify      time.Duration
+}
+
+func (f *eventFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.file, "f", "", "File with an event or a list of events, \"-\" for standard input")
+	fs.StringVar(&f.title, "title", "", "Event title")
+	fs.StringVar(&f.user, "user", "", "Owner user ID")
+	fs.StringVar(&f.start, "start", "", "Start time")
+	fs.StringVar(&f.end, "end", "", "End time")
+	fs.StringVar(&f.description, "description", "", "Event description")
+	fs.DurationVar(&f.notify, "notify", 0, "Notify this long before the start, 0 - at the start")
+}
+
+// events returns the events of the file or the single event described by the flags.
+func (f *eventFlags) events() ([]calendarctl.Event, error) {
+	if f.file != "" {
+		return calendarctl.ReadEventsFile(f.file)
+	}
+	if f.title == "" || f.user == "" || f.start == "" || f.end == "" {
+		return nil, errors.New("either -f or -title, -user, -start and -end are required")
+	}
+
+	userID, err := uuid.Parse(f.user)
+	if err != nil {
+		return nil, fmt.Errorf("-user: %w", err)
+	}
+	start, err := calendarctl.ParseTime(f.start)
+	if err != nil {
+		return nil, fmt.Errorf("-start: %w", err)
+	}
+	end, err := calendarctl.ParseTime(f.end)
+	if err != nil {
+		return nil, fmt.Errorf("-end: %w", err)
+	}
+	return []calendarctl.Event{{
+		UserID:      userID,
+		Title:       f.title,
+		Start:       start,
+		End:         end,
+		Description: f.description,
+		TimeBefore:  int64(f.notify / time.Second),
+	}}, nil
+}
+
+func runCreate(ctx context.Context, client *calendarctl.Client, args []string) error {
+	fs := newFlagSet("create")
+	var ef eventFlags
+	ef.register(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return errUsage
+	}
+
+	events, err := ef.events()
+	if err != nil {
+		return err
+	}
+	for i := range events {
+		if events[i].ID == uuid.Nil {
+			events[i].ID = uuid.New()
+		}
+	}
+	return apply(ctx, events, client.Create)
+}
+
+func runUpdate(ctx context.Context, client *calendarctl.Client, args []string) error {
+	fs := newFlagSet("update")
+	var ef eventFlags
+	ef.register(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return errUsage
+	}
+
+	events, err := ef.events()
+	if err != nil {
+		return err
+	}
+	if len(positional) == 1 {
+		id, err := uuid.Parse(positional[0])
+		if err != nil {
+			return fmt.Errorf("event ID: %w", err)
+		}
+		if len(events) != 1 {
+			return errors.New("an event ID is given, but the input holds several events")
+		}
+		events[0].ID = id
+	}
+	for _, e := range events {
+		if e.ID == uuid.Nil {
+			return fmt.Errorf("event %q has no ID", e.Title)
+		}
+	}
+	return apply(ctx, events, client.Update)
+}
+
+// apply calls op for every event in order, printing the processed events
+// even if one of them fails.
+func apply(ctx context.Context, events []calendarctl.Event, op func(context.Context, calendarctl.Event) error) error {
+	done := make([]calendarctl.Event, 0, len(events))
+	var opErr error
+	for _, e := range events {
+		if err := op(ctx, e); err != nil {
+			opErr = fmt.Errorf("event %s: %w", e.ID, err)
+			break
+		}
+		done = append(done, e)
+	}
+	if len(done) > 0 || opErr == nil {
+		if err := calendarctl.WriteEvents(os.Stdout, output, done); err != nil {
+			return err
+		}
+	}
+	return opErr
+}
+
+func runDelete(ctx context.Context, client *calendarctl.Client, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	ids := make([]uuid.UUID, 0, len(args))
+	for _, arg := range args {
+		id, err := uuid.Parse(arg)
+		if err != nil {
+			return fmt.Errorf("event ID %q: %w", arg, err)
+		}
+		ids = append(ids, id)
+	}
+
+	for _, id := range ids {
+		if err := client.Delete(ctx, id); err != nil {
+			return fmt.Errorf("event %s: %w", id, err)
+		}
+		fmt.Printf("deleted %s\n", id)
+	}
+	return nil
+}
+
+func runList(ctx context.Context, client *calendarctl.Client, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	period := args[0]
+
+	fs := newFlagSet("list " + period)
+	var startFlag, fromFlag, toFlag string
+	if period == "range" {
+		fs.StringVar(&fromFlag, "from", "", "Start of the range")
+		fs.StringVar(&toFlag, "to", "", "End of the range")
+	} else {
+		fs.StringVar(&startFlag, "start", "", "Start of the period, today by default")
+	}
+	positional, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return errUsage
+	}
+
+	var events []calendarctl.Event
+	if period == "range" {
+		if fromFlag == "" || toFlag == "" {
+			return errors.New("-from and -to are required")
+		}
+		from, err := calendarctl.ParseTime(fromFlag)
+		if err != nil {
+			return fmt.Errorf("-from: %w", err)
+		}
+		to, err := calendarctl.ParseTime(toFlag)
+		if err != nil {
+			return fmt.Errorf("-to: %w", err)
+		}
+		events, err = client.ListRange(ctx, from, to)
+		if err != nil {
+			return err
+		}
+	} else {
+		y, m, d := time.Now().Date()
+		start := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+		if startFlag != "" {
+			if start, err = calendarctl.ParseTime(startFlag); err != nil {
+				return fmt.Errorf("-start: %w", err)
+			}
+		}
+		events, err = client.List(ctx, period, start)
+		if err != nil {
+			return err
+		}
+	}
+	return calendarctl.WriteEvents(os.Stdout, output, events)
+}
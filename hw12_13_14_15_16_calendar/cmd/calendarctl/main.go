@@ -0,0 +1,155 @@
+// Command calendarctl manages events of the calendar service through its gRPC API.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/calendarctl"
+)
+
+const usageText = `Usage: calendarctl [flags] <command> [arguments]
+
+Commands:
+  create -f FILE | -title T -user ID -start TIME -end TIME [-description D] [-notify 15m]
+  update [ID] -f FILE | ID -title T -user ID -start TIME -end TIME [-description D] [-notify 15m]
+  delete ID...
+  list day|week|month [-start TIME]
+  list range -from TIME -to TIME
+  context list | current | use NAME | delete NAME
+  context set NAME [-server ADDR] [-tls] [-ca FILE] [-cert FILE -key FILE] [-server-name NAME]
+  version
+
+FILE holds an event or a list of events in JSON or YAML, "-" reads standard input.
+TIME is RFC 3339, "2006-01-02 15:04" or a date in local time.
+
+Flags:
+`
+
+var errUsage = errors.New("invalid arguments, run calendarctl -h for usage")
+
+var (
+	configPath  string
+	contextName string
+	server      string
+	output      string
+	timeout     time.Duration
+)
+
+func init() {
+	flag.StringVar(&configPath, "config", calendarctl.DefaultConfigPath(), "Path to the contexts file")
+	flag.StringVar(&contextName, "context", "", "Context to use instead of the current one")
+	flag.StringVar(&server, "server", "", "Server address overriding the one of the context")
+	flag.StringVar(&output, "o", calendarctl.FormatTable, "Output format: table, json or yaml")
+	flag.DurationVar(&timeout, "timeout", 10*time.Second, "Timeout of a command")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usageText)
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", calendarctl.DescribeError(err))
+		stop()
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	if err := calendarctl.ValidateFormat(output); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "version":
+		printVersion()
+		return nil
+	case "context":
+		return runContext(args[1:])
+	case "create", "update", "delete", "list":
+	default:
+		return fmt.Errorf("unknown command %q, run calendarctl -h for usage", args[0])
+	}
+
+	client, closeConn, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch args[0] {
+	case "create":
+		return runCreate(ctx, client, args[1:])
+	case "update":
+		return runUpdate(ctx, client, args[1:])
+	case "delete":
+		return runDelete(ctx, client, args[1:])
+	default:
+		return runList(ctx, client, args[1:])
+	}
+}
+
+// connect dials the server of the selected context.
+func connect() (*calendarctl.Client, func(), error) {
+	cfg, err := calendarctl.LoadConfig(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, c, err := cfg.Resolve(contextName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if server != "" {
+		c.Server = server
+	}
+
+	conn, err := calendarctl.Dial(c)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to %s: %w", c.Server, err)
+	}
+	return calendarctl.NewClient(conn), func() { _ = conn.Close() }, nil
+}
+
+// parseArgs parses flags placed before, between and after positional arguments,
+// which the flag package alone stops at.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of calendarctl %s:\n", strings.TrimSpace(name))
+		fs.PrintDefaults()
+	}
+	return fs
+}
@@ -1,31 +1,97 @@
 package main
 
 import (
-	"github.com/BurntSushi/toml"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/admin"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/config"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/rabbitmq/producer"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/scheduler"
-	"github.com/caarlos0/env/v10"
+	sqlstorage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage/sql"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tracing"
 )
 
 type Config struct {
-	Logger        logger.Config               `toml:"logger" env-prefix:"LOGGER_"`
-	Storage       StorageConf                 `toml:"storage" env-prefix:"STORAGE_"`
-	Notifications scheduler.NotificationsConf `toml:"notifications" env-prefix:"NOTIFICATIONS_"`
-	RabbitMQ      producer.RabbitMQConf       `toml:"rabbitmq" env-prefix:"RABBITMQ_"`
+	Logger        logger.Config               `toml:"logger" envPrefix:"LOGGER_"`
+	Tracing       tracing.Config              `toml:"tracing" envPrefix:"TRACING_"`
+	Storage       StorageConf                 `toml:"storage" envPrefix:"STORAGE_"`
+	Notifications scheduler.NotificationsConf `toml:"notifications" envPrefix:"NOTIFICATIONS_"`
+	RabbitMQ      producer.RabbitMQConf       `toml:"rabbitmq" envPrefix:"RABBITMQ_"`
+	Admin         admin.Config                `toml:"admin" envPrefix:"ADMIN_"`
 }
 
 type StorageConf struct {
-	DSN string `toml:"dsn" env:"DSN"`
+	// Mod — sql (по умолчанию) или sqlite.
+	Mod  string                `toml:"mod" env:"MOD"`
+	DSN  string                `toml:"dsn" env:"DSN" secret:"credentials"`
+	Pool sqlstorage.PoolConfig `toml:"pool" envPrefix:"POOL_"`
+	// Path — файл базы для mod = "sqlite", общий с календарём.
+	Path string `toml:"path" env:"PATH"`
+}
+
+// Validate checks the configuration; errors name the offending key.
+func (c Config) Validate() error {
+	if err := c.Logger.Validate(); err != nil {
+		return fmt.Errorf("logger.%w", err)
+	}
+	if err := c.Tracing.Validate(); err != nil {
+		return fmt.Errorf("tracing.%w", err)
+	}
+	if err := c.Storage.Validate(); err != nil {
+		return fmt.Errorf("storage.%w", err)
+	}
+	if err := c.Notifications.Validate(); err != nil {
+		return fmt.Errorf("notifications.%w", err)
+	}
+	if err := c.RabbitMQ.Validate(); err != nil {
+		return fmt.Errorf("rabbitmq.%w", err)
+	}
+	if err := c.Admin.Validate(); err != nil {
+		return fmt.Errorf("admin.%w", err)
+	}
+	return nil
 }
 
+// Validate checks that the storage is known and has a database.
+func (c StorageConf) Validate() error {
+	switch c.Mod {
+	case "sql":
+		if c.DSN == "" {
+			return errors.New("dsn: required")
+		}
+		if err := c.Pool.Validate(); err != nil {
+			return fmt.Errorf("pool.%w", err)
+		}
+	case "sqlite":
+		if c.Path == "" {
+			return errors.New("path: required by sqlite storage")
+		}
+	default:
+		return fmt.Errorf("mod: unknown storage %q, want sql or sqlite", c.Mod)
+	}
+	return nil
+}
+
+// NewConfig reads the configuration file, applies environment overrides and validates the result.
 func NewConfig() (Config, error) {
-	var cfg Config
-	if _, err := toml.DecodeFile(configFile, &cfg); err != nil {
-		return Config{}, err
+	cfg := Config{
+		Tracing: tracing.Config{SampleRatio: tracing.DefaultSampleRatio},
+		Storage: StorageConf{Mod: "sql"},
 	}
-	if err := env.Parse(&cfg); err != nil {
-		return cfg, err
+	if err := config.Load(configFile, &cfg); err != nil {
+		return Config{}, err
 	}
 	return cfg, nil
 }
+
+// checkConfig prints the effective configuration with secrets masked.
+func checkConfig(w io.Writer) error {
+	cfg, err := NewConfig()
+	if err != nil {
+		return err
+	}
+	return config.Write(w, cfg)
+}
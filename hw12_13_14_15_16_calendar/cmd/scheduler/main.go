@@ -6,13 +6,19 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/health"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/rabbitmq/producer"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/reload"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/scheduler"
 )
 
+// serviceName identifies the service in traces.
+const serviceName = "calendar-scheduler"
+
 var configFile string
 
 func init() {
@@ -25,12 +31,21 @@ func main() {
 		printVersion()
 		return
 	}
+	if flag.Arg(0) == "config" {
+		// Проверка конфигурации без запуска сервиса, например перед выкладкой
+		if flag.Arg(1) != "check" {
+			log.Fatalf("unknown command %q, want \"config check\"", strings.Join(flag.Args(), " "))
+		}
+		if err := checkConfig(os.Stdout); err != nil {
+			log.Fatalf("config %s: %v", configFile, err)
+		}
+		return
+	}
 
 	log.SetOutput(os.Stdout)
 	cfg, err := NewConfig()
 	if err != nil {
-		log.Printf("error initializing config: %v", err)
-		return
+		log.Fatalf("error initializing config: %v", err)
 	}
 	lg, closer, err := logger.NewLogger(cfg.Logger)
 	if err != nil {
@@ -42,7 +57,13 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	storage, closer, err := setupStorage(ctx, cfg, lg)
+	stopTracing := setupTracing(ctx, cfg.Tracing)
+	defer stopTracing()
+
+	// Проверки регистрируются по мере создания компонентов
+	checker := health.New(0)
+
+	storage, closer, err := setupStorage(ctx, cfg, lg, checker)
 	if err != nil {
 		log.Printf("error initializing storage: %v", err)
 		return
@@ -56,12 +77,20 @@ func main() {
 		}
 	}()
 
+	adminServer := startAdminServer(cfg.Admin, lg, checker)
+	defer stopAdminServer(adminServer)
+
 	producer, err := producer.NewRabbitProducer(ctx, cfg.RabbitMQ, lg)
 	if err != nil {
 		return
 	}
 
+	checker.AddReadiness("rabbitmq", producer.Check)
+
 	scheduler := scheduler.NewScheduler(lg, storage, producer, cfg.Notifications)
+	checker.AddLiveness("scheduler", scheduler.Check)
+	reload.Watch(ctx, lg, configReloader(cfg, lg, scheduler))
+	checker.SetReady(true)
 
 	scheduler.Start(ctx)
 	log.Print("scheduler shutdown complete...")
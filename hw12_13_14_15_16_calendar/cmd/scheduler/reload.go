@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/reload"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/scheduler"
+)
+
+// configReloader applies the settings that can change without a restart:
+// log level and format, scheduler tick, event TTL and maximum tick age.
+func configReloader(current Config, lg *slog.Logger, s *scheduler.Scheduler) reload.Func {
+	return func(ctx context.Context) error {
+		next, err := NewConfig()
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(current.fixed(), next.fixed()) {
+			lg.WarnContext(ctx, "only log level, log format and scheduler timing are reloaded, "+
+				"restart to apply other changes")
+		}
+
+		if err := logger.Reconfigure(lg, next.Logger.Level, next.Logger.JSON); err != nil {
+			return err
+		}
+		notifications := next.Notifications
+		// Формат уведомлений меняется только при перезапуске
+		notifications.Encoding = current.Notifications.Encoding
+		s.Reconfigure(notifications)
+
+		current.Logger.Level, current.Logger.JSON = next.Logger.Level, next.Logger.JSON
+		current.Notifications = notifications
+		return nil
+	}
+}
+
+// fixed returns the configuration without the settings applied on reload.
+func (c Config) fixed() Config {
+	c.Logger.Level, c.Logger.JSON = "", false
+	c.Notifications.Tick, c.Notifications.EventTTL, c.Notifications.MaxTickAge = 0, 0, 0
+	return c
+}
@@ -7,14 +7,27 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/admin"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/health"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/scheduler"
 	sqlstorage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage/sql"
+	sqlitestorage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage/sqlite"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tracing"
 )
 
-func setupStorage(ctx context.Context, cfg Config, lg *slog.Logger) (scheduler.Storage, io.Closer, error) {
+func setupStorage(
+	ctx context.Context,
+	cfg Config,
+	lg *slog.Logger,
+	checker *health.Checker,
+) (scheduler.Storage, io.Closer, error) {
+	if cfg.Storage.Mod == "sqlite" {
+		return openSQLite(ctx, cfg.Storage.Path, lg, checker)
+	}
+
 	log.Print("initializing connection to PostgreSQL...")
 
-	sqlStorage := sqlstorage.New(lg, cfg.Storage.DSN)
+	sqlStorage := sqlstorage.New(lg, cfg.Storage.DSN, cfg.Storage.Pool)
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -23,6 +36,83 @@ func setupStorage(ctx context.Context, cfg Config, lg *slog.Logger) (scheduler.S
 		return nil, nil, err
 	}
 
+	checker.AddReadiness("postgres", sqlStorage.Ping)
 	log.Print("sql storage initialized and connected successfully")
 	return sqlStorage, sqlStorage, nil
 }
+
+// openSQLite opens the database file shared with the calendar and applies
+// migrations, so the scheduler does not depend on the calendar starting first.
+func openSQLite(
+	ctx context.Context,
+	path string,
+	lg *slog.Logger,
+	checker *health.Checker,
+) (scheduler.Storage, io.Closer, error) {
+	log.Printf("opening SQLite database %s...", path)
+
+	sqliteStorage := sqlitestorage.New(lg, path)
+	if err := sqliteStorage.Connect(ctx); err != nil {
+		log.Printf("error opening SQLite database: %v", err)
+		return nil, nil, err
+	}
+	if err := sqliteStorage.Migrate(ctx); err != nil {
+		log.Print(err)
+		sqliteStorage.Close()
+		return nil, nil, err
+	}
+
+	checker.AddReadiness("sqlite", sqliteStorage.Ping)
+	log.Print("SQLite storage successfully initialized")
+	return sqliteStorage, sqliteStorage, nil
+}
+
+func startAdminServer(cfg admin.Config, lg *slog.Logger, checker *health.Checker) *admin.Server {
+	if cfg.Port == 0 {
+		log.Print("admin server disabled")
+		return nil
+	}
+
+	srv := admin.NewServer(cfg, lg)
+	checker.Register(srv)
+	go func() {
+		if err := srv.Start(); err != nil {
+			log.Printf("admin server error: %v", err)
+		}
+	}()
+	return srv
+}
+
+func stopAdminServer(srv *admin.Server) {
+	if srv == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := srv.Stop(ctx); err != nil {
+		log.Printf("[shutdown] error stopping admin server: %v", err)
+		return
+	}
+	log.Print("[shutdown] admin server stopped")
+}
+
+func setupTracing(ctx context.Context, cfg tracing.Config) func() {
+	shutdown, err := tracing.Setup(ctx, cfg, serviceName)
+	if err != nil {
+		log.Printf("error initializing tracing, spans will not be exported: %v", err)
+		return func() {}
+	}
+	if cfg.Exporter != "" && cfg.Exporter != tracing.ExporterNone {
+		log.Printf("tracing enabled, exporter: %s", cfg.Exporter)
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			log.Printf("[shutdown] error flushing spans: %v", err)
+		}
+	}
+}
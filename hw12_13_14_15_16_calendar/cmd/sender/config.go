@@ -1,24 +1,69 @@
 package main
 
 import (
-	"github.com/BurntSushi/toml"
+	"fmt"
+	"io"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/admin"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/config"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/rabbitmq/consumer"
-	"github.com/caarlos0/env/v10"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/sender"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tracing"
 )
 
 type Config struct {
-	Logger   logger.Config         `toml:"logger" env-prefix:"LOGGER_"`
-	RabbitMQ consumer.RabbitMQConf `toml:"rabbitmq" env-prefix:"RABBITMQ_"`
+	Logger      logger.Config         `toml:"logger" envPrefix:"LOGGER_"`
+	Tracing     tracing.Config        `toml:"tracing" envPrefix:"TRACING_"`
+	RabbitMQ    consumer.RabbitMQConf `toml:"rabbitmq" envPrefix:"RABBITMQ_"`
+	SMTP        sender.SMTPConf       `toml:"smtp" envPrefix:"SMTP_"`
+	Webhook     sender.WebhookConf    `toml:"webhook" envPrefix:"WEBHOOK_"`
+	Preferences []sender.Preference   `toml:"preferences"`
+	Admin       admin.Config          `toml:"admin" envPrefix:"ADMIN_"`
+}
+
+// Validate checks the configuration; errors name the offending key.
+func (c Config) Validate() error {
+	if err := c.Logger.Validate(); err != nil {
+		return fmt.Errorf("logger.%w", err)
+	}
+	if err := c.Tracing.Validate(); err != nil {
+		return fmt.Errorf("tracing.%w", err)
+	}
+	if err := c.RabbitMQ.Validate(); err != nil {
+		return fmt.Errorf("rabbitmq.%w", err)
+	}
+	if err := c.SMTP.Validate(); err != nil {
+		return fmt.Errorf("smtp.%w", err)
+	}
+	if err := c.Webhook.Validate(); err != nil {
+		return fmt.Errorf("webhook.%w", err)
+	}
+	for i, p := range c.Preferences {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("preferences[%d].%w", i, err)
+		}
+	}
+	if err := c.Admin.Validate(); err != nil {
+		return fmt.Errorf("admin.%w", err)
+	}
+	return nil
 }
 
+// NewConfig reads the configuration file, applies environment overrides and validates the result.
 func NewConfig() (Config, error) {
-	var cfg Config
-	if _, err := toml.DecodeFile(configFile, &cfg); err != nil {
+	cfg := Config{Tracing: tracing.Config{SampleRatio: tracing.DefaultSampleRatio}}
+	if err := config.Load(configFile, &cfg); err != nil {
 		return Config{}, err
 	}
-	if err := env.Parse(&cfg); err != nil {
-		return cfg, err
-	}
 	return cfg, nil
 }
+
+// checkConfig prints the effective configuration with secrets masked.
+func checkConfig(w io.Writer) error {
+	cfg, err := NewConfig()
+	if err != nil {
+		return err
+	}
+	return config.Write(w, cfg)
+}
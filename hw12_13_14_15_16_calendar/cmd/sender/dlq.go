@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/rabbitmq/consumer"
+)
+
+var errDLQUsage = errors.New("usage: sender dlq list|replay [-limit N]")
+
+// runDLQ executes the "dlq list" and "dlq replay" subcommands.
+func runDLQ(ctx context.Context, cfg Config, lg *slog.Logger, args []string) error {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "replay") {
+		return errDLQUsage
+	}
+
+	fs := flag.NewFlagSet("dlq "+args[0], flag.ContinueOnError)
+	limit := fs.Int("limit", 0, "maximum number of messages to process (0 - all)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	dlq, err := consumer.OpenDeadLetterQueue(cfg.RabbitMQ, lg)
+	if err != nil {
+		return err
+	}
+	defer dlq.Close()
+
+	switch args[0] {
+	case "list":
+		messages, err := dlq.List(ctx, *limit)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		for _, m := range messages {
+			if err := enc.Encode(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "replay":
+		n, err := dlq.Replay(ctx, *limit)
+		fmt.Printf("replayed %d messages\n", n)
+		return err
+	default:
+		return errDLQUsage
+	}
+}
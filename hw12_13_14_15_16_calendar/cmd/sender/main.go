@@ -7,13 +7,19 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/health"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/rabbitmq/consumer"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/reload"
 )
 
+// serviceName identifies the service in traces.
+const serviceName = "calendar-sender"
+
 var configFile string
 
 func init() {
@@ -26,12 +32,25 @@ func main() {
 		printVersion()
 		return
 	}
+	if flag.Arg(0) == "config" {
+		// Проверка конфигурации без запуска сервиса, например перед выкладкой
+		if flag.Arg(1) != "check" {
+			log.Fatalf("unknown command %q, want \"config check\"", strings.Join(flag.Args(), " "))
+		}
+		if err := checkConfig(os.Stdout); err != nil {
+			log.Fatalf("config %s: %v", configFile, err)
+		}
+		return
+	}
+	// Опечатка в команде не должна запускать рассыльщик
+	if flag.NArg() > 0 && flag.Arg(0) != "dlq" {
+		log.Fatalf("unknown command %q", strings.Join(flag.Args(), " "))
+	}
 
 	// ---------- настройка логирования ----------
 	cfg, err := NewConfig()
 	if err != nil {
-		log.Printf("error initializing config: %v", err)
-		return
+		log.Fatalf("error initializing config: %v", err)
 	}
 	lg, closer, err := logger.NewLogger(cfg.Logger)
 	if err != nil {
@@ -45,27 +64,54 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	stopTracing := setupTracing(ctx, cfg.Tracing)
+	defer stopTracing()
+
+	if flag.Arg(0) == "dlq" {
+		if err := runDLQ(ctx, cfg, lg, flag.Args()[1:]); err != nil {
+			log.Printf("dlq: %v", err)
+			stop()
+			os.Exit(1)
+		}
+		return
+	}
+
+	reload.Watch(ctx, lg, configReloader(cfg, lg))
+
+	checker := health.New(0)
+	adminServer := startAdminServer(cfg.Admin, lg, checker)
+	defer stopAdminServer(adminServer)
+
+	dispatcher, err := setupDispatcher(cfg, lg)
+	if err != nil {
+		log.Printf("cannot create dispatcher: %v", err)
+		return
+	}
+
 	cons, err := consumer.NewRabbitConsumer(ctx, cfg.RabbitMQ, lg)
 	if err != nil {
 		log.Printf("cannot create consumer: %v", err)
 		return
 	}
+	checker.AddReadiness("rabbitmq", cons.Check)
+	checker.SetReady(true)
 
 	// ---------- запуск consumer в отдельной горутине ----------
 	go func() {
-		if err := cons.Handle(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		if err := cons.Handle(ctx, dispatcher); err != nil && !errors.Is(err, context.Canceled) {
 			log.Printf("consumer error: %v", err)
 		}
 	}()
 
 	// ждём SIGINT/SIGTERM
 	<-ctx.Done()
+	checker.SetReady(false)
 
 	// ---------- даём времени на корректное закрытие ----------
-	_, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := cons.Shutdown(); err != nil {
+	if err := cons.Shutdown(shutdownCtx); err != nil {
 		log.Printf("error during shutdown: %v", err)
 	}
 
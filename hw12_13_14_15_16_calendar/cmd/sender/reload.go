@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/reload"
+)
+
+// configReloader applies the settings that can change without a restart: log level and format.
+func configReloader(current Config, lg *slog.Logger) reload.Func {
+	return func(ctx context.Context) error {
+		next, err := NewConfig()
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(current.fixed(), next.fixed()) {
+			lg.WarnContext(ctx, "only log level and format are reloaded, restart to apply other changes")
+		}
+
+		if err := logger.Reconfigure(lg, next.Logger.Level, next.Logger.JSON); err != nil {
+			return err
+		}
+		current.Logger.Level, current.Logger.JSON = next.Logger.Level, next.Logger.JSON
+		return nil
+	}
+}
+
+// fixed returns the configuration without the settings applied on reload.
+func (c Config) fixed() Config {
+	c.Logger.Level, c.Logger.JSON = "", false
+	return c
+}
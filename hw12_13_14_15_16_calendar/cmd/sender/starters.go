@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/admin"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/health"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/sender"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tracing"
+)
+
+func setupDispatcher(cfg Config, lg *slog.Logger) (*sender.Dispatcher, error) {
+	var email sender.Notifier
+	if cfg.SMTP.Host != "" {
+		smtpNotifier, err := sender.NewSMTPNotifier(cfg.SMTP, lg)
+		if err != nil {
+			return nil, err
+		}
+		email = smtpNotifier
+		log.Printf("SMTP notifier configured: %s:%d", cfg.SMTP.Host, cfg.SMTP.Port)
+	} else {
+		log.Print("SMTP is not configured, email notifications disabled")
+	}
+
+	webhook := sender.NewWebhookNotifier(cfg.Webhook, lg)
+
+	log.Printf("delivery preferences loaded for %d users", len(cfg.Preferences))
+	return sender.NewDispatcher(lg, cfg.Preferences, email, webhook), nil
+}
+
+func startAdminServer(cfg admin.Config, lg *slog.Logger, checker *health.Checker) *admin.Server {
+	if cfg.Port == 0 {
+		log.Print("admin server disabled")
+		return nil
+	}
+
+	srv := admin.NewServer(cfg, lg)
+	checker.Register(srv)
+	go func() {
+		if err := srv.Start(); err != nil {
+			log.Printf("admin server error: %v", err)
+		}
+	}()
+	return srv
+}
+
+func stopAdminServer(srv *admin.Server) {
+	if srv == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := srv.Stop(ctx); err != nil {
+		log.Printf("[shutdown] error stopping admin server: %v", err)
+		return
+	}
+	log.Print("[shutdown] admin server stopped")
+}
+
+func setupTracing(ctx context.Context, cfg tracing.Config) func() {
+	shutdown, err := tracing.Setup(ctx, cfg, serviceName)
+	if err != nil {
+		log.Printf("error initializing tracing, spans will not be exported: %v", err)
+		return func() {}
+	}
+	if cfg.Exporter != "" && cfg.Exporter != tracing.ExporterNone {
+		log.Printf("tracing enabled, exporter: %s", cfg.Exporter)
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			log.Printf("[shutdown] error flushing spans: %v", err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Package admin реализует служебный HTTP-сервер без TLS для метрик и проб Kubernetes.
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/metrics"
+)
+
+// Config defines the address of the admin listener. Zero port disables it.
+type Config struct {
+	Host string `toml:"host" env:"HOST"`
+	Port int    `toml:"port" env:"PORT"`
+}
+
+// Validate checks that the port is in range.
+func (c Config) Validate() error {
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("port: must be between 0 and 65535, got %d", c.Port)
+	}
+	return nil
+}
+
+// Server serves metrics and other service endpoints.
+type Server struct {
+	mux        *http.ServeMux
+	httpServer *http.Server
+	logger     *slog.Logger
+}
+
+func (s *Server) setLogCompMeth(ctx context.Context, method string) context.Context {
+	ctx = logger.WithLogComponent(ctx, "admin")
+	return logger.WithLogMethod(ctx, method)
+}
+
+// NewServer creates an admin server exposing /metrics.
+func NewServer(cfg Config, logger *slog.Logger) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+
+	return &Server{
+		mux: mux,
+		httpServer: &http.Server{
+			Addr:              fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+		logger: logger,
+	}
+}
+
+// Handle registers an additional handler.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Handler returns http.Handler used by the server.
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Start runs the admin server until Stop is called.
+func (s *Server) Start() error {
+	ctx := s.setLogCompMeth(context.Background(), "Start")
+	s.logger.InfoContext(ctx, "admin server starting", "addr", s.httpServer.Addr)
+
+	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return logger.AddPrefix(ctx, fmt.Errorf("listen: %w", err))
+	}
+	return nil
+}
+
+// Stop gracefully shuts down the admin server.
+func (s *Server) Stop(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
@@ -0,0 +1,23 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Metrics(t *testing.T) {
+	s := NewServer(Config{}, logger.New("debug", os.Stdout, false))
+	metrics.ObserveConnection("test", true)
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `calendar_rabbitmq_connected{component="test"} 1`)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/watch"
 	"github.com/google/uuid"
 )
 
@@ -14,6 +15,7 @@ import (
 type App struct {
 	storage Storage
 	logger  *slog.Logger
+	changes *watch.Bus
 }
 
 func (a *App) setLogCompMeth(ctx context.Context, method string) context.Context {
@@ -25,17 +27,25 @@ func (a *App) setLogCompMeth(ctx context.Context, method string) context.Context
 type Storage interface {
 	CreateEvent(context.Context, storage.Event) error
 	UpdateEvent(context.Context, uuid.UUID, storage.Event) error
-	DeleteEvent(context.Context, uuid.UUID) error
+	DeleteEvent(context.Context, uuid.UUID) (storage.Event, error)
 	GetEventsDay(context.Context, time.Time) ([]storage.Event, error)
 	GetEventsWeek(context.Context, time.Time) ([]storage.Event, error)
 	GetEventsMonth(context.Context, time.Time) ([]storage.Event, error)
 }
 
+// Параметры шины изменений: сколько изменений хранить для возобновления
+// и на сколько может отстать подписчик.
+const (
+	changesJournalSize = 1024
+	changesBuffer      = 64
+)
+
 // New creates a new App instance.
 func New(logger *slog.Logger, storage Storage) *App {
 	return &App{
 		logger:  logger,
 		storage: storage,
+		changes: watch.NewBus(changesJournalSize, changesBuffer),
 	}
 }
 
@@ -50,6 +60,7 @@ func (a *App) CreateEvent(ctx context.Context, event storage.Event) error {
 	if err != nil {
 		return logger.AddPrefix(ctx, err)
 	}
+	a.changes.Publish(watch.Created, event)
 	a.logger.InfoContext(ctx, "event created successfully")
 	return nil
 }
@@ -65,6 +76,8 @@ func (a *App) UpdateEvent(ctx context.Context, id uuid.UUID, event storage.Event
 	if err != nil {
 		return logger.AddPrefix(ctx, err)
 	}
+	event.ID = id
+	a.changes.Publish(watch.Updated, event)
 	a.logger.InfoContext(ctx, "event updated successfully")
 	return nil
 }
@@ -76,10 +89,12 @@ func (a *App) DeleteEvent(ctx context.Context, id uuid.UUID) error {
 	if err := ctx.Err(); err != nil {
 		return logger.AddPrefix(ctx, err)
 	}
-	err := a.storage.DeleteEvent(ctx, id)
+	event, err := a.storage.DeleteEvent(ctx, id)
 	if err != nil {
 		return logger.AddPrefix(ctx, err)
 	}
+	// Владелец и время нужны, чтобы удаление получили только подписчики этих событий
+	a.changes.Publish(watch.Deleted, event)
 	a.logger.InfoContext(ctx, "event deleted successfully")
 	return nil
 }
@@ -122,3 +137,20 @@ func (a *App) GetEventsMonth(ctx context.Context, start time.Time) ([]storage.Ev
 	a.logger.InfoContext(ctx, "events retrieved successfully", "count", len(events))
 	return events, nil
 }
+
+// WatchEvents subscribes to changes of events matching filter, resuming after token if it is set.
+func (a *App) WatchEvents(ctx context.Context, filter watch.Filter, token string) (<-chan watch.Change, error) {
+	ctx = a.setLogCompMeth(ctx, "WatchEvents")
+	a.logger.DebugContext(ctx, "attempting to subscribe to event changes")
+	changes, err := a.changes.Subscribe(ctx, filter, token)
+	if err != nil {
+		return nil, logger.AddPrefix(ctx, err)
+	}
+	a.logger.InfoContext(ctx, "subscribed to event changes")
+	return changes, nil
+}
+
+// Close disconnects watchers of event changes.
+func (a *App) Close() {
+	a.changes.Close()
+}
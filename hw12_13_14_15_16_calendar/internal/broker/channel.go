@@ -0,0 +1,35 @@
+package broker
+
+import "context"
+
+// Exchange kinds supported by every broker.
+const (
+	ExchangeDirect = "direct"
+	ExchangeFanout = "fanout"
+	ExchangeTopic  = "topic"
+)
+
+// Channel is a session with a message broker. Producers and consumers work through it,
+// so the same code runs over RabbitMQ and over the in-memory broker.
+type Channel interface {
+	// DeclareExchange creates a durable exchange of the kind if it does not exist.
+	DeclareExchange(name, kind string) error
+	// DeclareQueue creates a durable queue if it does not exist.
+	DeclareQueue(name string, opts QueueOptions) error
+	// BindQueue routes messages published to the exchange with a matching key to the queue.
+	BindQueue(queue, key, exchange string) error
+	// Publish sends the message. The confirmation resolves once the broker accepts it.
+	Publish(ctx context.Context, exchange, routingKey string, msg Message) (*Confirmation, error)
+	// Consume delivers messages of the queue until ctx is done. The returned channel
+	// is closed then or when the session is lost; deliveries not yet received are requeued.
+	Consume(ctx context.Context, queue, consumerTag string) (<-chan Delivery, error)
+}
+
+// QueueOptions configures a declared queue.
+type QueueOptions struct {
+	// DeadLetterExchange and DeadLetterRoutingKey route messages that are rejected
+	// without requeue or expire. Dead-lettering is disabled without a routing key;
+	// the empty exchange is the default one, routing to the queue named by the key.
+	DeadLetterExchange   string
+	DeadLetterRoutingKey string
+}
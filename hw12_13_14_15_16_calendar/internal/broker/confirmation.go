@@ -0,0 +1,67 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotConfirmed is returned when the broker rejects a published message.
+	ErrNotConfirmed = errors.New("message was not acknowledged by broker")
+	// ErrConfirmTimeout is returned when no confirmation arrives in time.
+	ErrConfirmTimeout = errors.New("timeout waiting for confirmation")
+)
+
+// Confirmation reports the outcome of an asynchronous publish.
+type Confirmation struct {
+	done     chan struct{}
+	once     sync.Once
+	err      error
+	deadline time.Time
+}
+
+// NewConfirmation creates an unresolved confirmation. Wait fails with
+// ErrConfirmTimeout after timeout unless it is zero.
+func NewConfirmation(timeout time.Duration) *Confirmation {
+	c := &Confirmation{done: make(chan struct{})}
+	if timeout > 0 {
+		c.deadline = time.Now().Add(timeout)
+	}
+	return c
+}
+
+// Confirmed returns a confirmation that is already resolved successfully.
+func Confirmed() *Confirmation {
+	c := NewConfirmation(0)
+	c.Resolve(nil)
+	return c
+}
+
+// Resolve sets the outcome of the publish. Only the first call has effect.
+func (c *Confirmation) Resolve(err error) {
+	c.once.Do(func() {
+		c.err = err
+		close(c.done)
+	})
+}
+
+// Wait blocks until the confirmation is resolved, times out or ctx is done.
+func (c *Confirmation) Wait(ctx context.Context) error {
+	var timeout <-chan time.Time
+	if !c.deadline.IsZero() {
+		timer := time.NewTimer(time.Until(c.deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-c.done:
+		return c.err
+	case <-timeout:
+		return ErrConfirmTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
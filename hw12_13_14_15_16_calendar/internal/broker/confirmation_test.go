@@ -0,0 +1,28 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmation(t *testing.T) {
+	ctx := context.Background()
+
+	require.NoError(t, Confirmed().Wait(ctx))
+
+	boom := errors.New("boom")
+	c := NewConfirmation(time.Second)
+	c.Resolve(boom)
+	c.Resolve(nil)
+	require.ErrorIs(t, c.Wait(ctx), boom)
+
+	require.ErrorIs(t, NewConfirmation(10*time.Millisecond).Wait(ctx), ErrConfirmTimeout)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, NewConfirmation(0).Wait(cancelled), context.Canceled)
+}
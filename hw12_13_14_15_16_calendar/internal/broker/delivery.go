@@ -0,0 +1,32 @@
+package broker
+
+// Acknowledger settles deliveries by their tag.
+type Acknowledger interface {
+	Ack(tag uint64) error
+	Nack(tag uint64, requeue bool) error
+}
+
+// Delivery is a message received from a queue. It must be settled with Ack or Nack.
+type Delivery struct {
+	Message
+	Exchange    string
+	RoutingKey  string
+	DeliveryTag uint64
+	// Redelivered is set when the message was requeued after a previous delivery.
+	Redelivered bool
+	// DeliveryCount is the number of times the message was delivered, starting from 1,
+	// or zero if the broker does not count deliveries.
+	DeliveryCount int
+	Acknowledger  Acknowledger
+}
+
+// Ack confirms that the delivery was processed.
+func (d Delivery) Ack() error {
+	return d.Acknowledger.Ack(d.DeliveryTag)
+}
+
+// Nack rejects the delivery. With requeue it is delivered again,
+// otherwise it is dead-lettered or dropped.
+func (d Delivery) Nack(requeue bool) error {
+	return d.Acknowledger.Nack(d.DeliveryTag, requeue)
+}
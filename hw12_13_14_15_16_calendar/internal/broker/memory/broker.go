@@ -0,0 +1,226 @@
+// Package memorybroker реализует брокер сообщений в памяти процесса
+// для тестов: связка планировщик → рассыльщик проверяется без RabbitMQ.
+package memorybroker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+)
+
+var (
+	// ErrExchangeNotFound is returned for operations on an undeclared exchange.
+	ErrExchangeNotFound = errors.New("exchange not found")
+	// ErrQueueNotFound is returned for operations on an undeclared queue.
+	ErrQueueNotFound = errors.New("queue not found")
+	// ErrExchangeKind is returned for unknown exchange kinds or when an exchange
+	// is redeclared with another kind.
+	ErrExchangeKind = errors.New("invalid exchange kind")
+	// ErrUnknownDeliveryTag is returned when a delivery is settled twice.
+	ErrUnknownDeliveryTag = errors.New("unknown delivery tag")
+	// ErrClosed is returned after the broker has been closed.
+	ErrClosed = errors.New("broker closed")
+)
+
+type binding struct {
+	queue string
+	key   string
+}
+
+// Broker is an in-process message broker with RabbitMQ-like routing.
+// The default exchange "" routes messages to the queue named by the routing key.
+// It is a broker.Channel, so producers and consumers run over it without RabbitMQ.
+type Broker struct {
+	mu        sync.RWMutex
+	exchanges map[string]string
+	bindings  map[string][]binding
+	queues    map[string]*queue
+	closed    chan struct{}
+	closeOnce sync.Once
+	logger    *slog.Logger
+}
+
+var _ broker.Channel = (*Broker)(nil)
+
+func (b *Broker) setLogCompMeth(ctx context.Context, method string) context.Context {
+	ctx = logger.WithLogComponent(ctx, "broker.memory")
+	return logger.WithLogMethod(ctx, method)
+}
+
+// New creates an empty broker.
+func New(logger *slog.Logger) *Broker {
+	return &Broker{
+		exchanges: make(map[string]string),
+		bindings:  make(map[string][]binding),
+		queues:    make(map[string]*queue),
+		closed:    make(chan struct{}),
+		logger:    logger,
+	}
+}
+
+// DeclareExchange creates the exchange if it does not exist.
+func (b *Broker) DeclareExchange(name, kind string) error {
+	switch kind {
+	case broker.ExchangeDirect, broker.ExchangeFanout, broker.ExchangeTopic:
+	default:
+		return fmt.Errorf("declare exchange %q: %w: %s", name, ErrExchangeKind, kind)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if existing, ok := b.exchanges[name]; ok && existing != kind {
+		return fmt.Errorf("declare exchange %q: %w: declared as %s", name, ErrExchangeKind, existing)
+	}
+	b.exchanges[name] = kind
+	return nil
+}
+
+// DeclareQueue creates the queue if it does not exist.
+func (b *Broker) DeclareQueue(name string, opts broker.QueueOptions) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.queues[name]; !ok {
+		b.queues[name] = newQueue(b, name, opts)
+	}
+	return nil
+}
+
+// BindQueue routes messages published to the exchange with a matching routing key to the queue.
+func (b *Broker) BindQueue(queueName, key, exchange string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.exchanges[exchange]; !ok {
+		return fmt.Errorf("bind queue %q: %w: %s", queueName, ErrExchangeNotFound, exchange)
+	}
+	if _, ok := b.queues[queueName]; !ok {
+		return fmt.Errorf("bind queue %q: %w", queueName, ErrQueueNotFound)
+	}
+	for _, bnd := range b.bindings[exchange] {
+		if bnd.queue == queueName && bnd.key == key {
+			return nil
+		}
+	}
+	b.bindings[exchange] = append(b.bindings[exchange], binding{queue: queueName, key: key})
+	return nil
+}
+
+// Publish routes the message to all queues bound to the exchange with a matching key.
+// Unroutable messages are dropped, as RabbitMQ does for non-mandatory publishing.
+// Routing is synchronous, so the returned confirmation is already resolved.
+func (b *Broker) Publish(
+	ctx context.Context,
+	exchange, routingKey string,
+	msg broker.Message,
+) (*broker.Confirmation, error) {
+	if err := b.publish(ctx, exchange, routingKey, msg); err != nil {
+		return nil, err
+	}
+	return broker.Confirmed(), nil
+}
+
+func (b *Broker) publish(ctx context.Context, exchange, routingKey string, msg broker.Message) error {
+	ctx = b.setLogCompMeth(ctx, "Publish")
+
+	select {
+	case <-b.closed:
+		return logger.AddPrefix(ctx, ErrClosed)
+	default:
+	}
+
+	queues, err := b.route(exchange, routingKey)
+	if err != nil {
+		return logger.AddPrefix(ctx, err)
+	}
+	if len(queues) == 0 {
+		b.logger.WarnContext(ctx, "message is unroutable", "exchange", exchange, "routing_key", routingKey)
+		return nil
+	}
+
+	for _, q := range queues {
+		q.push(msg, exchange, routingKey)
+	}
+	b.logger.DebugContext(ctx, "message routed", "messageId", msg.ID, "queues", len(queues))
+	return nil
+}
+
+func (b *Broker) route(exchange, routingKey string) ([]*queue, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if exchange == "" {
+		if q, ok := b.queues[routingKey]; ok {
+			return []*queue{q}, nil
+		}
+		return nil, nil
+	}
+
+	kind, ok := b.exchanges[exchange]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrExchangeNotFound, exchange)
+	}
+
+	var (
+		res  []*queue
+		seen = make(map[string]bool)
+	)
+	for _, bnd := range b.bindings[exchange] {
+		if seen[bnd.queue] {
+			continue
+		}
+		matched := false
+		switch kind {
+		case broker.ExchangeFanout:
+			matched = true
+		case broker.ExchangeDirect:
+			matched = bnd.key == routingKey
+		case broker.ExchangeTopic:
+			matched = matchTopic(bnd.key, routingKey)
+		}
+		if matched {
+			seen[bnd.queue] = true
+			res = append(res, b.queues[bnd.queue])
+		}
+	}
+	return res, nil
+}
+
+// Consume starts delivering messages from the queue until ctx is done or the broker is closed.
+// Messages are delivered to competing consumers of the same queue in turn; the consumer tag is ignored.
+func (b *Broker) Consume(ctx context.Context, queueName, _ string) (<-chan broker.Delivery, error) {
+	b.mu.RLock()
+	q, ok := b.queues[queueName]
+	b.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("consume %q: %w", queueName, ErrQueueNotFound)
+	}
+
+	out := make(chan broker.Delivery)
+	go q.consume(ctx, out)
+	return out, nil
+}
+
+// QueueLen returns the number of ready and unacknowledged messages in the queue.
+func (b *Broker) QueueLen(queueName string) (ready, unacked int, err error) {
+	b.mu.RLock()
+	q, ok := b.queues[queueName]
+	b.mu.RUnlock()
+	if !ok {
+		return 0, 0, fmt.Errorf("queue %q: %w", queueName, ErrQueueNotFound)
+	}
+	ready, unacked = q.len()
+	return ready, unacked, nil
+}
+
+// Close stops all consumers. Unacknowledged messages can no longer be settled.
+func (b *Broker) Close() error {
+	b.closeOnce.Do(func() { close(b.closed) })
+	return nil
+}
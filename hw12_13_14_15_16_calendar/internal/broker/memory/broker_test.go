@@ -0,0 +1,200 @@
+package memorybroker
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		match   bool
+	}{
+		{"event.#", "event", true},
+		{"event.#", "event.reminder", true},
+		{"event.#", "event.reminder.email", true},
+		{"event.#", "events.reminder", false},
+		{"event.*", "event.reminder", true},
+		{"event.*", "event", false},
+		{"event.*", "event.reminder.email", false},
+		{"*.reminder", "event.reminder", true},
+		{"#.email", "event.reminder.email", true},
+		{"#", "anything.at.all", true},
+		{"event.#.email", "event.email", true},
+		{"event.#.email", "event.a.b.email", true},
+		{"event.#.email", "event.a.b.sms", false},
+		{"event.reminder", "event.reminder", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.key, func(t *testing.T) {
+			require.Equal(t, tt.match, matchTopic(tt.pattern, tt.key))
+		})
+	}
+}
+
+func newTestBroker(t *testing.T) *Broker {
+	t.Helper()
+	b := New(logger.New("debug", os.Stdout, false))
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+func receive(t *testing.T, deliveries <-chan broker.Delivery) broker.Delivery {
+	t.Helper()
+	select {
+	case d := <-deliveries:
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("no delivery received")
+		return broker.Delivery{}
+	}
+}
+
+func publish(t *testing.T, b *Broker, exchange, key string, msg broker.Message) {
+	t.Helper()
+	confirm, err := b.Publish(context.Background(), exchange, key, msg)
+	require.NoError(t, err)
+	require.NoError(t, confirm.Wait(context.Background()))
+}
+
+func TestBroker_Routing(t *testing.T) {
+	ctx := context.Background()
+	b := newTestBroker(t)
+
+	require.NoError(t, b.DeclareExchange("events", broker.ExchangeTopic))
+	require.NoError(t, b.DeclareExchange("direct", broker.ExchangeDirect))
+	require.NoError(t, b.DeclareExchange("fanout", broker.ExchangeFanout))
+	require.ErrorIs(t, b.DeclareExchange("events", broker.ExchangeDirect), ErrExchangeKind)
+
+	for _, q := range []string{"all", "reminders", "direct", "fanout"} {
+		require.NoError(t, b.DeclareQueue(q, broker.QueueOptions{}))
+	}
+	require.NoError(t, b.BindQueue("all", "event.#", "events"))
+	require.NoError(t, b.BindQueue("reminders", "event.reminder", "events"))
+	require.NoError(t, b.BindQueue("direct", "key", "direct"))
+	require.NoError(t, b.BindQueue("fanout", "ignored", "fanout"))
+	require.ErrorIs(t, b.BindQueue("all", "#", "missing"), ErrExchangeNotFound)
+
+	publish(t, b, "events", "event.reminder", broker.Message{ID: "1"})
+	publish(t, b, "events", "event.deleted", broker.Message{ID: "2"})
+	publish(t, b, "direct", "other", broker.Message{ID: "3"})
+	publish(t, b, "fanout", "whatever", broker.Message{ID: "4"})
+	publish(t, b, "", "direct", broker.Message{ID: "5"})
+	_, err := b.Publish(ctx, "missing", "key", broker.Message{})
+	require.ErrorIs(t, err, ErrExchangeNotFound)
+
+	expected := map[string]int{"all": 2, "reminders": 1, "direct": 1, "fanout": 1}
+	for q, n := range expected {
+		ready, _, err := b.QueueLen(q)
+		require.NoError(t, err)
+		require.Equal(t, n, ready, q)
+	}
+}
+
+func TestBroker_AckAndRedelivery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := newTestBroker(t)
+	require.NoError(t, b.DeclareQueue("dlq", broker.QueueOptions{}))
+	require.NoError(t, b.DeclareQueue("q", broker.QueueOptions{DeadLetterRoutingKey: "dlq"}))
+
+	publish(t, b, "", "q", broker.Message{ID: "1"})
+
+	deliveries, err := b.Consume(ctx, "q", "")
+	require.NoError(t, err)
+
+	first := receive(t, deliveries)
+	require.Equal(t, "1", first.ID)
+	require.False(t, first.Redelivered)
+	require.NoError(t, first.Nack(true))
+
+	again := receive(t, deliveries)
+	require.Equal(t, "1", again.ID)
+	require.True(t, again.Redelivered)
+	require.Equal(t, 2, again.DeliveryCount)
+	require.NoError(t, again.Ack())
+	require.ErrorIs(t, again.Ack(), ErrUnknownDeliveryTag)
+
+	// Отклонённое без возврата сообщение уходит в очередь недоставленных
+	publish(t, b, "", "q", broker.Message{ID: "2"})
+	second := receive(t, deliveries)
+	require.Equal(t, "2", second.ID)
+	require.NoError(t, second.Nack(false))
+
+	ready, unacked, err := b.QueueLen("q")
+	require.NoError(t, err)
+	require.Zero(t, ready)
+	require.Zero(t, unacked)
+
+	ready, _, err = b.QueueLen("dlq")
+	require.NoError(t, err)
+	require.Equal(t, 1, ready)
+}
+
+func TestBroker_CompetingConsumers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := newTestBroker(t)
+	require.NoError(t, b.DeclareQueue("q", broker.QueueOptions{}))
+
+	first, err := b.Consume(ctx, "q", "")
+	require.NoError(t, err)
+	second, err := b.Consume(ctx, "q", "")
+	require.NoError(t, err)
+
+	const total = 50
+	for i := 0; i < total; i++ {
+		publish(t, b, "", "q", broker.Message{})
+	}
+
+	seen := 0
+	for seen < total {
+		select {
+		case d := <-first:
+			require.NoError(t, d.Ack())
+		case d := <-second:
+			require.NoError(t, d.Ack())
+		case <-time.After(time.Second):
+			t.Fatalf("received %d of %d messages", seen, total)
+		}
+		seen++
+	}
+}
+
+func TestBroker_MessageExpiration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := newTestBroker(t)
+	require.NoError(t, b.DeclareExchange("retry", broker.ExchangeDirect))
+	require.NoError(t, b.DeclareQueue("q", broker.QueueOptions{}))
+	require.NoError(t, b.DeclareQueue("delay", broker.QueueOptions{DeadLetterRoutingKey: "q"}))
+	require.NoError(t, b.BindQueue("delay", "delay", "retry"))
+
+	// Просроченное сообщение возвращается в основную очередь через обменник по умолчанию
+	start := time.Now()
+	publish(t, b, "retry", "delay", broker.Message{ID: "1", Expiration: 20 * time.Millisecond})
+	deliveries, err := b.Consume(ctx, "q", "")
+	require.NoError(t, err)
+
+	d := receive(t, deliveries)
+	require.Equal(t, "1", d.ID)
+	require.Equal(t, "", d.Exchange)
+	require.Equal(t, "q", d.RoutingKey)
+	require.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	require.Zero(t, d.Expiration)
+	require.NoError(t, d.Ack())
+
+	ready, _, err := b.QueueLen("delay")
+	require.NoError(t, err)
+	require.Zero(t, ready)
+}
@@ -0,0 +1,191 @@
+package memorybroker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
+)
+
+type envelope struct {
+	msg        broker.Message
+	exchange   string
+	routingKey string
+	deliveries int
+}
+
+// queue stores messages in FIFO order and tracks deliveries awaiting acknowledgement.
+type queue struct {
+	broker *Broker
+	name   string
+	opts   broker.QueueOptions
+
+	mu      sync.Mutex
+	ready   []*envelope
+	unacked map[uint64]*envelope
+	nextTag uint64
+	// signal wakes up a consumer waiting for messages.
+	signal chan struct{}
+}
+
+func newQueue(b *Broker, name string, opts broker.QueueOptions) *queue {
+	return &queue{
+		broker:  b,
+		name:    name,
+		opts:    opts,
+		unacked: make(map[uint64]*envelope),
+		signal:  make(chan struct{}, 1),
+	}
+}
+
+func (q *queue) push(msg broker.Message, exchange, routingKey string) {
+	e := &envelope{msg: msg, exchange: exchange, routingKey: routingKey}
+	q.mu.Lock()
+	q.ready = append(q.ready, e)
+	q.mu.Unlock()
+
+	if msg.Expiration > 0 {
+		time.AfterFunc(msg.Expiration, func() { q.expire(e) })
+	}
+	q.notify()
+}
+
+// expire dead-letters the message if it is still waiting for a consumer.
+// As in RabbitMQ, delivered and requeued messages do not expire.
+func (q *queue) expire(e *envelope) {
+	q.mu.Lock()
+	i := slices.Index(q.ready, e)
+	if i < 0 || e.deliveries > 0 {
+		q.mu.Unlock()
+		return
+	}
+	q.ready = slices.Delete(q.ready, i, i+1)
+	q.mu.Unlock()
+
+	if err := q.deadLetter(e); err != nil {
+		q.broker.logger.Error("failed to dead-letter expired message", "queue", q.name, "error", err)
+	}
+}
+
+// deadLetter routes the message to the dead-letter exchange of the queue or drops it.
+// As in RabbitMQ, the dead-lettered message no longer expires.
+func (q *queue) deadLetter(e *envelope) error {
+	if q.opts.DeadLetterRoutingKey == "" {
+		return nil
+	}
+	msg := e.msg
+	msg.Expiration = 0
+	if err := q.broker.publish(context.Background(), q.opts.DeadLetterExchange,
+		q.opts.DeadLetterRoutingKey, msg); err != nil {
+		return fmt.Errorf("queue %q: dead-letter: %w", q.name, err)
+	}
+	return nil
+}
+
+func (q *queue) notify() {
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+// next moves the first ready message to unacknowledged ones.
+func (q *queue) next() (broker.Delivery, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.ready) == 0 {
+		return broker.Delivery{}, false
+	}
+	e := q.ready[0]
+	q.ready = q.ready[1:]
+	if len(q.ready) > 0 {
+		// Будим следующего потребителя, если сообщения ещё остались
+		q.notify()
+	}
+
+	e.deliveries++
+	q.nextTag++
+	q.unacked[q.nextTag] = e
+	return broker.Delivery{
+		Message:       e.msg,
+		Exchange:      e.exchange,
+		RoutingKey:    e.routingKey,
+		DeliveryTag:   q.nextTag,
+		Redelivered:   e.deliveries > 1,
+		DeliveryCount: e.deliveries,
+		Acknowledger:  q,
+	}, true
+}
+
+func (q *queue) consume(ctx context.Context, out chan<- broker.Delivery) {
+	defer close(out)
+	for {
+		d, ok := q.next()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.broker.closed:
+				return
+			case <-q.signal:
+				continue
+			}
+		}
+
+		select {
+		case out <- d:
+		case <-ctx.Done():
+			_ = q.Nack(d.DeliveryTag, true)
+			return
+		case <-q.broker.closed:
+			return
+		}
+	}
+}
+
+// Ack implements broker.Acknowledger.
+func (q *queue) Ack(tag uint64) error {
+	_, err := q.settle(tag)
+	return err
+}
+
+// Nack implements broker.Acknowledger. Requeued messages are delivered before others,
+// rejected ones are dead-lettered as configured for the queue.
+func (q *queue) Nack(tag uint64, requeue bool) error {
+	e, err := q.settle(tag)
+	if err != nil {
+		return err
+	}
+
+	if requeue {
+		q.mu.Lock()
+		q.ready = append([]*envelope{e}, q.ready...)
+		q.mu.Unlock()
+		q.notify()
+		return nil
+	}
+
+	return q.deadLetter(e)
+}
+
+func (q *queue) settle(tag uint64) (*envelope, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, ok := q.unacked[tag]
+	if !ok {
+		return nil, fmt.Errorf("queue %q: %w: %d", q.name, ErrUnknownDeliveryTag, tag)
+	}
+	delete(q.unacked, tag)
+	return e, nil
+}
+
+func (q *queue) len() (int, int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.ready), len(q.unacked)
+}
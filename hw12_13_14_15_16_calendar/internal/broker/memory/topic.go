@@ -0,0 +1,34 @@
+package memorybroker
+
+import "strings"
+
+// matchTopic reports whether the routing key matches the binding pattern of a topic exchange.
+// Words are separated by dots, "*" matches exactly one word and "#" matches zero or more words.
+func matchTopic(pattern, key string) bool {
+	return matchWords(strings.Split(pattern, "."), strings.Split(key, "."))
+}
+
+func matchWords(pattern, key []string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case "#":
+			// "#" поглощает любое число слов, включая ноль
+			for i := 0; i <= len(key); i++ {
+				if matchWords(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case "*":
+			if len(key) == 0 {
+				return false
+			}
+		default:
+			if len(key) == 0 || key[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, key = pattern[1:], key[1:]
+	}
+	return len(key) == 0
+}
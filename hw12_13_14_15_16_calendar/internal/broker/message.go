@@ -0,0 +1,17 @@
+// Package broker содержит типы, не зависящие от конкретного брокера сообщений.
+package broker
+
+import "time"
+
+// Message is a message with its metadata independent of the broker implementation.
+type Message struct {
+	ID          string
+	Type        string
+	ContentType string
+	Timestamp   time.Time
+	Headers     map[string]interface{}
+	Body        []byte
+	// Expiration is how long the message may wait in a queue before it is dead-lettered,
+	// zero means no limit. It is cleared when the message is dead-lettered.
+	Expiration time.Duration
+}
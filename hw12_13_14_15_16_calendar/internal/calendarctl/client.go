@@ -0,0 +1,232 @@
+package calendarctl
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	pb "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Periods of event listings.
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// rangeStep is the window of requests assembling an arbitrary range: the server
+// returns 30 days for a month. Events spanning two windows are listed once.
+const rangeStep = 30 * 24 * time.Hour
+
+// maxRateLimitRetries limits repeats of a call rejected by the rate limiter.
+const maxRateLimitRetries = 5
+
+// ErrUnknownPeriod is returned for a period other than day, week or month.
+var ErrUnknownPeriod = errors.New("unknown period, want day, week or month")
+
+// Dial creates a connection to the server of c. The connection is established on the first call.
+func Dial(c Context) (*grpc.ClientConn, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	creds := insecure.NewCredentials()
+	if c.TLS || c.CAFile != "" || c.CertFile != "" {
+		cfg, err := c.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(cfg)
+	}
+	return grpc.NewClient(c.Server,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(retryRateLimited),
+	)
+}
+
+// retryRateLimited repeats calls rejected by the rate limiter after the delay
+// the server asks for. Rejected calls are not executed, so repeating is safe.
+func retryRateLimited(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+) error {
+	for attempt := 0; ; attempt++ {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		delay, ok := retryDelay(err)
+		if !ok || attempt == maxRateLimitRetries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryDelay returns the delay of a ResourceExhausted error with RetryInfo.
+func retryDelay(err error) (time.Duration, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.ResourceExhausted {
+		return 0, false
+	}
+	for _, d := range st.Details() {
+		if ri, ok := d.(*errdetails.RetryInfo); ok {
+			return ri.GetRetryDelay().AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+func (c Context) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: c.ServerName}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.CAFile)
+		}
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// Client manages events through the gRPC API.
+type Client struct {
+	api pb.CalendarClient
+}
+
+// NewClient creates a client over conn.
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{api: pb.NewCalendarClient(conn)}
+}
+
+// Create creates the event.
+func (c *Client) Create(ctx context.Context, e Event) error {
+	_, err := c.api.CreateEvent(ctx, &pb.CreateEventReq{Event: e.toProto()})
+	return err
+}
+
+// Update replaces the event with the ID of e.
+func (c *Client) Update(ctx context.Context, e Event) error {
+	_, err := c.api.UpdateEvent(ctx, &pb.UpdateEventReq{Id: e.ID.String(), Event: e.toProto()})
+	return err
+}
+
+// Delete deletes the event.
+func (c *Client) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := c.api.DeleteEvent(ctx, &pb.DeleteEventReq{Id: id.String()})
+	return err
+}
+
+// List returns events overlapping the period beginning at start, ordered by start.
+func (c *Client) List(ctx context.Context, period string, start time.Time) ([]Event, error) {
+	var get func(context.Context, *pb.GetEventsReq, ...grpc.CallOption) (*pb.GetEventsResp, error)
+	switch period {
+	case PeriodDay:
+		get = c.api.GetEventsDay
+	case PeriodWeek:
+		get = c.api.GetEventsWeek
+	case PeriodMonth:
+		get = c.api.GetEventsMonth
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPeriod, period)
+	}
+
+	resp, err := get(ctx, &pb.GetEventsReq{Start: timestamppb.New(start)})
+	if err != nil {
+		return nil, err
+	}
+	events := make([]Event, 0, len(resp.GetEvents()))
+	for _, pe := range resp.GetEvents() {
+		e, err := eventFromProto(pe)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	sortEvents(events)
+	return events, nil
+}
+
+// ListRange returns events overlapping [from, to], ordered by start. The API has
+// no range query, so the range is assembled from month listings.
+func (c *Client) ListRange(ctx context.Context, from, to time.Time) ([]Event, error) {
+	if to.Before(from) {
+		return nil, errors.New("range end is before its start")
+	}
+
+	seen := make(map[uuid.UUID]struct{})
+	var events []Event
+	for start := from; !start.After(to); start = start.Add(rangeStep) {
+		page, err := c.List(ctx, PeriodMonth, start)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range page {
+			if _, ok := seen[e.ID]; ok || e.Start.After(to) || e.End.Before(from) {
+				continue
+			}
+			seen[e.ID] = struct{}{}
+			events = append(events, e)
+		}
+	}
+	sortEvents(events)
+	return events, nil
+}
+
+func sortEvents(events []Event) {
+	slices.SortStableFunc(events, func(a, b Event) int {
+		return a.Start.Compare(b.Start)
+	})
+}
+
+// DescribeError formats an error returned by the API with the field violations
+// reported by the server. The context the error was wrapped in is kept.
+func DescribeError(err error) string {
+	var se interface {
+		error
+		GRPCStatus() *status.Status
+	}
+	if !errors.As(err, &se) {
+		return err.Error()
+	}
+	st := se.GRPCStatus()
+	prefix, _ := strings.CutSuffix(err.Error(), se.Error())
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%s: %s", prefix, st.Code(), st.Message())
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				fmt.Fprintf(&b, "\n  %s: %s", v.GetField(), v.GetDescription())
+			}
+		}
+		if ri, ok := d.(*errdetails.RetryInfo); ok {
+			fmt.Fprintf(&b, "\n  retry in %s", ri.GetRetryDelay().AsDuration())
+		}
+	}
+	return b.String()
+}
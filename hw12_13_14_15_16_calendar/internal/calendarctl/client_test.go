@@ -0,0 +1,154 @@
+package calendarctl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// fakeCalendar returns events overlapping the requested 30 days and rejects
+// the first limited calls as the rate limiter does.
+type fakeCalendar struct {
+	pb.UnimplementedCalendarServer
+
+	mu      sync.Mutex
+	events  []Event
+	limited int
+	calls   int
+}
+
+func (f *fakeCalendar) CreateEvent(_ context.Context, req *pb.CreateEventReq) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.limited > 0 {
+		f.limited--
+		st, err := status.New(codes.ResourceExhausted, "rate limit exceeded").
+			WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(10 * time.Millisecond)})
+		if err != nil {
+			return nil, err
+		}
+		return nil, st.Err()
+	}
+	e, err := eventFromProto(req.GetEvent())
+	if err != nil {
+		return nil, err
+	}
+	f.events = append(f.events, e)
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeCalendar) UpdateEvent(context.Context, *pb.UpdateEventReq) (*emptypb.Empty, error) {
+	st, err := status.New(codes.InvalidArgument, "invalid event").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "end", Description: "before start"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nil, st.Err()
+}
+
+func (f *fakeCalendar) GetEventsMonth(_ context.Context, req *pb.GetEventsReq) (*pb.GetEventsResp, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	start := req.GetStart().AsTime()
+	end := start.Add(30 * 24 * time.Hour)
+	resp := &pb.GetEventsResp{}
+	for _, e := range f.events {
+		if !e.Start.After(end) && !e.End.Before(start) {
+			resp.Events = append(resp.Events, e.toProto())
+		}
+	}
+	return resp, nil
+}
+
+// stats returns the number of calls and stored events.
+func (f *fakeCalendar) stats() (calls, events int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls, len(f.events)
+}
+
+func startFake(t *testing.T, fake *fakeCalendar) *Client {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	pb.RegisterCalendarServer(srv, fake)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := Dial(Context{Server: lis.Addr().String()})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return NewClient(conn)
+}
+
+func newEvent(title string, start time.Time, d time.Duration) Event {
+	return Event{ID: uuid.New(), UserID: uuid.New(), Title: title, Start: start.UTC(), End: start.Add(d).UTC()}
+}
+
+func TestClient_ListRange(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	long := newEvent("spans windows", base.Add(29*24*time.Hour), 48*time.Hour)
+	late := newEvent("late", base.Add(70*24*time.Hour), time.Hour)
+	early := newEvent("early", base.Add(time.Hour), time.Hour)
+	outside := newEvent("outside", base.Add(100*24*time.Hour), time.Hour)
+	fake := &fakeCalendar{events: []Event{late, long, outside, early}}
+	client := startFake(t, fake)
+
+	events, err := client.ListRange(context.Background(), base, base.Add(80*24*time.Hour))
+	require.NoError(t, err)
+	titles := make([]string, 0, len(events))
+	for _, e := range events {
+		titles = append(titles, e.Title)
+	}
+	require.Equal(t, []string{"early", "spans windows", "late"}, titles)
+	calls, _ := fake.stats()
+	require.Equal(t, 3, calls)
+
+	_, err = client.ListRange(context.Background(), base, base.Add(-time.Hour))
+	require.Error(t, err)
+}
+
+func TestClient_RetriesRateLimited(t *testing.T) {
+	fake := &fakeCalendar{limited: 2}
+	client := startFake(t, fake)
+
+	e := newEvent("standup", time.Now(), time.Hour)
+	require.NoError(t, client.Create(context.Background(), e))
+	calls, events := fake.stats()
+	require.Equal(t, 3, calls)
+	require.Equal(t, 1, events)
+
+	// Лимит повторов исчерпан — ошибка возвращается клиенту
+	fake.mu.Lock()
+	fake.limited = maxRateLimitRetries + 1
+	fake.mu.Unlock()
+	err := client.Create(context.Background(), newEvent("review", time.Now(), time.Hour))
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestDescribeError(t *testing.T) {
+	client := startFake(t, &fakeCalendar{})
+
+	err := client.Update(context.Background(), newEvent("standup", time.Now(), time.Hour))
+	require.Equal(t, "event 1: InvalidArgument: invalid event\n  end: before start",
+		DescribeError(fmt.Errorf("event 1: %w", err)))
+	require.Equal(t, "plain", DescribeError(errors.New("plain")))
+}
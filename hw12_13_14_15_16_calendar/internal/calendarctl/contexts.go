@@ -0,0 +1,160 @@
+// Package calendarctl реализует клиентскую часть утилиты calendarctl:
+// контексты подключения, чтение и вывод событий и вызовы gRPC API календаря.
+package calendarctl
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DefaultServer is used when no context is configured.
+const DefaultServer = "localhost:50051"
+
+var (
+	// ErrContextNotFound is returned for a context missing from the configuration.
+	ErrContextNotFound = errors.New("context not found")
+	// ErrNoServer is returned for a context without a server address.
+	ErrNoServer = errors.New("server address is not set")
+)
+
+// Context describes how to reach a calendar server.
+type Context struct {
+	// Server is the gRPC address, e.g. "localhost:50051".
+	Server string `toml:"server"`
+	// TLS enables TLS verified against the system roots; setting CAFile or
+	// a client certificate enables it as well.
+	TLS    bool   `toml:"tls,omitempty"`
+	CAFile string `toml:"ca_file,omitempty"`
+	// CertFile and KeyFile are the client certificate for servers requiring mutual TLS.
+	CertFile string `toml:"cert_file,omitempty"`
+	KeyFile  string `toml:"key_file,omitempty"`
+	// ServerName overrides the name verified in the server certificate.
+	ServerName string `toml:"server_name,omitempty"`
+}
+
+// Validate checks that the server is set and the client certificate is complete.
+func (c Context) Validate() error {
+	if c.Server == "" {
+		return ErrNoServer
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("both cert_file and key_file must be set")
+	}
+	return nil
+}
+
+// Config is the file storing named contexts and the one in use.
+type Config struct {
+	CurrentContext string             `toml:"current_context"`
+	Contexts       map[string]Context `toml:"contexts"`
+}
+
+// DefaultConfigPath returns the path of the configuration in the user config directory.
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "calendarctl", "config.toml")
+}
+
+// LoadConfig reads the configuration; a missing file yields an empty configuration.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{Contexts: map[string]Context{}}
+	md, err := toml.DecodeFile(path, cfg)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("%s: unknown key %s", path, undecoded[0])
+	}
+	if cfg.Contexts == nil {
+		cfg.Contexts = map[string]Context{}
+	}
+	return cfg, nil
+}
+
+// Save writes the configuration readable by the owner only: it may point to private keys.
+func (c *Config) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.toml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := toml.NewEncoder(tmp).Encode(c); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// Переименование не оставляет наполовину записанный файл при сбое
+	return os.Rename(tmp.Name(), path)
+}
+
+// Names returns the names of the contexts in order.
+func (c *Config) Names() []string {
+	return slices.Sorted(maps.Keys(c.Contexts))
+}
+
+// Resolve returns the context called name, the current one if name is empty,
+// or a context for DefaultServer if nothing is configured.
+func (c *Config) Resolve(name string) (string, Context, error) {
+	if name == "" {
+		name = c.CurrentContext
+	}
+	if name == "" {
+		return "default", Context{Server: DefaultServer}, nil
+	}
+	ctx, ok := c.Contexts[name]
+	if !ok {
+		return "", Context{}, fmt.Errorf("%w: %s", ErrContextNotFound, name)
+	}
+	return name, ctx, nil
+}
+
+// Set adds or replaces a context; the first context becomes the current one.
+func (c *Config) Set(name string, ctx Context) error {
+	if err := ctx.Validate(); err != nil {
+		return fmt.Errorf("context %s: %w", name, err)
+	}
+	c.Contexts[name] = ctx
+	if c.CurrentContext == "" {
+		c.CurrentContext = name
+	}
+	return nil
+}
+
+// Use makes the context called name the current one.
+func (c *Config) Use(name string) error {
+	if _, ok := c.Contexts[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrContextNotFound, name)
+	}
+	c.CurrentContext = name
+	return nil
+}
+
+// Delete removes a context, unsetting it if it is the current one.
+func (c *Config) Delete(name string) error {
+	if _, ok := c.Contexts[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrContextNotFound, name)
+	}
+	delete(c.Contexts, name)
+	if c.CurrentContext == name {
+		c.CurrentContext = ""
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package calendarctl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Contexts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendarctl", "config.toml")
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	name, c, err := cfg.Resolve("")
+	require.NoError(t, err)
+	require.Equal(t, "default", name)
+	require.Equal(t, DefaultServer, c.Server)
+
+	require.NoError(t, cfg.Set("local", Context{Server: "localhost:50051"}))
+	require.NoError(t, cfg.Set("prod", Context{
+		Server: "calendar:50051", CAFile: "ca.crt", CertFile: "tls.crt", KeyFile: "tls.key",
+	}))
+	require.ErrorIs(t, cfg.Set("broken", Context{}), ErrNoServer)
+	require.Error(t, cfg.Set("half", Context{Server: "calendar:50051", CertFile: "tls.crt"}))
+	require.Equal(t, "local", cfg.CurrentContext, "the first context becomes current")
+
+	require.NoError(t, cfg.Use("prod"))
+	require.ErrorIs(t, cfg.Use("staging"), ErrContextNotFound)
+	require.NoError(t, cfg.Save(path))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	loaded, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, cfg, loaded)
+	require.Equal(t, []string{"local", "prod"}, loaded.Names())
+
+	name, c, err = loaded.Resolve("")
+	require.NoError(t, err)
+	require.Equal(t, "prod", name)
+	require.Equal(t, "tls.key", c.KeyFile)
+	_, c, err = loaded.Resolve("local")
+	require.NoError(t, err)
+	require.Equal(t, "localhost:50051", c.Server)
+
+	require.NoError(t, loaded.Delete("prod"))
+	require.Empty(t, loaded.CurrentContext)
+	require.ErrorIs(t, loaded.Delete("prod"), ErrContextNotFound)
+}
+
+func TestLoadConfig_UnknownKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte("[contexts.local]\nsrever = \"localhost:50051\"\n"), 0o600))
+
+	_, err := LoadConfig(path)
+	require.ErrorContains(t, err, "contexts.local.srever")
+}
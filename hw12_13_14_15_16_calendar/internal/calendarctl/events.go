@@ -0,0 +1,129 @@
+package calendarctl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	pb "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoEvents is returned for an input without events.
+var ErrNoEvents = errors.New("no events in input")
+
+// Event is the representation of an event in files and command output.
+// Field names follow the HTTP API; TimeBefore is in seconds.
+type Event struct {
+	ID          uuid.UUID `json:"id" yaml:"id"`
+	UserID      uuid.UUID `json:"userId" yaml:"userId"`
+	Title       string    `json:"title" yaml:"title"`
+	Start       time.Time `json:"start" yaml:"start"`
+	End         time.Time `json:"end" yaml:"end"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+	TimeBefore  int64     `json:"timeBefore,omitempty" yaml:"timeBefore,omitempty"`
+}
+
+func (e Event) toProto() *pb.Event {
+	return &pb.Event{
+		Id:          e.ID.String(),
+		UserId:      e.UserID.String(),
+		Title:       e.Title,
+		StartTime:   timestamppb.New(e.Start),
+		EndTime:     timestamppb.New(e.End),
+		Description: e.Description,
+		TimeBefore:  e.TimeBefore,
+	}
+}
+
+func eventFromProto(e *pb.Event) (Event, error) {
+	id, err := uuid.Parse(e.GetId())
+	if err != nil {
+		return Event{}, fmt.Errorf("event id %q: %w", e.GetId(), err)
+	}
+	userID, err := uuid.Parse(e.GetUserId())
+	if err != nil {
+		return Event{}, fmt.Errorf("event %s: user id %q: %w", id, e.GetUserId(), err)
+	}
+	return Event{
+		ID:          id,
+		UserID:      userID,
+		Title:       e.GetTitle(),
+		Start:       e.GetStartTime().AsTime(),
+		End:         e.GetEndTime().AsTime(),
+		Description: e.GetDescription(),
+		TimeBefore:  e.GetTimeBefore(),
+	}, nil
+}
+
+// timeLayouts are accepted by ParseTime besides RFC 3339.
+var timeLayouts = []string{"2006-01-02T15:04", "2006-01-02 15:04", time.DateOnly}
+
+// ParseTime parses a time in RFC 3339 or, in local time, as "2006-01-02 15:04"
+// or a date.
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, want RFC 3339, \"2006-01-02 15:04\" or a date", s)
+}
+
+// ReadEvents decodes a single event or a list of events in YAML or JSON,
+// which is a subset of YAML.
+func ReadEvents(r io.Reader) ([]Event, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrNoEvents
+		}
+		return nil, err
+	}
+
+	var events []Event
+	root := &doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind == yaml.SequenceNode {
+		if err := root.Decode(&events); err != nil {
+			return nil, err
+		}
+	} else {
+		var e Event
+		if err := root.Decode(&e); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if len(events) == 0 {
+		return nil, ErrNoEvents
+	}
+	return events, nil
+}
+
+// ReadEventsFile reads events from the file at path; "-" means standard input.
+func ReadEventsFile(path string) ([]Event, error) {
+	if path == "-" {
+		return ReadEvents(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	events, err := ReadEvents(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return events, nil
+}
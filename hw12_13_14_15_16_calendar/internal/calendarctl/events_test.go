@@ -0,0 +1,98 @@
+package calendarctl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadEvents(t *testing.T) {
+	user := uuid.New()
+
+	events, err := ReadEvents(strings.NewReader(`
+title: Standup
+userId: ` + user.String() + `
+start: 2026-10-20T10:00:00Z
+end: 2026-10-20T10:15:00Z
+timeBefore: 600
+`))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, uuid.Nil, events[0].ID)
+	require.Equal(t, user, events[0].UserID)
+	require.Equal(t, time.Date(2026, 10, 20, 10, 15, 0, 0, time.UTC), events[0].End.UTC())
+	require.Equal(t, int64(600), events[0].TimeBefore)
+
+	// JSON тоже читается, в том числе список
+	id := uuid.New()
+	events, err = ReadEvents(strings.NewReader(`[
+		{"id": "` + id.String() + `", "title": "Review", "userId": "` + user.String() + `",
+		 "start": "2026-10-21T12:00:00+03:00", "end": "2026-10-21T13:00:00+03:00"},
+		{"title": "Planning", "userId": "` + user.String() + `",
+		 "start": "2026-10-22T12:00:00Z", "end": "2026-10-22T13:00:00Z"}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, id, events[0].ID)
+	require.Equal(t, time.Date(2026, 10, 21, 9, 0, 0, 0, time.UTC), events[0].Start.UTC())
+	require.Equal(t, "Planning", events[1].Title)
+
+	_, err = ReadEvents(strings.NewReader(""))
+	require.ErrorIs(t, err, ErrNoEvents)
+	_, err = ReadEvents(strings.NewReader("[]"))
+	require.ErrorIs(t, err, ErrNoEvents)
+	_, err = ReadEvents(strings.NewReader(`{"userId": "not-a-uuid"}`))
+	require.Error(t, err)
+}
+
+func TestParseTime(t *testing.T) {
+	got, err := ParseTime("2026-10-20T10:00:00+03:00")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 10, 20, 7, 0, 0, 0, time.UTC), got.UTC())
+
+	got, err = ParseTime("2026-10-20 10:00")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 10, 20, 10, 0, 0, 0, time.Local), got)
+
+	got, err = ParseTime("2026-10-20")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local), got)
+
+	_, err = ParseTime("tomorrow")
+	require.Error(t, err)
+}
+
+func TestWriteEvents(t *testing.T) {
+	e := Event{
+		ID:         uuid.MustParse("63b862d7-912c-4dc5-942b-6d61b505e7b0"),
+		UserID:     uuid.MustParse("12fe7571-d773-4d62-97e2-4b70ad5442c5"),
+		Title:      "Standup",
+		Start:      time.Date(2026, 10, 20, 10, 0, 0, 0, time.UTC),
+		End:        time.Date(2026, 10, 20, 10, 15, 0, 0, time.UTC),
+		TimeBefore: 600,
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, WriteEvents(&out, FormatTable, []Event{e}))
+	require.Contains(t, out.String(), "ID ")
+	require.Contains(t, out.String(), "Standup")
+	require.Contains(t, out.String(), "10m0s")
+
+	out.Reset()
+	require.NoError(t, WriteEvents(&out, FormatJSON, nil))
+	require.Equal(t, "[]\n", out.String())
+
+	// Вывод в YAML читается обратно
+	out.Reset()
+	require.NoError(t, WriteEvents(&out, FormatYAML, []Event{e}))
+	require.Contains(t, out.String(), "userId: 12fe7571-d773-4d62-97e2-4b70ad5442c5")
+	events, err := ReadEvents(&out)
+	require.NoError(t, err)
+	require.Equal(t, []Event{e}, events)
+
+	require.ErrorIs(t, WriteEvents(&out, "xml", nil), ErrUnknownFormat)
+}
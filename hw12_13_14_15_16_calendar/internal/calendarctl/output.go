@@ -0,0 +1,75 @@
+package calendarctl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+)
+
+// ErrUnknownFormat is returned for an unsupported output format.
+var ErrUnknownFormat = errors.New("unknown output format, want table, json or yaml")
+
+// tableTimeLayout keeps the table narrow; JSON and YAML use RFC 3339.
+const tableTimeLayout = "2006-01-02 15:04"
+
+// ValidateFormat checks that format is supported.
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatTable, FormatJSON, FormatYAML:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// WriteEvents prints events in the given format. Times in the table are local.
+func WriteEvents(w io.Writer, format string, events []Event) error {
+	if events == nil {
+		// Пустой список, а не null в JSON
+		events = []Event{}
+	}
+
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(events)
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(events); err != nil {
+			return err
+		}
+		return enc.Close()
+	case FormatTable:
+		return writeTable(w, events)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+func writeTable(w io.Writer, events []Event) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTART\tEND\tTITLE\tUSER\tNOTIFY")
+	for _, e := range events {
+		notify := "-"
+		if e.TimeBefore > 0 {
+			notify = (time.Duration(e.TimeBefore) * time.Second).String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", e.ID,
+			e.Start.Local().Format(tableTimeLayout), e.End.Local().Format(tableTimeLayout),
+			e.Title, e.UserID, notify)
+	}
+	return tw.Flush()
+}
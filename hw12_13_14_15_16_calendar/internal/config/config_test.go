@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type dbConf struct {
+	DSN      string `toml:"dsn" env:"DSN" secret:"credentials"`
+	Password string `toml:"password" env:"PASSWORD" secret:"password"`
+}
+
+type userConf struct {
+	Name  string `toml:"name"`
+	Token string `toml:"token" secret:"password"`
+}
+
+type testConfig struct {
+	Level string     `toml:"level" env:"LEVEL"`
+	DB    dbConf     `toml:"db" envPrefix:"DB_"`
+	Users []userConf `toml:"users"`
+}
+
+func (c testConfig) Validate() error {
+	if c.Level == "loud" {
+		return errors.New("level: unknown level")
+	}
+	return nil
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeFile(t, `
+level = "info"
+
+[db]
+dsn = "host=db password=secret"
+`)
+	t.Setenv("DB_PASSWORD", "from-env")
+
+	var cfg testConfig
+	require.NoError(t, Load(path, &cfg))
+	require.Equal(t, "info", cfg.Level)
+	require.Equal(t, "host=db password=secret", cfg.DB.DSN)
+	require.Equal(t, "from-env", cfg.DB.Password)
+}
+
+func TestLoad_UnknownKeys(t *testing.T) {
+	path := writeFile(t, `
+levle = "info"
+
+[db]
+dsn = "host=db"
+pasword = "secret"
+`)
+	var cfg testConfig
+	err := Load(path, &cfg)
+	require.ErrorIs(t, err, ErrUnknownKeys)
+	require.ErrorContains(t, err, "levle, db.pasword")
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := writeFile(t, `level = "info"`)
+	t.Setenv("LEVEL", "loud")
+
+	var cfg testConfig
+	require.EqualError(t, Load(path, &cfg), "invalid config: level: unknown level")
+}
+
+func TestWrite(t *testing.T) {
+	cfg := testConfig{
+		Level: "info",
+		DB:    dbConf{DSN: "postgres://app:secret@db:5432/calendar", Password: "secret"},
+		Users: []userConf{{Name: "alice", Token: "t0ken"}},
+	}
+
+	var out strings.Builder
+	require.NoError(t, Write(&out, cfg))
+	require.NotContains(t, out.String(), "secret")
+	require.NotContains(t, out.String(), "t0ken")
+	require.Contains(t, out.String(), `dsn = "postgres://app:******@db:5432/calendar"`)
+	require.Contains(t, out.String(), `name = "alice"`)
+
+	// Исходная конфигурация не меняется
+	require.Equal(t, "secret", cfg.DB.Password)
+	require.Equal(t, "t0ken", cfg.Users[0].Token)
+}
+
+func TestRedactCredentials(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"amqp://guest:guest@rb:5672/", "amqp://guest:******@rb:5672/"},
+		{"amqp://rb:5672/", "amqp://rb:5672/"},
+		{"amqp://guest@rb:5672/", "amqp://guest@rb:5672/"},
+		{"host=db user=u password=p dbname=otus", "host=db user=u password=****** dbname=otus"},
+		{"host=db password='p w' sslmode=disable", "host=db password=****** sslmode=disable"},
+		{"host=db user=u", "host=db user=u"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, RedactCredentials(tt.in), tt.in)
+	}
+}
@@ -0,0 +1,192 @@
+// Package health собирает проверки зависимостей сервиса и отдаёт их
+// результат через /healthz (liveness) и /readyz (readiness).
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultTimeout limits a single check when the checker is created with a zero timeout.
+const defaultTimeout = 2 * time.Second
+
+// Status values reported in responses.
+const (
+	StatusOK       = "ok"
+	StatusFail     = "fail"
+	StatusStarting = "starting"
+)
+
+// ErrNotReady is reported while the service is starting or shutting down.
+var ErrNotReady = errors.New("service is not ready")
+
+// Check reports why a dependency is unavailable; nil means it is healthy.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name     string
+	check    Check
+	liveness bool
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the body of /healthz and /readyz responses.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// OK reports whether all checks passed.
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Err converts a failed report into an error, nil otherwise.
+func (r Report) Err() error {
+	switch {
+	case r.OK():
+		return nil
+	case r.Status == StatusStarting:
+		return ErrNotReady
+	}
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(r.Checks)) {
+		if res := r.Checks[name]; res.Status != StatusOK {
+			errs = append(errs, errors.New(name+": "+res.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Checker runs registered checks. It reports not ready until SetReady(true) is called.
+type Checker struct {
+	timeout time.Duration
+	ready   atomic.Bool
+
+	mu     sync.RWMutex
+	checks []namedCheck
+}
+
+// New creates a checker limiting every check by timeout.
+func New(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// AddReadiness registers a check of a dependency required to serve requests,
+// such as a database. Its failure takes the service out of rotation only.
+func (c *Checker) AddReadiness(name string, check Check) {
+	c.add(namedCheck{name: name, check: check})
+}
+
+// AddLiveness registers a check whose failure means the process must be restarted,
+// such as a stuck loop. Liveness checks are part of readiness as well.
+func (c *Checker) AddLiveness(name string, check Check) {
+	c.add(namedCheck{name: name, check: check, liveness: true})
+}
+
+func (c *Checker) add(nc namedCheck) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, nc)
+}
+
+// SetReady marks the service as started (true) or shutting down (false).
+func (c *Checker) SetReady(ready bool) {
+	c.ready.Store(ready)
+}
+
+// Live runs liveness checks.
+func (c *Checker) Live(ctx context.Context) Report {
+	return c.run(ctx, true)
+}
+
+// Ready runs all checks; the report fails while the service is not marked ready.
+func (c *Checker) Ready(ctx context.Context) Report {
+	if !c.ready.Load() {
+		return Report{Status: StatusStarting}
+	}
+	return c.run(ctx, false)
+}
+
+// run executes checks concurrently so that one hanging dependency does not delay the others.
+func (c *Checker) run(ctx context.Context, livenessOnly bool) Report {
+	c.mu.RLock()
+	checks := make([]namedCheck, 0, len(c.checks))
+	for _, nc := range c.checks {
+		if !livenessOnly || nc.liveness {
+			checks = append(checks, nc)
+		}
+	}
+	c.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+			results[i] = Result{Status: StatusOK}
+			if err := nc.check(ctx); err != nil {
+				results[i] = Result{Status: StatusFail, Error: err.Error()}
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, nc := range checks {
+		report.Checks[nc.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// LiveHandler serves /healthz.
+func (c *Checker) LiveHandler() http.Handler {
+	return reportHandler(c.Live)
+}
+
+// ReadyHandler serves /readyz.
+func (c *Checker) ReadyHandler() http.Handler {
+	return reportHandler(c.Ready)
+}
+
+func reportHandler(run func(context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := run(r.Context())
+
+		status := http.StatusOK
+		if !report.OK() {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// Register mounts /healthz and /readyz on mux.
+func (c *Checker) Register(mux interface{ Handle(string, http.Handler) }) {
+	mux.Handle("GET /healthz", c.LiveHandler())
+	mux.Handle("GET /readyz", c.ReadyHandler())
+}
@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_Ready(t *testing.T) {
+	c := New(50 * time.Millisecond)
+	c.AddReadiness("postgres", func(context.Context) error { return nil })
+	c.AddReadiness("rabbitmq", func(context.Context) error { return errors.New("not connected") })
+	c.AddLiveness("scheduler", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	// До SetReady сервис считается запускающимся
+	report := c.Ready(context.Background())
+	require.Equal(t, StatusStarting, report.Status)
+	require.ErrorIs(t, report.Err(), ErrNotReady)
+
+	c.SetReady(true)
+	report = c.Ready(context.Background())
+	require.Equal(t, StatusFail, report.Status)
+	require.Equal(t, Result{Status: StatusOK}, report.Checks["postgres"])
+	require.Equal(t, Result{Status: StatusFail, Error: "not connected"}, report.Checks["rabbitmq"])
+	require.Equal(t, StatusFail, report.Checks["scheduler"].Status)
+	require.EqualError(t, report.Err(),
+		"rabbitmq: not connected\nscheduler: "+context.DeadlineExceeded.Error())
+
+	// Liveness не зависит от внешних сервисов
+	report = c.Live(context.Background())
+	require.Len(t, report.Checks, 1)
+	require.Contains(t, report.Checks, "scheduler")
+}
+
+func TestChecker_Handlers(t *testing.T) {
+	c := New(time.Second)
+	failing := errors.New("ping PostgreSQL: connection refused")
+	c.AddReadiness("postgres", func(context.Context) error { return failing })
+	mux := http.NewServeMux()
+	c.Register(mux)
+
+	tests := []struct {
+		name   string
+		target string
+		ready  bool
+		status int
+		want   Report
+	}{
+		{"live", "/healthz", false, http.StatusOK, Report{Status: StatusOK}},
+		{"starting", "/readyz", false, http.StatusServiceUnavailable, Report{Status: StatusStarting}},
+		{
+			"not ready", "/readyz", true, http.StatusServiceUnavailable,
+			Report{Status: StatusFail, Checks: map[string]Result{
+				"postgres": {Status: StatusFail, Error: failing.Error()},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.SetReady(tt.ready)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			require.Equal(t, tt.status, w.Code)
+			require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			var got Report
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -18,9 +18,17 @@ var levelMap = map[string]slog.Level{
 	"debug": slog.LevelDebug,
 }
 
-// New creates a logger with the provided level and writer.
 // New creates a logger with the provided level, writer and format (JSON or human-readable).
+// Level and format can be changed later with Reconfigure.
 func New(level string, out io.Writer, asJSON bool) *slog.Logger {
+	state := &switchState{out: out}
+	state.set(level, asJSON)
+
+	// Пример middleware для добавления полей или обработки
+	return slog.New(NewHandlerMiddleware(&switchHandler{state: state}))
+}
+
+func newHandler(level string, out io.Writer, asJSON bool) slog.Handler {
 	var levLog slog.Level
 	if lvl, ok := levelMap[level]; ok {
 		levLog = lvl
@@ -28,28 +36,21 @@ func New(level string, out io.Writer, asJSON bool) *slog.Logger {
 		levLog = slog.LevelDebug
 	}
 
-	var handler slog.Handler
-
 	if asJSON {
 		// JSON-логирование для парсинга в Elastic, Loki и т.п.
-		handler = slog.NewJSONHandler(out, &slog.HandlerOptions{
+		return slog.NewJSONHandler(out, &slog.HandlerOptions{
 			Level: levLog,
 		})
-	} else {
-		// Человекочитаемый вывод с цветами (если терминал)
-		useColor := isStdout(out)
-
-		handler = tint.NewHandler(out, &tint.Options{
-			Level:      levLog,
-			TimeFormat: time.Kitchen,
-			NoColor:    !useColor,
-		})
 	}
 
-	// Пример middleware для добавления полей или обработки
-	handler = NewHandlerMiddleware(handler)
+	// Человекочитаемый вывод с цветами (если терминал)
+	useColor := isStdout(out)
 
-	return slog.New(handler)
+	return tint.NewHandler(out, &tint.Options{
+		Level:      levLog,
+		TimeFormat: time.Kitchen,
+		NoColor:    !useColor,
+	})
 }
 
 func isStdout(out io.Writer) bool {
@@ -2,12 +2,15 @@ package logger
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func captureOutput(f func(w io.Writer)) string {
@@ -61,3 +64,67 @@ func TestLogger_Info(t *testing.T) {
 	fmt.Println(output)
 	require.Contains(t, output, "database connection completely lost", "should contain the message")
 }
+
+func TestLogger_TraceContext(t *testing.T) {
+	var buf bytes.Buffer
+	log := New("debug", &buf, true)
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0xaa},
+		SpanID:  trace.SpanID{0xbb},
+	})
+	log.InfoContext(trace.ContextWithSpanContext(context.Background(), sc), "traced")
+
+	require.Contains(t, buf.String(), `"trace_id":"`+sc.TraceID().String()+`"`)
+	require.Contains(t, buf.String(), `"span_id":"`+sc.SpanID().String()+`"`)
+}
+
+func TestLogger_RequestID(t *testing.T) {
+	var buf bytes.Buffer
+	log := New("debug", &buf, true)
+
+	ctx := WithLogRequestID(context.Background(), "req-1")
+	ctx = WithLogComponent(ctx, "test")
+	require.Equal(t, "req-1", RequestIDFromContext(ctx))
+	require.Empty(t, RequestIDFromContext(context.Background()))
+
+	log.InfoContext(ctx, "handled")
+	require.Contains(t, buf.String(), `"request_id":"req-1"`)
+}
+
+func TestLogger_Client(t *testing.T) {
+	var buf bytes.Buffer
+	log := New("debug", &buf, true)
+
+	ctx := WithLogClient(WithLogRequestID(context.Background(), "req-1"), "spiffe://calendar/cli")
+	require.Equal(t, "spiffe://calendar/cli", ClientFromContext(ctx))
+	require.Equal(t, "req-1", RequestIDFromContext(ctx))
+	require.Empty(t, ClientFromContext(context.Background()))
+
+	log.InfoContext(ctx, "handled")
+	require.Contains(t, buf.String(), `"client":"spiffe://calendar/cli"`)
+}
+
+func TestReconfigure(t *testing.T) {
+	var buf bytes.Buffer
+	log := New("info", &buf, false)
+	derived := log.With("component", "test")
+
+	log.Debug("hidden")
+	require.Empty(t, buf.String())
+
+	require.NoError(t, Reconfigure(log, "debug", true))
+	derived.Debug("shown")
+	require.Contains(t, buf.String(), `"msg":"shown"`)
+	require.Contains(t, buf.String(), `"component":"test"`)
+
+	require.ErrorIs(t, Reconfigure(slog.Default(), "debug", true), ErrNotReconfigurable)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	require.NoError(t, Config{}.Validate())
+	require.NoError(t, Config{Mod: "file", Level: "warn"}.Validate())
+	require.EqualError(t, Config{Level: "verbose"}.Validate(),
+		`level: unknown level "verbose", want debug, info, warn or error`)
+	require.EqualError(t, Config{Mod: "syslog"}.Validate(), `mod: unknown mode "syslog", want console or file`)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // HandlerMiddleware adds context fields to every log record.
@@ -29,6 +30,12 @@ func (h *HandlerMiddleware) Enabled(ctx context.Context, rec slog.Level) bool {
 // Handle enriches the record with context information before logging.
 func (h *HandlerMiddleware) Handle(ctx context.Context, rec slog.Record) error {
 	if c, ok := ctx.Value(key).(logCtx); ok {
+		if c.RequestID != "" {
+			rec.Add("request_id", c.RequestID)
+		}
+		if c.Client != "" {
+			rec.Add("client", c.Client)
+		}
 		if c.Component != "" {
 			rec.Add("component", c.Component)
 		}
@@ -42,6 +49,9 @@ func (h *HandlerMiddleware) Handle(ctx context.Context, rec slog.Record) error {
 			rec.Add("start", c.Start.Format(time.RFC3339))
 		}
 	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		rec.Add("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+	}
 	return h.next.Handle(ctx, rec)
 }
 
@@ -60,6 +70,8 @@ func (h *HandlerMiddleware) WithGroup(name string) slog.Handler {
 }
 
 type logCtx struct {
+	RequestID string
+	Client    string
 	Component string
 	Method    string
 	EventID   uuid.UUID
@@ -103,6 +115,44 @@ func WithLogEventID(ctx context.Context, eventID uuid.UUID) context.Context {
 	})
 }
 
+// WithLogRequestID attaches a request ID to the logging context.
+func WithLogRequestID(ctx context.Context, requestID string) context.Context {
+	if c, ok := ctx.Value(key).(logCtx); ok {
+		c.RequestID = requestID
+		return context.WithValue(ctx, key, c)
+	}
+	return context.WithValue(ctx, key, logCtx{
+		RequestID: requestID,
+	})
+}
+
+// RequestIDFromContext returns the request ID stored in the logging context.
+func RequestIDFromContext(ctx context.Context) string {
+	if c, ok := ctx.Value(key).(logCtx); ok {
+		return c.RequestID
+	}
+	return ""
+}
+
+// WithLogClient attaches the identity of an authenticated client to the logging context.
+func WithLogClient(ctx context.Context, client string) context.Context {
+	if c, ok := ctx.Value(key).(logCtx); ok {
+		c.Client = client
+		return context.WithValue(ctx, key, c)
+	}
+	return context.WithValue(ctx, key, logCtx{
+		Client: client,
+	})
+}
+
+// ClientFromContext returns the identity of the authenticated client, if any.
+func ClientFromContext(ctx context.Context) string {
+	if c, ok := ctx.Value(key).(logCtx); ok {
+		return c.Client
+	}
+	return ""
+}
+
 // WithLogStart adds a start time to the logging context.
 func WithLogStart(ctx context.Context, start time.Time) context.Context {
 	if c, ok := ctx.Value(key).(logCtx); ok {
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+)
+
+// ErrNotReconfigurable is returned by Reconfigure for loggers not created by this package.
+var ErrNotReconfigurable = errors.New("logger was not created by logger.New")
+
+// switchState holds the handler all loggers derived from one New call write with.
+type switchState struct {
+	out  io.Writer
+	base atomic.Pointer[slog.Handler]
+}
+
+func (s *switchState) set(level string, asJSON bool) {
+	h := newHandler(level, s.out, asJSON)
+	s.base.Store(&h)
+}
+
+// switchHandler forwards records to the current handler of its state. Attributes
+// and groups added with With are replayed on the current handler, so derived
+// loggers follow reconfiguration too.
+type switchHandler struct {
+	state *switchState
+	ops   []func(slog.Handler) slog.Handler
+}
+
+func (h *switchHandler) current() slog.Handler {
+	next := *h.state.base.Load()
+	for _, op := range h.ops {
+		next = op(next)
+	}
+	return next
+}
+
+func (h *switchHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.current().Enabled(ctx, level)
+}
+
+func (h *switchHandler) Handle(ctx context.Context, rec slog.Record) error {
+	return h.current().Handle(ctx, rec)
+}
+
+func (h *switchHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *switchHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *switchHandler) with(op func(slog.Handler) slog.Handler) slog.Handler {
+	return &switchHandler{state: h.state, ops: append(slices.Clip(h.ops), op)}
+}
+
+// Reconfigure changes the level and the format of l and of all loggers derived from it.
+func Reconfigure(l *slog.Logger, level string, asJSON bool) error {
+	mw, ok := l.Handler().(*HandlerMiddleware)
+	if !ok {
+		return ErrNotReconfigurable
+	}
+	sw, ok := mw.next.(*switchHandler)
+	if !ok {
+		return ErrNotReconfigurable
+	}
+	sw.state.set(level, asJSON)
+	return nil
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"log/slog"
@@ -15,6 +16,19 @@ type Config struct {
 	Level string `toml:"level" env:"LEVEL"`
 }
 
+// Validate checks that the mode and the level are known.
+func (c Config) Validate() error {
+	switch c.Mod {
+	case "", "console", "file":
+	default:
+		return fmt.Errorf("mod: unknown mode %q, want console or file", c.Mod)
+	}
+	if _, ok := levelMap[c.Level]; !ok && c.Level != "" {
+		return fmt.Errorf("level: unknown level %q, want debug, info, warn or error", c.Level)
+	}
+	return nil
+}
+
 // New initializes global slog.Logger according to configuration.
 // It returns created logger and optional io.Closer that should be closed
 // when logger output is a file.
@@ -0,0 +1,170 @@
+// Package metrics содержит метрики Prometheus всех сервисов календаря.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "calendar"
+
+var (
+	// HTTPRequests counts handled HTTP requests.
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of handled HTTP requests.",
+	}, []string{"method", "route", "code"})
+
+	// HTTPDuration measures HTTP request latency.
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// GRPCRequests counts handled gRPC calls.
+	GRPCRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "Number of handled gRPC calls.",
+	}, []string{"method", "code"})
+
+	// GRPCDuration measures gRPC call latency.
+	GRPCDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of gRPC calls.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	// RateLimited counts requests rejected by rate limits.
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ratelimit",
+		Name:      "rejected_total",
+		Help:      "Number of requests rejected by rate limits.",
+	}, []string{"transport", "route"})
+
+	// StorageDuration measures storage operation latency.
+	StorageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "operation_duration_seconds",
+		Help:      "Latency of storage operations.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"backend", "operation"})
+
+	// StorageErrors counts failed storage operations.
+	StorageErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "errors_total",
+		Help:      "Number of failed storage operations.",
+	}, []string{"backend", "operation"})
+
+	// NotificationsPublished counts notifications confirmed by the broker.
+	NotificationsPublished = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "notifications_published_total",
+		Help:      "Number of notifications published and confirmed by the broker.",
+	})
+
+	// NotificationsFailed counts notifications that failed to publish and were left for retry.
+	NotificationsFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "notifications_failed_total",
+		Help:      "Number of notifications that failed to publish.",
+	})
+
+	// NotificationsPerTick measures the number of notifications handled in one tick.
+	NotificationsPerTick = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "tick_notifications",
+		Help:      "Number of notifications handled in one scheduler tick.",
+		Buckets:   []float64{0, 1, 5, 10, 50, 100, 500, 1000},
+	}, []string{"result"})
+
+	// LastTick is the time of the last finished scheduler tick.
+	LastTick = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "last_tick_timestamp_seconds",
+		Help:      "Unix time of the last finished scheduler tick.",
+	})
+
+	// ConsumerDeliveries counts processed deliveries by outcome: acked, retried or dead_lettered.
+	ConsumerDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "consumer",
+		Name:      "deliveries_total",
+		Help:      "Number of processed deliveries by outcome.",
+	}, []string{"result"})
+
+	// ConsumerRedeliveries counts deliveries received again after a failure or a retry.
+	ConsumerRedeliveries = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "consumer",
+		Name:      "redeliveries_total",
+		Help:      "Number of deliveries received again after a failure or a retry.",
+	})
+
+	// ConsumerLag measures the time between publishing and receiving a message.
+	ConsumerLag = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "consumer",
+		Name:      "lag_seconds",
+		Help:      "Time between publishing and receiving a message.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300, 900},
+	})
+
+	// RabbitMQConnectionState is 1 while the component is connected to RabbitMQ.
+	RabbitMQConnectionState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "rabbitmq",
+		Name:      "connected",
+		Help:      "Whether the component is connected to RabbitMQ.",
+	}, []string{"component"})
+
+	// ConfigReloads counts configuration reloads by result: applied or rejected.
+	ConfigReloads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "config",
+		Name:      "reloads_total",
+		Help:      "Number of configuration reloads by result.",
+	}, []string{"result"})
+)
+
+// Handler returns the handler exposing metrics in Prometheus format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveStorage records latency and outcome of a storage operation started at start.
+func ObserveStorage(backend, operation string, start time.Time, err error) {
+	StorageDuration.WithLabelValues(backend, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		StorageErrors.WithLabelValues(backend, operation).Inc()
+	}
+}
+
+// ObserveConnection records the RabbitMQ connection state of the component.
+func ObserveConnection(component string, connected bool) {
+	v := 0.0
+	if connected {
+		v = 1
+	}
+	RabbitMQConnectionState.WithLabelValues(component).Set(v)
+}
@@ -0,0 +1,205 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"time"
+
+	pb "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// ContentTypeJSON is the content type of JSON-encoded envelopes.
+	ContentTypeJSON = "application/json"
+	// ContentTypeProtobuf is the content type of protobuf-encoded envelopes.
+	ContentTypeProtobuf = "application/x-protobuf"
+
+	// contentTypeLegacy was used for raw notifications before envelopes were introduced.
+	contentTypeLegacy = "text/plain"
+)
+
+// Encoding names accepted by ContentTypeFor.
+const (
+	EncodingJSON     = "json"
+	EncodingProtobuf = "protobuf"
+)
+
+// ContentTypeFor returns the content type for the encoding name.
+func ContentTypeFor(encoding string) (string, error) {
+	switch encoding {
+	case EncodingJSON, "":
+		return ContentTypeJSON, nil
+	case EncodingProtobuf:
+		return ContentTypeProtobuf, nil
+	default:
+		return "", fmt.Errorf("%w: encoding %q", ErrUnsupportedContentType, encoding)
+	}
+}
+
+// Marshal encodes the envelope into a broker message with the given content type.
+func Marshal(e Envelope, contentType string) (broker.Message, error) {
+	var (
+		body []byte
+		err  error
+	)
+	switch contentType {
+	case ContentTypeJSON:
+		body, err = json.Marshal(e)
+	case ContentTypeProtobuf:
+		body, err = proto.Marshal(toProto(e))
+	default:
+		return broker.Message{}, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+	if err != nil {
+		return broker.Message{}, fmt.Errorf("marshal envelope: %w", err)
+	}
+
+	return broker.Message{
+		ID:          e.ID.String(),
+		Type:        e.Type,
+		ContentType: contentType,
+		Timestamp:   e.CreatedAt,
+		Headers: map[string]interface{}{
+			SchemaVersionHeader:  int32(e.SchemaVersion), //nolint:gosec
+			IdempotencyKeyHeader: e.IdempotencyKey,
+		},
+		Body: body,
+	}, nil
+}
+
+// Unmarshal decodes a message body of the given content type.
+// Legacy messages without an envelope are upgraded to the current schema version.
+func Unmarshal(contentType string, body []byte) (Envelope, error) {
+	mediaType := contentType
+	if contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return Envelope{}, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+		}
+	}
+
+	var (
+		e   Envelope
+		err error
+	)
+	switch mediaType {
+	case ContentTypeJSON, contentTypeLegacy, "":
+		e, err = unmarshalJSON(body)
+	case ContentTypeProtobuf:
+		e, err = unmarshalProto(body)
+	default:
+		return Envelope{}, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	return upgrade(e)
+}
+
+func unmarshalJSON(body []byte) (Envelope, error) {
+	var e Envelope
+	if err := json.Unmarshal(body, &e); err != nil {
+		return Envelope{}, fmt.Errorf("unmarshal envelope: %w", err)
+	}
+	if e.SchemaVersion == 0 && e.Type == "" {
+		// Версия 0: тело сообщения — сама структура уведомления
+		var n storage.Notification
+		if err := json.Unmarshal(body, &n); err != nil {
+			return Envelope{}, fmt.Errorf("unmarshal legacy notification: %w", err)
+		}
+		e.Payload = n
+	}
+	return e, nil
+}
+
+func unmarshalProto(body []byte) (Envelope, error) {
+	var m pb.NotificationEnvelope
+	if err := proto.Unmarshal(body, &m); err != nil {
+		return Envelope{}, fmt.Errorf("unmarshal envelope: %w", err)
+	}
+	return fromProto(&m)
+}
+
+// upgrade converts envelopes of older schema versions to the current one.
+func upgrade(e Envelope) (Envelope, error) {
+	switch e.SchemaVersion {
+	case 0:
+		return Envelope{
+			Type:           TypeEventReminder,
+			SchemaVersion:  SchemaVersion,
+			ID:             uuid.New(),
+			IdempotencyKey: IdempotencyKey(e.Payload),
+			ExpiresAt:      e.Payload.Start,
+			Payload:        e.Payload,
+		}, nil
+	case SchemaVersion:
+		if e.Type != TypeEventReminder {
+			return Envelope{}, fmt.Errorf("%w: %q", ErrUnsupportedType, e.Type)
+		}
+		return e, nil
+	default:
+		return Envelope{}, fmt.Errorf("%w: %d", ErrUnsupportedVersion, e.SchemaVersion)
+	}
+}
+
+func toProto(e Envelope) *pb.NotificationEnvelope {
+	return &pb.NotificationEnvelope{
+		Type:           e.Type,
+		SchemaVersion:  int32(e.SchemaVersion), //nolint:gosec
+		Id:             e.ID.String(),
+		IdempotencyKey: e.IdempotencyKey,
+		CreatedAt:      timestamppb.New(e.CreatedAt),
+		ExpiresAt:      timestamppb.New(e.ExpiresAt),
+		Payload: &pb.Notification{
+			Id:     e.Payload.ID.String(),
+			Title:  e.Payload.Title,
+			Start:  timestamppb.New(e.Payload.Start),
+			UserId: e.Payload.UserID.String(),
+		},
+	}
+}
+
+func fromProto(m *pb.NotificationEnvelope) (Envelope, error) {
+	e := Envelope{
+		Type:           m.GetType(),
+		SchemaVersion:  int(m.GetSchemaVersion()),
+		IdempotencyKey: m.GetIdempotencyKey(),
+		CreatedAt:      asTime(m.GetCreatedAt()),
+		ExpiresAt:      asTime(m.GetExpiresAt()),
+	}
+
+	var err error
+	if m.GetId() != "" {
+		if e.ID, err = uuid.Parse(m.GetId()); err != nil {
+			return Envelope{}, fmt.Errorf("envelope id: %w", err)
+		}
+	}
+
+	p := m.GetPayload()
+	if p == nil {
+		return e, nil
+	}
+	e.Payload.Title = p.GetTitle()
+	e.Payload.Start = asTime(p.GetStart())
+	if e.Payload.ID, err = uuid.Parse(p.GetId()); err != nil {
+		return Envelope{}, fmt.Errorf("notification id: %w", err)
+	}
+	if e.Payload.UserID, err = uuid.Parse(p.GetUserId()); err != nil {
+		return Envelope{}, fmt.Errorf("notification user id: %w", err)
+	}
+	return e, nil
+}
+
+func asTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
@@ -0,0 +1,89 @@
+package notification
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func testNotification() storage.Notification {
+	return storage.Notification{
+		ID:     uuid.New(),
+		Title:  "meeting",
+		Start:  time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC),
+		UserID: uuid.New(),
+	}
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	n := testNotification()
+	e := New(n, time.Date(2030, 1, 2, 9, 0, 0, 0, time.UTC))
+
+	for _, ct := range []string{ContentTypeJSON, ContentTypeProtobuf} {
+		t.Run(ct, func(t *testing.T) {
+			msg, err := Marshal(e, ct)
+			require.NoError(t, err)
+			require.Equal(t, ct, msg.ContentType)
+			require.Equal(t, e.ID.String(), msg.ID)
+			require.Equal(t, TypeEventReminder, msg.Type)
+			require.Equal(t, int32(SchemaVersion), msg.Headers[SchemaVersionHeader])
+			require.Equal(t, e.IdempotencyKey, msg.Headers[IdempotencyKeyHeader])
+
+			got, err := Unmarshal(msg.ContentType, msg.Body)
+			require.NoError(t, err)
+			require.Equal(t, e, got)
+		})
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	n := testNotification()
+	require.Equal(t, IdempotencyKey(n), New(n, time.Now()).IdempotencyKey)
+	require.NotEqual(t, New(n, time.Now()).ID, New(n, time.Now()).ID)
+
+	moved := n
+	moved.Start = n.Start.Add(time.Hour)
+	require.NotEqual(t, IdempotencyKey(n), IdempotencyKey(moved))
+}
+
+func TestUnmarshal_Legacy(t *testing.T) {
+	n := testNotification()
+	body, err := json.Marshal(n)
+	require.NoError(t, err)
+
+	e, err := Unmarshal("text/plain", body)
+	require.NoError(t, err)
+	require.Equal(t, SchemaVersion, e.SchemaVersion)
+	require.Equal(t, TypeEventReminder, e.Type)
+	require.Equal(t, IdempotencyKey(n), e.IdempotencyKey)
+	require.Equal(t, n, e.Payload)
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	future := New(testNotification(), time.Now())
+	future.SchemaVersion = SchemaVersion + 1
+	body, err := json.Marshal(future)
+	require.NoError(t, err)
+	_, err = Unmarshal(ContentTypeJSON, body)
+	require.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	unknown := New(testNotification(), time.Now())
+	unknown.Type = "calendar.event.unknown"
+	body, err = json.Marshal(unknown)
+	require.NoError(t, err)
+	_, err = Unmarshal(ContentTypeJSON, body)
+	require.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = Unmarshal("application/xml", []byte("<x/>"))
+	require.ErrorIs(t, err, ErrUnsupportedContentType)
+
+	_, err = Unmarshal(ContentTypeJSON, []byte("{"))
+	require.Error(t, err)
+
+	_, err = ContentTypeFor("xml")
+	require.ErrorIs(t, err, ErrUnsupportedContentType)
+}
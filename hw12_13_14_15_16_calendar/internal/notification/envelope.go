@@ -0,0 +1,64 @@
+// Package notification описывает формат сообщений с уведомлениями,
+// которыми обмениваются планировщик и рассыльщик.
+package notification
+
+import (
+	"errors"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	// TypeEventReminder is the type of a reminder about an upcoming event.
+	TypeEventReminder = "calendar.event.reminder"
+	// SchemaVersion is the current version of the envelope schema.
+	SchemaVersion = 1
+
+	// SchemaVersionHeader carries the schema version in message headers.
+	SchemaVersionHeader = "x-schema-version"
+	// IdempotencyKeyHeader carries the idempotency key in message headers.
+	IdempotencyKeyHeader = "x-idempotency-key"
+)
+
+var (
+	// ErrUnsupportedVersion is returned for envelopes of unknown schema version.
+	ErrUnsupportedVersion = errors.New("unsupported notification schema version")
+	// ErrUnsupportedContentType is returned for messages of unknown content type.
+	ErrUnsupportedContentType = errors.New("unsupported notification content type")
+	// ErrUnsupportedType is returned for envelopes of unknown type.
+	ErrUnsupportedType = errors.New("unsupported notification type")
+)
+
+// Envelope wraps a notification with metadata required for delivery.
+type Envelope struct {
+	Type          string `json:"type"`
+	SchemaVersion int    `json:"schemaVersion"`
+	// ID is unique for every published message.
+	ID uuid.UUID `json:"id"`
+	// IdempotencyKey is the same for all messages about the same reminder.
+	IdempotencyKey string               `json:"idempotencyKey"`
+	CreatedAt      time.Time            `json:"createdAt"`
+	ExpiresAt      time.Time            `json:"expiresAt"`
+	Payload        storage.Notification `json:"payload"`
+}
+
+// New wraps the notification into an envelope of the current schema version.
+func New(n storage.Notification, now time.Time) Envelope {
+	return Envelope{
+		Type:           TypeEventReminder,
+		SchemaVersion:  SchemaVersion,
+		ID:             uuid.New(),
+		IdempotencyKey: IdempotencyKey(n),
+		CreatedAt:      now.UTC(),
+		ExpiresAt:      n.Start.UTC(),
+		Payload:        n,
+	}
+}
+
+// IdempotencyKey returns a stable key of the reminder: it changes only when
+// the event is rescheduled.
+func IdempotencyKey(n storage.Notification) string {
+	return uuid.NewSHA1(n.ID, []byte(n.Start.UTC().Format(time.RFC3339Nano))).String()
+}
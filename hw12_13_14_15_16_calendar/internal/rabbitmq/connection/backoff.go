@@ -0,0 +1,21 @@
+package connection
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// backoff returns the delay before the given attempt (starting from 1): it doubles
+// with every attempt up to MaxInterval and is randomized to spread reconnects
+// of many clients after a broker restart.
+func (c ReconnectConf) backoff(attempt int) time.Duration {
+	d := c.MaxInterval
+	if attempt < 32 {
+		if exp := c.InitialInterval << (attempt - 1); exp > 0 && exp < d {
+			d = exp
+		}
+	}
+	// Равномерно в диапазоне [d/2, d]
+	half := d / 2
+	return half + rand.N(d-half+1) //nolint:gosec // jitter does not need a secure source
+}
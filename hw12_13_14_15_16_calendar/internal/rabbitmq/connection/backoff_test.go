@@ -0,0 +1,46 @@
+package connection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	cfg := ReconnectConf{InitialInterval: 100 * time.Millisecond, MaxInterval: time.Second}.withDefaults()
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{100, time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			d := cfg.backoff(tt.attempt)
+			require.GreaterOrEqual(t, d, tt.max/2, "attempt %d", tt.attempt)
+			require.LessOrEqual(t, d, tt.max, "attempt %d", tt.attempt)
+		}
+	}
+}
+
+func TestReconnectConfDefaults(t *testing.T) {
+	cfg := ReconnectConf{}.withDefaults()
+	require.Equal(t, defaultInitialInterval, cfg.InitialInterval)
+	require.Equal(t, defaultMaxInterval, cfg.MaxInterval)
+	require.Equal(t, defaultMaxAttempts, cfg.MaxAttempts)
+
+	cfg = ReconnectConf{InitialInterval: time.Minute, MaxInterval: time.Second}.withDefaults()
+	require.Equal(t, time.Minute, cfg.MaxInterval)
+}
+
+func TestStateString(t *testing.T) {
+	require.Equal(t, "connected", StateConnected.String())
+	require.Equal(t, "State(42)", State(42).String())
+}
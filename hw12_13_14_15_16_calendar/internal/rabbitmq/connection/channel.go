@@ -0,0 +1,183 @@
+package connection
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/streadway/amqp"
+)
+
+// confirmBuffer is the capacity of the channel receiving publisher confirms.
+const confirmBuffer = 256
+
+// Channel adapts an AMQP channel to broker.Channel. Exchanges and queues are durable
+// and messages persistent.
+type Channel struct {
+	ch *amqp.Channel
+	// confirms is set in confirm mode.
+	confirms *confirmTracker
+}
+
+var _ broker.Channel = (*Channel)(nil)
+
+// NewChannel wraps an open AMQP channel.
+func NewChannel(ch *amqp.Channel) *Channel {
+	return &Channel{ch: ch}
+}
+
+// Qos limits the number of unacknowledged deliveries of the channel, zero means no limit.
+func (c *Channel) Qos(prefetch int) error {
+	return c.ch.Qos(prefetch, 0, false)
+}
+
+// EnableConfirms puts the channel into confirm mode: confirmations returned by Publish
+// resolve when the broker acknowledges the message and fail after timeout.
+func (c *Channel) EnableConfirms(timeout time.Duration) error {
+	if err := c.ch.Confirm(false); err != nil {
+		return err
+	}
+	// Подтверждения нового канала нумеруются заново, поэтому трекер создаётся на каждый канал
+	c.confirms = newConfirmTracker(timeout)
+	go c.confirms.listen(c.ch.NotifyPublish(make(chan amqp.Confirmation, confirmBuffer)))
+	return nil
+}
+
+// DeclareExchange implements broker.Channel.
+func (c *Channel) DeclareExchange(name, kind string) error {
+	return c.ch.ExchangeDeclare(name, kind, true, false, false, false, nil)
+}
+
+// DeclareQueue implements broker.Channel.
+func (c *Channel) DeclareQueue(name string, opts broker.QueueOptions) error {
+	var args amqp.Table
+	if opts.DeadLetterRoutingKey != "" {
+		args = amqp.Table{
+			"x-dead-letter-exchange":    opts.DeadLetterExchange,
+			"x-dead-letter-routing-key": opts.DeadLetterRoutingKey,
+		}
+	}
+	_, err := c.ch.QueueDeclare(name, true, false, false, false, args)
+	return err
+}
+
+// BindQueue implements broker.Channel.
+func (c *Channel) BindQueue(queue, key, exchange string) error {
+	return c.ch.QueueBind(queue, key, exchange, false, nil)
+}
+
+// Publish implements broker.Channel. Outside confirm mode the message is
+// confirmed as soon as it is written to the connection.
+func (c *Channel) Publish(
+	_ context.Context,
+	exchange, routingKey string,
+	msg broker.Message,
+) (*broker.Confirmation, error) {
+	var expiration string
+	if msg.Expiration > 0 {
+		expiration = strconv.FormatInt(msg.Expiration.Milliseconds(), 10)
+	}
+	publish := func() error {
+		return c.ch.Publish(exchange, routingKey, false, false, amqp.Publishing{
+			Headers:      amqp.Table(msg.Headers),
+			ContentType:  msg.ContentType,
+			MessageId:    msg.ID,
+			Type:         msg.Type,
+			Timestamp:    msg.Timestamp,
+			Body:         msg.Body,
+			Expiration:   expiration,
+			DeliveryMode: amqp.Persistent,
+		})
+	}
+
+	if c.confirms == nil {
+		if err := publish(); err != nil {
+			return nil, err
+		}
+		return broker.Confirmed(), nil
+	}
+	return c.confirms.publish(publish)
+}
+
+// Consume implements broker.Channel. Cancelling ctx cancels the consumer on the server.
+func (c *Channel) Consume(ctx context.Context, queue, consumerTag string) (<-chan broker.Delivery, error) {
+	deliveries, err := c.ch.Consume(queue, consumerTag, false, false, false, false, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan broker.Delivery)
+	go func() {
+		defer close(out)
+
+		done := ctx.Done()
+		cancel := func() {
+			// Библиотека закроет deliveries, когда сервер подтвердит отмену
+			_ = c.ch.Cancel(consumerTag, false)
+			done = nil
+		}
+		for {
+			select {
+			case <-done:
+				cancel()
+			case d, ok := <-deliveries:
+				if !ok {
+					return
+				}
+				if done == nil {
+					_ = d.Nack(false, true)
+					continue
+				}
+				select {
+				case out <- toDelivery(d):
+				case <-done:
+					_ = d.Nack(false, true)
+					cancel()
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Get receives one message from the queue without waiting, ok is false when the queue is empty.
+// The delivery must be settled.
+func (c *Channel) Get(queue string) (d broker.Delivery, ok bool, err error) {
+	msg, ok, err := c.ch.Get(queue, false)
+	if err != nil || !ok {
+		return broker.Delivery{}, false, err
+	}
+	return toDelivery(msg), true, nil
+}
+
+func toDelivery(d amqp.Delivery) broker.Delivery {
+	return broker.Delivery{
+		Message: broker.Message{
+			ID:          d.MessageId,
+			Type:        d.Type,
+			ContentType: d.ContentType,
+			Timestamp:   d.Timestamp,
+			Headers:     d.Headers,
+			Body:        d.Body,
+		},
+		Exchange:     d.Exchange,
+		RoutingKey:   d.RoutingKey,
+		DeliveryTag:  d.DeliveryTag,
+		Redelivered:  d.Redelivered,
+		Acknowledger: acknowledger{ack: d.Acknowledger},
+	}
+}
+
+// acknowledger settles deliveries one by one.
+type acknowledger struct {
+	ack amqp.Acknowledger
+}
+
+func (a acknowledger) Ack(tag uint64) error {
+	return a.ack.Ack(tag, false)
+}
+
+func (a acknowledger) Nack(tag uint64, requeue bool) error {
+	return a.ack.Nack(tag, false, requeue)
+}
@@ -0,0 +1,73 @@
+package connection
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	defaultInitialInterval = 500 * time.Millisecond
+	defaultMaxInterval     = 30 * time.Second
+	defaultMaxAttempts     = 5
+)
+
+// ReconnectConf defines backoff between connection attempts.
+type ReconnectConf struct {
+	InitialInterval time.Duration `toml:"initial_interval" env:"INITIAL_INTERVAL"`
+	MaxInterval     time.Duration `toml:"max_interval" env:"MAX_INTERVAL"`
+	// MaxAttempts limits attempts of the initial connection only;
+	// a lost connection is restored until the supervisor is closed.
+	MaxAttempts int `toml:"max_attempts" env:"MAX_ATTEMPTS"`
+}
+
+// Validate checks that the intervals and the number of attempts are not negative;
+// zero values are replaced with defaults.
+func (c ReconnectConf) Validate() error {
+	if c.InitialInterval < 0 {
+		return fmt.Errorf("initial_interval: must not be negative, got %s", c.InitialInterval)
+	}
+	if c.MaxInterval < 0 {
+		return fmt.Errorf("max_interval: must not be negative, got %s", c.MaxInterval)
+	}
+	if c.MaxAttempts < 0 {
+		return fmt.Errorf("max_attempts: must not be negative, got %d", c.MaxAttempts)
+	}
+	return nil
+}
+
+func (c ReconnectConf) withDefaults() ReconnectConf {
+	if c.InitialInterval <= 0 {
+		c.InitialInterval = defaultInitialInterval
+	}
+	if c.MaxInterval <= 0 {
+		c.MaxInterval = defaultMaxInterval
+	}
+	if c.MaxInterval < c.InitialInterval {
+		c.MaxInterval = c.InitialInterval
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaultMaxAttempts
+	}
+	return c
+}
+
+// ValidateURI checks that uri is a valid AMQP URI.
+func ValidateURI(uri string) error {
+	if uri == "" {
+		return errors.New("required")
+	}
+	_, err := amqp.ParseURI(uri)
+	return err
+}
+
+// ValidateExchangeType checks that kind is a built-in exchange type.
+func ValidateExchangeType(kind string) error {
+	switch kind {
+	case amqp.ExchangeDirect, amqp.ExchangeFanout, amqp.ExchangeTopic, amqp.ExchangeHeaders:
+		return nil
+	}
+	return fmt.Errorf("unknown type %q, want direct, fanout, topic or headers", kind)
+}
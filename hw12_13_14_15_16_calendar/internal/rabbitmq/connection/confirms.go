@@ -0,0 +1,92 @@
+package connection
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/streadway/amqp"
+)
+
+// ErrChannelClosed is returned for messages whose confirms were lost with the channel.
+var ErrChannelClosed = errors.New("channel closed before confirmation")
+
+// confirmTracker matches publisher confirms of one channel with published messages
+// by delivery tag, so many messages can wait for confirmation at once.
+type confirmTracker struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	nextTag uint64
+	pending map[uint64]*broker.Confirmation
+	closed  bool
+}
+
+func newConfirmTracker(timeout time.Duration) *confirmTracker {
+	return &confirmTracker{
+		timeout: timeout,
+		nextTag: 1,
+		pending: make(map[uint64]*broker.Confirmation),
+	}
+}
+
+// publish calls fn and registers the delivery tag assigned to the message by the channel.
+// The lock keeps tags in the order messages are published.
+func (t *confirmTracker) publish(fn func() error) (*broker.Confirmation, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil, ErrChannelClosed
+	}
+	if err := fn(); err != nil {
+		return nil, err
+	}
+
+	c := broker.NewConfirmation(t.timeout)
+	t.pending[t.nextTag] = c
+	t.nextTag++
+	return c, nil
+}
+
+// listen resolves confirmations until the channel closes, then fails the rest.
+func (t *confirmTracker) listen(confirms <-chan amqp.Confirmation) {
+	for c := range confirms {
+		t.resolve(c.DeliveryTag, c.Ack)
+	}
+	t.close()
+}
+
+func (t *confirmTracker) resolve(tag uint64, ack bool) {
+	t.mu.Lock()
+	c, ok := t.pending[tag]
+	delete(t.pending, tag)
+	t.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	if ack {
+		c.Resolve(nil)
+	} else {
+		c.Resolve(broker.ErrNotConfirmed)
+	}
+}
+
+func (t *confirmTracker) close() {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[uint64]*broker.Confirmation)
+	t.closed = true
+	t.mu.Unlock()
+
+	for _, c := range pending {
+		c.Resolve(ErrChannelClosed)
+	}
+}
+
+func (t *confirmTracker) inFlight() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.pending)
+}
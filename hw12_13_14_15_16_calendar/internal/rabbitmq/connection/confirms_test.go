@@ -0,0 +1,51 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmTracker(t *testing.T) {
+	ctx := context.Background()
+	tr := newConfirmTracker(time.Second)
+	confirms := make(chan amqp.Confirmation, 3)
+	go tr.listen(confirms)
+
+	first, err := tr.publish(func() error { return nil })
+	require.NoError(t, err)
+	second, err := tr.publish(func() error { return nil })
+	require.NoError(t, err)
+	third, err := tr.publish(func() error { return nil })
+	require.NoError(t, err)
+
+	_, err = tr.publish(func() error { return errors.New("boom") })
+	require.Error(t, err)
+	require.Equal(t, 3, tr.inFlight())
+
+	// Подтверждения могут приходить не по порядку
+	confirms <- amqp.Confirmation{DeliveryTag: 2, Ack: false}
+	confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+
+	require.NoError(t, first.Wait(ctx))
+	require.ErrorIs(t, second.Wait(ctx), broker.ErrNotConfirmed)
+
+	close(confirms)
+	require.ErrorIs(t, third.Wait(ctx), ErrChannelClosed)
+
+	_, err = tr.publish(func() error { return nil })
+	require.ErrorIs(t, err, ErrChannelClosed)
+}
+
+func TestConfirmTracker_Timeout(t *testing.T) {
+	tr := newConfirmTracker(10 * time.Millisecond)
+
+	c, err := tr.publish(func() error { return nil })
+	require.NoError(t, err)
+	require.ErrorIs(t, c.Wait(context.Background()), broker.ErrConfirmTimeout)
+}
@@ -0,0 +1,273 @@
+// Package connection поддерживает соединение с RabbitMQ и восстанавливает его
+// при обрывах для производителя и потребителя.
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/streadway/amqp"
+)
+
+// State describes the connection state reported to callbacks.
+type State int
+
+const (
+	// StateConnecting is reported before every connection attempt.
+	StateConnecting State = iota
+	// StateConnected is reported when the channel is ready and the topology is declared.
+	StateConnected
+	// StateDisconnected is reported when the connection or the channel is lost.
+	StateDisconnected
+	// StateClosed is reported when the supervisor is closed.
+	StateClosed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateClosed:
+		return "closed"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// ErrNotConnected is returned by Check while the connection or the channel is down.
+var ErrNotConnected = errors.New("not connected to RabbitMQ")
+
+// SetupFunc prepares a new channel: configures it and declares the topology.
+// It is called after every (re)connection before the channel is used.
+type SetupFunc func(ctx context.Context, ch *Channel) error
+
+// StateFunc is notified about connection state changes. err is the cause of
+// a disconnection or a failed attempt.
+type StateFunc func(state State, err error)
+
+// Supervisor owns a connection and a channel and restores them when they are closed.
+type Supervisor struct {
+	uri     string
+	cfg     ReconnectConf
+	setup   SetupFunc
+	onState StateFunc
+	logger  *slog.Logger
+
+	mu      sync.RWMutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (s *Supervisor) setLogCompMeth(ctx context.Context, method string) context.Context {
+	ctx = logger.WithLogComponent(ctx, "rabbitmq.connection")
+	return logger.WithLogMethod(ctx, method)
+}
+
+// New creates a supervisor. setup and onState may be nil.
+func New(uri string, cfg ReconnectConf, setup SetupFunc, onState StateFunc, logger *slog.Logger) *Supervisor {
+	if setup == nil {
+		setup = func(context.Context, *Channel) error { return nil }
+	}
+	if onState == nil {
+		onState = func(State, error) {}
+	}
+	return &Supervisor{
+		uri:     uri,
+		cfg:     cfg.withDefaults(),
+		setup:   setup,
+		onState: onState,
+		logger:  logger,
+		done:    make(chan struct{}),
+	}
+}
+
+// Start connects to RabbitMQ, making up to MaxAttempts attempts, and then
+// watches the connection in background until ctx is done or Close is called.
+func (s *Supervisor) Start(ctx context.Context) error {
+	ctx = s.setLogCompMeth(ctx, "Start")
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	if err := s.connect(ctx, s.cfg.MaxAttempts); err != nil {
+		s.cancel()
+		close(s.done)
+		return err
+	}
+
+	go s.watch(ctx)
+	return nil
+}
+
+// Close stops reconnecting and closes the connection.
+func (s *Supervisor) Close() error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	<-s.done
+
+	s.mu.Lock()
+	conn := s.conn
+	s.conn, s.channel = nil, nil
+	s.mu.Unlock()
+
+	s.onState(StateClosed, nil)
+	if conn == nil {
+		return nil
+	}
+	if err := conn.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+		return fmt.Errorf("Supervisor.Close: %w", err)
+	}
+	return nil
+}
+
+// Check reports whether the connection and the channel are open.
+func (s *Supervisor) Check(context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.conn == nil || s.conn.IsClosed() || s.channel == nil {
+		return ErrNotConnected
+	}
+	return nil
+}
+
+// watch waits for the connection or the channel to close and restores them.
+func (s *Supervisor) watch(ctx context.Context) {
+	defer close(s.done)
+	ctx = s.setLogCompMeth(ctx, "watch")
+
+	for {
+		s.mu.RLock()
+		conn := s.conn
+		s.mu.RUnlock()
+
+		// Слушатель закрытия регистрируется один раз на соединение, а не на каждый канал.
+		// Каналы уведомлений закрываются библиотекой вместе с соединением,
+		// поэтому отдельные горутины для них не нужны
+		connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+		if !s.watchChannel(ctx, conn, connClosed) {
+			return
+		}
+
+		if err := s.connect(ctx, 0); err != nil {
+			return
+		}
+	}
+}
+
+// watchChannel re-creates the channel while conn is alive. It returns false when ctx is done
+// and true when the connection is lost and has to be restored.
+func (s *Supervisor) watchChannel(ctx context.Context, conn *amqp.Connection, connClosed <-chan *amqp.Error) bool {
+	for {
+		s.mu.RLock()
+		ch := s.channel
+		s.mu.RUnlock()
+
+		chClosed := ch.NotifyClose(make(chan *amqp.Error, 1))
+
+		var reason error
+		select {
+		case <-ctx.Done():
+			return false
+		case amqpErr := <-connClosed:
+			reason = closeReason(amqpErr)
+			s.logger.WarnContext(ctx, "connection closed", "error", reason)
+		case amqpErr := <-chClosed:
+			reason = closeReason(amqpErr)
+			s.logger.WarnContext(ctx, "channel closed", "error", reason)
+		}
+
+		s.mu.Lock()
+		s.channel = nil
+		s.mu.Unlock()
+		s.onState(StateDisconnected, reason)
+
+		if conn.IsClosed() {
+			return true
+		}
+		// Если соединение живо, достаточно пересоздать канал
+		if err := s.openChannel(ctx, conn); err != nil {
+			_ = conn.Close()
+			return true
+		}
+	}
+}
+
+// connect dials RabbitMQ until it succeeds, maxAttempts is reached (0 means no limit) or ctx is done.
+func (s *Supervisor) connect(ctx context.Context, maxAttempts int) error {
+	var err error
+	for attempt := 1; maxAttempts == 0 || attempt <= maxAttempts; attempt++ {
+		s.onState(StateConnecting, nil)
+		s.logger.DebugContext(ctx, "try connecting to RabbitMQ", slog.Int("attempt", attempt))
+
+		if err = s.dial(ctx); err == nil {
+			return nil
+		}
+
+		delay := s.cfg.backoff(attempt)
+		s.logger.WarnContext(ctx, "failed to connect to RabbitMQ", slog.Int("attempt", attempt),
+			slog.Duration("retry_in", delay), slog.String("error", err.Error()))
+		s.onState(StateDisconnected, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return logger.AddPrefix(ctx, fmt.Errorf("connection cancelled: %w", ctx.Err()))
+		case <-timer.C:
+		}
+	}
+	return logger.AddPrefix(ctx, fmt.Errorf("failed to connect to RabbitMQ after %d attempts: %w",
+		maxAttempts, err))
+}
+
+func (s *Supervisor) dial(ctx context.Context) error {
+	conn, err := amqp.Dial(s.uri)
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+	if err := s.openChannel(ctx, conn); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	return nil
+}
+
+// openChannel opens a channel on conn, runs the setup hook and makes the channel current.
+func (s *Supervisor) openChannel(ctx context.Context, conn *amqp.Connection) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("channel: %w", err)
+	}
+	if err := s.setup(ctx, NewChannel(ch)); err != nil {
+		_ = ch.Close()
+		return fmt.Errorf("setup: %w", err)
+	}
+
+	s.mu.Lock()
+	s.conn, s.channel = conn, ch
+	s.mu.Unlock()
+
+	s.logger.InfoContext(ctx, "connection established")
+	s.onState(StateConnected, nil)
+	return nil
+}
+
+func closeReason(err *amqp.Error) error {
+	if err == nil {
+		return amqp.ErrClosed
+	}
+	return err
+}
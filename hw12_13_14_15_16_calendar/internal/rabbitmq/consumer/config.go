@@ -1,11 +1,78 @@
 package consumer
 
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/rabbitmq/connection"
+)
+
 // RabbitMQConf defines RabbitMQ consumer configuration.
 type RabbitMQConf struct {
-	URI          string `toml:"uri" env:"URI"`
-	Exchange     string `toml:"exchange" env:"EXCHANGE"`
-	ExchangeType string `toml:"exchange_type" env:"EXCHANGE_TYPE"`
-	Queue        string `toml:"queue" env:"QUEUE"`
-	BindingKey   string `toml:"binding_key" env:"BINDING_KEY"`
-	ConsumerTag  string `toml:"consumer_tag" env:"CONSUMER_TAG"`
+	URI          string        `toml:"uri" env:"URI" secret:"credentials"`
+	Exchange     string        `toml:"exchange" env:"EXCHANGE"`
+	ExchangeType string        `toml:"exchange_type" env:"EXCHANGE_TYPE"`
+	Queue        string        `toml:"queue" env:"QUEUE"`
+	BindingKey   string        `toml:"binding_key" env:"BINDING_KEY"`
+	ConsumerTag  string        `toml:"consumer_tag" env:"CONSUMER_TAG"`
+	MaxRetries   int           `toml:"max_retries" env:"MAX_RETRIES"`
+	RetryDelay   time.Duration `toml:"retry_delay" env:"RETRY_DELAY"`
+	Prefetch     int           `toml:"prefetch" env:"PREFETCH"`
+	Workers      int           `toml:"workers" env:"WORKERS"`
+
+	Reconnect connection.ReconnectConf `toml:"reconnect" envPrefix:"RECONNECT_"`
+}
+
+// Validate checks the connection, the topology and the limits.
+func (cfg RabbitMQConf) Validate() error {
+	if err := connection.ValidateURI(cfg.URI); err != nil {
+		return fmt.Errorf("uri: %w", err)
+	}
+	if cfg.Exchange == "" {
+		return errors.New("exchange: required")
+	}
+	if err := connection.ValidateExchangeType(cfg.ExchangeType); err != nil {
+		return fmt.Errorf("exchange_type: %w", err)
+	}
+	if cfg.Queue == "" {
+		return errors.New("queue: required")
+	}
+	if cfg.MaxRetries < 0 {
+		return fmt.Errorf("max_retries: must not be negative, got %d", cfg.MaxRetries)
+	}
+	if cfg.Prefetch < 0 {
+		return fmt.Errorf("prefetch: must not be negative, got %d", cfg.Prefetch)
+	}
+	if cfg.Workers < 0 {
+		return fmt.Errorf("workers: must not be negative, got %d", cfg.Workers)
+	}
+	if cfg.RetryDelay < 0 {
+		return fmt.Errorf("retry_delay: must not be negative, got %s", cfg.RetryDelay)
+	}
+	if err := cfg.Reconnect.Validate(); err != nil {
+		return fmt.Errorf("reconnect.%w", err)
+	}
+	return nil
+}
+
+// retryExchange returns the name of the exchange that routes messages to delay queues.
+func (cfg RabbitMQConf) retryExchange() string {
+	return cfg.Exchange + ".retry"
+}
+
+// retryQueue returns the name of the delay queue for the given attempt.
+// The delay is set on each message, so changing retry_delay keeps the queue arguments.
+func (cfg RabbitMQConf) retryQueue(attempt int) string {
+	return fmt.Sprintf("%s.delay.%d", cfg.Queue, attempt)
+}
+
+// deadLetterExchange returns the name of the exchange receiving rejected messages.
+func (cfg RabbitMQConf) deadLetterExchange() string {
+	return cfg.Exchange + ".dlx"
+}
+
+// DeadLetterQueue returns the name of the queue storing rejected messages.
+func (cfg RabbitMQConf) DeadLetterQueue() string {
+	return cfg.Queue + ".dlq"
 }
@@ -2,27 +2,42 @@ package consumer
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
-	"time"
+	"sync"
 
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
-	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
-	"github.com/streadway/amqp"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/metrics"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/notification"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/rabbitmq/connection"
 )
 
-// RabbitConsumer consumes events from RabbitMQ.
+// Handler processes notifications received from the queue.
+// A delivery is acknowledged only after Handle returns nil.
+type Handler interface {
+	Handle(ctx context.Context, e notification.Envelope) error
+}
+
+// RabbitConsumer consumes events from RabbitMQ or from another broker.Channel.
 type RabbitConsumer struct {
-	conn      *amqp.Connection
-	channel   *amqp.Channel
-	tag       string
-	queue     amqp.Queue
-	logger    *slog.Logger
-	cancel    context.CancelFunc
-	done      chan error
-	reconnect chan struct{}
+	// supervisor restores the connection to RabbitMQ; it is nil over other brokers.
+	supervisor *connection.Supervisor
+	cfg        RabbitMQConf
+	tag        string
+	retry      retryPolicy
+	prefetch   int
+	workers    int
+	logger     *slog.Logger
+	done       chan error
+	reconnect  chan struct{}
+	stopping   chan struct{}
+	stopOnce   sync.Once
+
+	// mu guards the channel replaced on reconnection.
+	mu      sync.RWMutex
+	channel broker.Channel
 }
 
 func (c *RabbitConsumer) setLogCompMeth(ctx context.Context, method string) context.Context {
@@ -30,102 +45,100 @@ func (c *RabbitConsumer) setLogCompMeth(ctx context.Context, method string) cont
 	return logger.WithLogMethod(ctx, method)
 }
 
-// NewRabbitConsumer creates and configures a RabbitMQ consumer.
-func NewRabbitConsumer(ctx context.Context, cfg RabbitMQConf, lg *slog.Logger) (*RabbitConsumer, error) {
-	ctx, cancel := context.WithCancel(ctx)
-	c := &RabbitConsumer{
+func newConsumer(cfg RabbitMQConf, lg *slog.Logger) *RabbitConsumer {
+	return &RabbitConsumer{
+		cfg:       cfg,
 		tag:       cfg.ConsumerTag,
+		retry:     newRetryPolicy(cfg),
+		prefetch:  cfg.Prefetch,
+		workers:   cfg.Workers,
 		logger:    lg,
-		cancel:    cancel,
 		done:      make(chan error),
 		reconnect: make(chan struct{}, 1),
+		stopping:  make(chan struct{}),
 	}
+}
 
-	if err := c.connectWithRetry(ctx, cfg.URI); err != nil {
-		return nil, err
-	}
+// NewRabbitConsumer creates and configures a RabbitMQ consumer.
+func NewRabbitConsumer(ctx context.Context, cfg RabbitMQConf, lg *slog.Logger) (*RabbitConsumer, error) {
+	c := newConsumer(cfg, lg)
 
-	if err := c.initChannel(ctx); err != nil {
+	c.supervisor = connection.New(cfg.URI, cfg.Reconnect, c.setupRabbitChannel, c.onStateChange, lg)
+	if err := c.supervisor.Start(ctx); err != nil {
 		return nil, err
 	}
 
-	if err := c.declareExchangeQueueBind(ctx, cfg); err != nil {
+	return c, nil
+}
+
+// NewConsumer creates a consumer of ch, e.g. the in-memory broker.
+// The URI, the prefetch and the reconnection settings of cfg are not used.
+func NewConsumer(ctx context.Context, ch broker.Channel, cfg RabbitMQConf, lg *slog.Logger) (*RabbitConsumer, error) {
+	c := newConsumer(cfg, lg)
+	if err := c.setupChannel(ctx, ch); err != nil {
 		return nil, err
 	}
-
-	c.reconnect <- struct{}{}
-	go c.startReconnectLoop(ctx, cfg)
-
 	return c, nil
 }
 
-func (c *RabbitConsumer) connectWithRetry(ctx context.Context, uri string) error {
-	ctx = c.setLogCompMeth(ctx, "connectWithRetry")
-	const (
-		maxAttempts = 5
-		retryDelay  = 2 * time.Second
-	)
-
-	var err error
-	for i := 1; i <= maxAttempts; i++ {
-		c.logger.DebugContext(ctx, "try connecting to RabbitMQ", slog.String("uri", uri), slog.Int("attempt", i))
-
-		c.conn, err = amqp.Dial(uri)
-		if err == nil {
-			break
+// setupRabbitChannel sets the prefetch count of every new RabbitMQ channel.
+func (c *RabbitConsumer) setupRabbitChannel(ctx context.Context, ch *connection.Channel) error {
+	if c.prefetch > 0 {
+		ctx := c.setLogCompMeth(ctx, "setupRabbitChannel")
+		if err := ch.Qos(c.prefetch); err != nil {
+			return logger.AddPrefix(ctx, fmt.Errorf("qos: %w", err))
 		}
+		c.logger.InfoContext(ctx, "prefetch count set", "prefetch", c.prefetch)
+	}
+	return c.setupChannel(ctx, ch)
+}
 
-		c.logger.WarnContext(ctx, "failed to connect to RabbitMQ", "error", err)
+// setupChannel declares the topology and makes Handle resume consuming from ch.
+func (c *RabbitConsumer) setupChannel(ctx context.Context, ch broker.Channel) error {
+	ctx = c.setLogCompMeth(ctx, "setupChannel")
 
-		select {
-		case <-ctx.Done():
-			c.logger.InfoContext(ctx, "connection cancelled", "error", ctx.Err())
-			return logger.AddPrefix(ctx, fmt.Errorf("connection cancelled: %w", ctx.Err()))
-		case <-time.After(retryDelay):
-			// Pause before the next attempt
-		}
-	}
-	if err != nil {
-		return logger.AddPrefix(ctx, fmt.Errorf("failed to connect to RabbitMQ after %d attempts: %w",
-			maxAttempts, err))
+	if err := c.declareExchangeQueueBind(ctx, ch, c.cfg); err != nil {
+		return err
 	}
 
-	c.logger.InfoContext(ctx, "connection established")
-
-	// отслеживаем закрытие подключения брокером
-	go func() {
-		c.logger.WarnContext(ctx, "connection closed", "error", <-c.conn.NotifyClose(make(chan *amqp.Error)))
-	}()
+	c.mu.Lock()
+	c.channel = ch
+	c.mu.Unlock()
 
+	select {
+	case c.reconnect <- struct{}{}:
+	default:
+	}
 	return nil
 }
 
-func (c *RabbitConsumer) initChannel(ctx context.Context) error {
-	ctx = c.setLogCompMeth(ctx, "initChannel")
-	c.logger.DebugContext(ctx, "trying to initialize channel")
-	var err error
-	c.channel, err = c.conn.Channel()
-	if err != nil {
-		return logger.AddPrefix(ctx, fmt.Errorf("channel: %w", err))
+// Check reports whether the connection to RabbitMQ is usable.
+func (c *RabbitConsumer) Check(ctx context.Context) error {
+	if c.supervisor == nil {
+		return nil
 	}
-	c.logger.InfoContext(ctx, "channel initialized")
-	return nil
+	return c.supervisor.Check(ctx)
 }
 
-func (c *RabbitConsumer) declareExchangeQueueBind(ctx context.Context, cfg RabbitMQConf) error {
+func (c *RabbitConsumer) onStateChange(state connection.State, err error) {
+	ctx := c.setLogCompMeth(context.Background(), "onStateChange")
+	c.logger.DebugContext(ctx, "connection state changed", "state", state.String(), "error", err)
+	metrics.ObserveConnection("consumer", state == connection.StateConnected)
+}
+
+// current returns the channel to consume from.
+func (c *RabbitConsumer) current() broker.Channel {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.channel
+}
+
+func (c *RabbitConsumer) declareExchangeQueueBind(ctx context.Context, ch broker.Channel, cfg RabbitMQConf) error {
 	ctx = c.setLogCompMeth(ctx, "declareExchangeQueueBind")
 
 	c.logger.DebugContext(ctx, "try declaring exchange", "type", cfg.ExchangeType, "name", cfg.Exchange)
 
-	if err := c.channel.ExchangeDeclare(
-		cfg.Exchange,
-		cfg.ExchangeType,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	); err != nil {
+	if err := ch.DeclareExchange(cfg.Exchange, cfg.ExchangeType); err != nil {
 		return logger.AddPrefix(ctx, fmt.Errorf("exchange declare: %w", err))
 	}
 
@@ -133,157 +146,148 @@ func (c *RabbitConsumer) declareExchangeQueueBind(ctx context.Context, cfg Rabbi
 
 	c.logger.DebugContext(ctx, "try declaring queue", "name", cfg.Queue)
 
-	q, err := c.channel.QueueDeclare(
-		cfg.Queue, true, false, false, false, nil,
-	)
-	if err != nil {
+	if err := c.declareDeadLetter(ctx, ch, cfg); err != nil {
+		return err
+	}
+
+	// Аргументы основной очереди не меняются, чтобы объявление не конфликтовало с уже созданной очередью:
+	// недоставленные сообщения публикуются в обменник недоставленных явно
+	if err := ch.DeclareQueue(cfg.Queue, broker.QueueOptions{}); err != nil {
 		return logger.AddPrefix(ctx, fmt.Errorf("queue declare: %w", err))
 	}
-	c.queue = q
 
 	c.logger.InfoContext(ctx, "queue declared")
 
-	c.logger.DebugContext(ctx, "try binding queue", "name", q.Name, "binding_key",
+	c.logger.DebugContext(ctx, "try binding queue", "name", cfg.Queue, "binding_key",
 		cfg.BindingKey, "exchange", cfg.Exchange)
 
-	if err := c.channel.QueueBind(
-		q.Name, cfg.BindingKey, cfg.Exchange, false, nil,
-	); err != nil {
+	if err := ch.BindQueue(cfg.Queue, cfg.BindingKey, cfg.Exchange); err != nil {
 		return logger.AddPrefix(ctx, fmt.Errorf("queue bind: %w", err))
 	}
 
 	c.logger.InfoContext(ctx, "queue bound")
 
-	return nil
+	return c.declareRetry(ctx, ch, cfg)
 }
 
-// Handle starts consuming messages from RabbitMQ.
-func (c *RabbitConsumer) Handle(ctx context.Context) error {
+// Handle starts consuming messages from RabbitMQ and passes them to h.
+// Deliveries are processed by a pool of workers; deliveries of the same user
+// are always processed by the same worker to preserve their order.
+func (c *RabbitConsumer) Handle(ctx context.Context, h Handler) error {
 	defer close(c.done)
 
 	ctx = c.setLogCompMeth(ctx, "Handle")
 
+	// Обработка уже полученных сообщений не прерывается при остановке
+	workCtx := context.WithoutCancel(ctx)
+	pool := newWorkerPool(c.workers, c.queueCapacity(), func(w work) {
+		if err := c.processDelivery(workCtx, h, w); err != nil {
+			c.logger.ErrorContext(workCtx, "failed to process delivery", "error", err)
+		}
+	})
+	defer func() {
+		c.logger.InfoContext(ctx, "draining in-flight deliveries")
+		pool.stop()
+		c.logger.InfoContext(ctx, "in-flight deliveries drained")
+	}()
+
+	// Отмена подписки возвращает в очередь ещё не полученные сообщения,
+	// она же прерывает ожидание занятого обработчика при остановке
+	consumeCtx, cancelConsume := context.WithCancel(ctx)
+	defer cancelConsume()
+	go func() {
+		select {
+		case <-c.stopping:
+			cancelConsume()
+		case <-consumeCtx.Done():
+		}
+	}()
+
 outer:
 	for {
 		select {
 		case <-ctx.Done():
 			c.logger.InfoContext(ctx, "context cancelled")
 			return logger.AddPrefix(ctx, fmt.Errorf("context cancelled: %w", ctx.Err()))
+		case <-c.stopping:
+			c.logger.InfoContext(ctx, "consumer stopped")
+			return nil
 		case <-c.reconnect:
 		}
 
 		c.logger.DebugContext(ctx, "try consuming", "consumer_tag", c.tag)
 
-		deliveries, err := c.channel.Consume(
-			c.queue.Name,
-			c.tag,
-			false,
-			false,
-			false,
-			false,
-			nil,
-		)
+		deliveries, err := c.current().Consume(consumeCtx, c.cfg.Queue, c.tag)
 		if err != nil {
 			return logger.AddPrefix(ctx, fmt.Errorf("queue consume: %w", err))
 		}
 
-		c.logger.InfoContext(ctx, "messages are being consumed")
+		c.logger.InfoContext(ctx, "messages are being consumed", "workers", pool.size())
 
 		for {
 			select {
 			case <-ctx.Done():
 				c.logger.InfoContext(ctx, "context cancelled")
 				return logger.AddPrefix(ctx, fmt.Errorf("context cancelled: %w", ctx.Err()))
+			case <-c.stopping:
+				c.logger.InfoContext(ctx, "consumer stopped")
+				return nil
 			case d, ok := <-deliveries:
 				if !ok {
 					c.logger.InfoContext(ctx, "deliveries channel closed")
 					continue outer
 				}
 
-				c.logger.DebugContext(ctx,
-					"received delivery",
-					"size", len(d.Body),
-					"tag", d.DeliveryTag,
-					"body", string(d.Body),
-				)
-
-				c.logger.InfoContext(ctx, "message delivered", "delivery_tag", d.DeliveryTag)
-
-				c.logger.DebugContext(ctx, "try unmarshalling notification")
-				var notification storage.Notification
-				if err := json.Unmarshal(d.Body, &notification); err != nil {
-					return logger.AddPrefix(ctx, fmt.Errorf("error during unmarshal: %w", err))
-				}
-				c.logger.InfoContext(ctx, "notification unmarshalled", "notification", notification)
-
-				if err := c.ackDelivery(ctx, d); err != nil {
-					return err
+				// Сообщение декодируется один раз: для выбора обработчика и для самой обработки
+				if err := pool.dispatch(consumeCtx, decode(d)); err != nil {
+					// Сообщение не передано обработчику и будет доставлено повторно
+					if nackErr := d.Nack(true); nackErr != nil {
+						c.logger.ErrorContext(ctx, "failed to requeue message", "error", nackErr)
+					}
 				}
-
-				c.logger.InfoContext(ctx, "notification event", "notification", notification)
 			}
 		}
 	}
 }
 
-// Shutdown gracefully closes consumer resources.
-func (c *RabbitConsumer) Shutdown() error {
+// queueCapacity returns the capacity of a worker queue. Deliveries of the prefetch window
+// are spread over the workers, so a busy worker does not stop dispatching to the others.
+func (c *RabbitConsumer) queueCapacity() int {
+	workers := c.workers
+	if workers < 1 {
+		workers = 1
+	}
+	return c.prefetch / workers
+}
+
+// Shutdown stops consuming, waits until in-flight deliveries are processed
+// or ctx is done, and closes consumer resources.
+func (c *RabbitConsumer) Shutdown(ctx context.Context) error {
 	var errs []error
 
-	if err := c.channel.Cancel(c.tag, true); err != nil {
-		errs = append(errs, fmt.Errorf("RabbitConsumer.Shutdown: consumer cancel failed: %w", err))
-	}
+	// Handle отменяет подписку и дожидается обработки полученных сообщений
+	c.stopOnce.Do(func() { close(c.stopping) })
 
-	if err := c.conn.Close(); err != nil {
-		errs = append(errs, fmt.Errorf("RabbitConsumer.Shutdown: AMQP connection close error: %w", err))
+	// Ожидаем завершения Handle и обработки полученных сообщений
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("RabbitConsumer.Shutdown: drain in-flight deliveries: %w", ctx.Err()))
 	}
 
-	c.cancel()
-	// Ожидаем завершения Handle
-	<-c.done
+	if c.supervisor != nil {
+		if err := c.supervisor.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("RabbitConsumer.Shutdown: %w", err))
+		}
+	}
 
 	return errors.Join(errs...)
 }
 
-func (c *RabbitConsumer) ackDelivery(ctx context.Context, d amqp.Delivery) error {
-	if err := d.Ack(false); err != nil {
+func (c *RabbitConsumer) ackDelivery(ctx context.Context, d broker.Delivery) error {
+	if err := d.Ack(); err != nil {
 		c.logger.ErrorContext(ctx, "failed to acknowledge message", slog.String("error", err.Error()))
 		return logger.AddPrefix(ctx, fmt.Errorf("ack: %w", err))
 	}
 	return nil
 }
-
-func (c *RabbitConsumer) startReconnectLoop(ctx context.Context, cfg RabbitMQConf) {
-	notify := c.conn.NotifyClose(make(chan *amqp.Error))
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case errNotify := <-notify:
-			if errNotify != nil {
-				c.logger.WarnContext(ctx, "connection closed", slog.String("error", errNotify.Error()))
-			}
-
-			for ctx.Err() == nil {
-				if err := c.connectWithRetry(ctx, cfg.URI); err != nil {
-					continue
-				}
-				if err := c.initChannel(ctx); err != nil {
-					continue
-				}
-				if err := c.declareExchangeQueueBind(ctx, cfg); err != nil {
-					continue
-				}
-
-				notify = c.conn.NotifyClose(make(chan *amqp.Error))
-
-				select {
-				case c.reconnect <- struct{}{}:
-				default:
-				}
-
-				break
-			}
-		}
-	}
-}
@@ -4,29 +4,30 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
+	memorybroker "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker/memory"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
-	"github.com/streadway/amqp"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/notification"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/rabbitmq/producer"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
-type ackMock struct{ err error }
-
-func (a *ackMock) Ack(tag uint64, multiple bool) error {
-	_ = tag
-	_ = multiple
-	return a.err
+type ackMock struct {
+	err error
 }
 
-func (a *ackMock) Nack(tag uint64, multiple bool, requeue bool) error {
+func (a *ackMock) Ack(tag uint64) error {
 	_ = tag
-	_ = multiple
-	_ = requeue
-	return nil
+	return a.err
 }
 
-func (a *ackMock) Reject(tag uint64, requeue bool) error {
+func (a *ackMock) Nack(tag uint64, requeue bool) error {
 	_ = tag
 	_ = requeue
 	return nil
@@ -37,7 +38,7 @@ func TestAckDeliveryError(t *testing.T) {
 	lg := logger.New("debug", &buf, false)
 	c := &RabbitConsumer{logger: lg}
 
-	d := amqp.Delivery{
+	d := broker.Delivery{
 		Acknowledger: &ackMock{err: errors.New("ack error")},
 		DeliveryTag:  1,
 	}
@@ -46,3 +47,205 @@ func TestAckDeliveryError(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, buf.String(), "failed to acknowledge message")
 }
+
+// handlerMock fails the first fails calls and records received envelopes.
+type handlerMock struct {
+	mu    sync.Mutex
+	fails int
+	calls int
+	got   []notification.Envelope
+}
+
+func (h *handlerMock) Handle(_ context.Context, e notification.Envelope) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls++
+	h.got = append(h.got, e)
+	if h.calls <= h.fails {
+		return errors.New("smtp down")
+	}
+	return nil
+}
+
+func (h *handlerMock) callCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.calls
+}
+
+func TestRetryPolicy(t *testing.T) {
+	p := newRetryPolicy(RabbitMQConf{Queue: "q", RetryDelay: time.Second, MaxRetries: 3})
+	require.Equal(t, time.Second, p.delay(1))
+	require.Equal(t, 2*time.Second, p.delay(2))
+	require.Equal(t, 4*time.Second, p.delay(3))
+
+	p = newRetryPolicy(RabbitMQConf{MaxRetries: -1})
+	require.Equal(t, 0, p.maxRetries)
+	require.Equal(t, defaultRetryDelay, p.baseDelay)
+
+	require.Equal(t, 0, retryCount(nil))
+	require.Equal(t, 2, retryCount(map[string]interface{}{retryCountHeader: int32(2)}))
+	require.Equal(t, 3, retryCount(map[string]interface{}{retryCountHeader: int64(3)}))
+}
+
+// flow runs the producer and the consumer over the in-memory broker.
+type flow struct {
+	broker   *memorybroker.Broker
+	producer *producer.RabbitProducer
+	cfg      RabbitMQConf
+}
+
+func newFlow(t *testing.T, h Handler, maxRetries int) *flow {
+	t.Helper()
+
+	lg := logger.New("debug", &bytes.Buffer{}, false)
+	ctx := context.Background()
+	b := memorybroker.New(lg)
+	t.Cleanup(func() { _ = b.Close() })
+
+	cfg := RabbitMQConf{
+		Exchange:     "events",
+		ExchangeType: broker.ExchangeDirect,
+		Queue:        "notifications",
+		BindingKey:   "notify",
+		MaxRetries:   maxRetries,
+		RetryDelay:   10 * time.Millisecond,
+		Workers:      2,
+	}
+	c, err := NewConsumer(ctx, b, cfg, lg)
+	require.NoError(t, err)
+
+	p, err := producer.NewProducer(ctx, b, producer.RabbitMQConf{
+		Exchange:     cfg.Exchange,
+		ExchangeType: cfg.ExchangeType,
+		RoutingKey:   cfg.BindingKey,
+		Reliable:     true,
+	}, lg)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- c.Handle(ctx, h) }()
+	t.Cleanup(func() {
+		shutdownCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		require.NoError(t, c.Shutdown(shutdownCtx))
+		require.NoError(t, <-done)
+	})
+
+	return &flow{broker: b, producer: p, cfg: cfg}
+}
+
+func (f *flow) publish(t *testing.T, msg broker.Message) {
+	t.Helper()
+
+	confirm, err := f.producer.Publish(context.Background(), msg)
+	require.NoError(t, err)
+	require.NoError(t, confirm.Wait(context.Background()))
+}
+
+func (f *flow) publishNotification(t *testing.T) notification.Envelope {
+	t.Helper()
+
+	e := notification.New(storage.Notification{ID: uuid.New(), UserID: uuid.New(), Title: "meeting"}, time.Now())
+	msg, err := notification.Marshal(e, notification.ContentTypeProtobuf)
+	require.NoError(t, err)
+	f.publish(t, msg)
+	return e
+}
+
+// requireSettled waits until the queue has no ready and unacknowledged messages.
+func (f *flow) requireSettled(t *testing.T, queue string) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		ready, unacked, err := f.broker.QueueLen(queue)
+		return err == nil && ready == 0 && unacked == 0
+	}, time.Second, 5*time.Millisecond)
+}
+
+func (f *flow) deadLettered(t *testing.T) int {
+	t.Helper()
+
+	ready, _, err := f.broker.QueueLen(f.cfg.DeadLetterQueue())
+	require.NoError(t, err)
+	return ready
+}
+
+// takeDeadLetter receives a message from the dead-letter queue.
+func (f *flow) takeDeadLetter(t *testing.T) broker.Delivery {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	deliveries, err := f.broker.Consume(ctx, f.cfg.DeadLetterQueue(), "")
+	require.NoError(t, err)
+
+	select {
+	case d := <-deliveries:
+		require.NoError(t, d.Ack())
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("no dead-lettered message")
+		return broker.Delivery{}
+	}
+}
+
+func TestConsumer_Flow(t *testing.T) {
+	t.Run("success is acknowledged", func(t *testing.T) {
+		h := &handlerMock{}
+		f := newFlow(t, h, 3)
+
+		e := f.publishNotification(t)
+
+		require.Eventually(t, func() bool { return h.callCount() == 1 }, time.Second, 5*time.Millisecond)
+		f.requireSettled(t, f.cfg.Queue)
+		require.Equal(t, e.ID, h.got[0].ID)
+		require.Equal(t, e.Payload.UserID, h.got[0].Payload.UserID)
+		require.Equal(t, 0, f.deadLettered(t))
+	})
+
+	t.Run("poison message is dead-lettered", func(t *testing.T) {
+		h := &handlerMock{}
+		f := newFlow(t, h, 3)
+
+		f.publish(t, broker.Message{ContentType: notification.ContentTypeJSON, Body: []byte(`{`)})
+
+		d := f.takeDeadLetter(t)
+		f.requireSettled(t, f.cfg.Queue)
+		require.Equal(t, 0, h.callCount())
+		require.Equal(t, reasonPoison, d.Headers[reasonHeader])
+		require.Equal(t, []byte(`{`), d.Body)
+	})
+
+	t.Run("failed delivery is retried", func(t *testing.T) {
+		h := &handlerMock{fails: 2}
+		f := newFlow(t, h, 3)
+
+		e := f.publishNotification(t)
+
+		require.Eventually(t, func() bool { return h.callCount() == 3 }, 2*time.Second, 5*time.Millisecond)
+		f.requireSettled(t, f.cfg.Queue)
+		for _, got := range h.got {
+			require.Equal(t, e.ID, got.ID)
+		}
+		require.Equal(t, 0, f.deadLettered(t))
+	})
+
+	t.Run("exhausted retries are dead-lettered", func(t *testing.T) {
+		h := &handlerMock{fails: 100}
+		f := newFlow(t, h, 2)
+
+		e := f.publishNotification(t)
+
+		d := f.takeDeadLetter(t)
+		require.Equal(t, 3, h.callCount())
+		require.Equal(t, reasonExhausted, d.Headers[reasonHeader])
+		require.Equal(t, "smtp down", d.Headers[lastErrorHeader])
+		require.Equal(t, 2, retryCount(d.Headers))
+		require.Zero(t, d.Expiration)
+
+		got, err := notification.Unmarshal(d.ContentType, d.Body)
+		require.NoError(t, err)
+		require.Equal(t, e.ID, got.ID)
+	})
+}
@@ -0,0 +1,183 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/rabbitmq/connection"
+	"github.com/streadway/amqp"
+)
+
+// DeadLetter describes a message stored in the dead-letter queue.
+type DeadLetter struct {
+	MessageID   string    `json:"messageId,omitempty"`
+	Timestamp   time.Time `json:"timestamp,omitempty"`
+	Retries     int       `json:"retries"`
+	LastError   string    `json:"lastError,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	Body        string    `json:"body"`
+	Redelivered bool      `json:"redelivered"`
+}
+
+// replayConfirmTimeout limits waiting for the broker to confirm a replayed message.
+const replayConfirmTimeout = 5 * time.Second
+
+// deadLetterChannel is the part of connection.Channel used by DeadLetterQueue.
+type deadLetterChannel interface {
+	Get(queue string) (broker.Delivery, bool, error)
+	Publish(ctx context.Context, exchange, routingKey string, msg broker.Message) (*broker.Confirmation, error)
+}
+
+// DeadLetterQueue gives access to messages rejected by the consumer.
+type DeadLetterQueue struct {
+	conn    *amqp.Connection
+	channel deadLetterChannel
+	cfg     RabbitMQConf
+	logger  *slog.Logger
+}
+
+func (q *DeadLetterQueue) setLogCompMeth(ctx context.Context, method string) context.Context {
+	ctx = logger.WithLogComponent(ctx, "rabbitmq.dlq")
+	return logger.WithLogMethod(ctx, method)
+}
+
+// OpenDeadLetterQueue connects to RabbitMQ to inspect or replay dead-lettered messages.
+func OpenDeadLetterQueue(cfg RabbitMQConf, lg *slog.Logger) (*DeadLetterQueue, error) {
+	conn, err := amqp.Dial(cfg.URI)
+	if err != nil {
+		return nil, fmt.Errorf("OpenDeadLetterQueue: dial: %w", err)
+	}
+	amqpCh, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("OpenDeadLetterQueue: channel: %w", err)
+	}
+	// Сообщение удаляется из DLQ только после подтверждения брокером его повторной публикации
+	ch := connection.NewChannel(amqpCh)
+	if err := ch.EnableConfirms(replayConfirmTimeout); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("OpenDeadLetterQueue: confirm mode: %w", err)
+	}
+	return &DeadLetterQueue{conn: conn, channel: ch, cfg: cfg, logger: lg}, nil
+}
+
+// List returns up to limit messages from the dead-letter queue without removing them.
+func (q *DeadLetterQueue) List(ctx context.Context, limit int) ([]DeadLetter, error) {
+	ctx = q.setLogCompMeth(ctx, "List")
+
+	var (
+		res  []DeadLetter
+		read []broker.Delivery
+		err  error
+	)
+	for limit <= 0 || len(res) < limit {
+		d, ok, getErr := q.channel.Get(q.cfg.DeadLetterQueue())
+		if getErr != nil {
+			err = logger.AddPrefix(ctx, fmt.Errorf("get: %w", getErr))
+			break
+		}
+		if !ok {
+			break
+		}
+		res = append(res, toDeadLetter(d))
+		read = append(read, d)
+	}
+
+	// Возвращаем прочитанные сообщения обратно в очередь
+	for _, d := range read {
+		if nackErr := d.Nack(true); nackErr != nil {
+			err = errors.Join(err, logger.AddPrefix(ctx, fmt.Errorf("requeue: %w", nackErr)))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	q.logger.InfoContext(ctx, "dead letters listed", "count", len(res))
+	return res, nil
+}
+
+// Replay moves up to limit messages from the dead-letter queue back to the main queue
+// with the retry counter reset. It returns the number of replayed messages.
+func (q *DeadLetterQueue) Replay(ctx context.Context, limit int) (int, error) {
+	ctx = q.setLogCompMeth(ctx, "Replay")
+
+	replayed := 0
+	for limit <= 0 || replayed < limit {
+		if err := ctx.Err(); err != nil {
+			return replayed, logger.AddPrefix(ctx, err)
+		}
+
+		d, ok, err := q.channel.Get(q.cfg.DeadLetterQueue())
+		if err != nil {
+			return replayed, logger.AddPrefix(ctx, fmt.Errorf("get: %w", err))
+		}
+		if !ok {
+			break
+		}
+
+		msg := d.Message
+		msg.Headers = make(map[string]interface{}, len(d.Headers))
+		msg.Expiration = 0
+		for k, v := range d.Headers {
+			if k == retryCountHeader || k == lastErrorHeader || k == reasonHeader || k == "x-death" {
+				continue
+			}
+			msg.Headers[k] = v
+		}
+
+		// Без подтверждения сообщение возвращается в DLQ, иначе оно потеряется
+		confirm, err := q.channel.Publish(ctx, "", q.cfg.Queue, msg)
+		if err == nil {
+			err = confirm.Wait(ctx)
+		}
+		if err != nil {
+			return replayed, errors.Join(
+				logger.AddPrefix(ctx, fmt.Errorf("publish: %w", err)),
+				d.Nack(true),
+			)
+		}
+		if err := d.Ack(); err != nil {
+			return replayed, logger.AddPrefix(ctx, fmt.Errorf("ack: %w", err))
+		}
+		replayed++
+	}
+
+	q.logger.InfoContext(ctx, "dead letters replayed", "count", replayed)
+	return replayed, nil
+}
+
+// Close closes the connection to RabbitMQ.
+func (q *DeadLetterQueue) Close() error {
+	if q.conn == nil {
+		return nil
+	}
+	return q.conn.Close()
+}
+
+func toDeadLetter(d broker.Delivery) DeadLetter {
+	dl := DeadLetter{
+		MessageID:   d.ID,
+		Timestamp:   d.Timestamp,
+		Retries:     retryCount(d.Headers),
+		Body:        string(d.Body),
+		Redelivered: d.Redelivered,
+	}
+	if s, ok := d.Headers[lastErrorHeader].(string); ok {
+		dl.LastError = s
+	}
+	// У сообщений, отклонённых прежними версиями средствами брокера, причина хранится в x-death
+	if s, ok := d.Headers[reasonHeader].(string); ok {
+		dl.Reason = s
+	} else if deaths, ok := d.Headers["x-death"].([]interface{}); ok && len(deaths) > 0 {
+		if death, ok := deaths[0].(amqp.Table); ok {
+			dl.Reason, _ = death["reason"].(string)
+		}
+	}
+	return dl
+}
@@ -0,0 +1,120 @@
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+// settleMock records how deliveries were settled.
+type settleMock struct {
+	acked    []uint64
+	requeued []uint64
+}
+
+func (s *settleMock) Ack(tag uint64) error {
+	s.acked = append(s.acked, tag)
+	return nil
+}
+
+func (s *settleMock) Nack(tag uint64, requeue bool) error {
+	if requeue {
+		s.requeued = append(s.requeued, tag)
+	}
+	return nil
+}
+
+// deadLetterChannelMock serves deliveries from a slice and fails publishes on demand.
+type deadLetterChannelMock struct {
+	deliveries []broker.Delivery
+	publishErr error
+	confirmErr error
+	published  []broker.Message
+}
+
+func (c *deadLetterChannelMock) Get(_ string) (broker.Delivery, bool, error) {
+	if len(c.deliveries) == 0 {
+		return broker.Delivery{}, false, nil
+	}
+	d := c.deliveries[0]
+	c.deliveries = c.deliveries[1:]
+	return d, true, nil
+}
+
+func (c *deadLetterChannelMock) Publish(
+	_ context.Context,
+	_, _ string,
+	msg broker.Message,
+) (*broker.Confirmation, error) {
+	if c.publishErr != nil {
+		return nil, c.publishErr
+	}
+	c.published = append(c.published, msg)
+	confirm := broker.NewConfirmation(0)
+	confirm.Resolve(c.confirmErr)
+	return confirm, nil
+}
+
+func newDeadLetterQueue(ch *deadLetterChannelMock, settle *settleMock) *DeadLetterQueue {
+	for tag := uint64(1); tag <= 2; tag++ {
+		ch.deliveries = append(ch.deliveries, broker.Delivery{
+			Message: broker.Message{
+				ID:   "msg",
+				Body: []byte(`{}`),
+				Headers: map[string]interface{}{
+					retryCountHeader: int32(3),
+					reasonHeader:     reasonExhausted,
+					"trace":          "t",
+				},
+			},
+			DeliveryTag:  tag,
+			Acknowledger: settle,
+		})
+	}
+	return &DeadLetterQueue{
+		channel: ch,
+		cfg:     RabbitMQConf{Queue: "notifications"},
+		logger:  logger.New("debug", &bytes.Buffer{}, false),
+	}
+}
+
+func TestDeadLetterQueue_Replay(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("confirmed messages are removed", func(t *testing.T) {
+		ch, settle := &deadLetterChannelMock{}, &settleMock{}
+		n, err := newDeadLetterQueue(ch, settle).Replay(ctx, 0)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		require.Equal(t, []uint64{1, 2}, settle.acked)
+		require.Empty(t, settle.requeued)
+		require.Len(t, ch.published, 2)
+		require.Equal(t, map[string]interface{}{"trace": "t"}, ch.published[0].Headers)
+	})
+
+	tests := []struct {
+		name       string
+		publishErr error
+		confirmErr error
+	}{
+		{name: "publish fails", publishErr: errors.New("channel closed")},
+		{name: "broker rejects", confirmErr: broker.ErrNotConfirmed},
+		{name: "confirmation times out", confirmErr: broker.ErrConfirmTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := &deadLetterChannelMock{publishErr: tt.publishErr, confirmErr: tt.confirmErr}
+			settle := &settleMock{}
+			n, err := newDeadLetterQueue(ch, settle).Replay(ctx, 0)
+			require.Error(t, err)
+			require.Equal(t, 0, n)
+			require.Empty(t, settle.acked)
+			require.Equal(t, []uint64{1}, settle.requeued)
+		})
+	}
+}
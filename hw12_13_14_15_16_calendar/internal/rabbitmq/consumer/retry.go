@@ -0,0 +1,248 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/metrics"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	retryCountHeader = "x-retry-count"
+	lastErrorHeader  = "x-last-error"
+	reasonHeader     = "x-dead-letter-reason"
+
+	// Причины, по которым сообщение попадает в очередь недоставленных
+	reasonPoison    = "poison"
+	reasonExhausted = "retries_exhausted"
+
+	defaultRetryDelay = time.Second
+)
+
+// retryPolicy describes how failed deliveries are retried.
+type retryPolicy struct {
+	maxRetries int
+	baseDelay  time.Duration
+	topology   RabbitMQConf
+}
+
+func newRetryPolicy(cfg RabbitMQConf) retryPolicy {
+	p := retryPolicy{
+		maxRetries: cfg.MaxRetries,
+		baseDelay:  cfg.RetryDelay,
+		topology:   cfg,
+	}
+	if p.maxRetries < 0 {
+		p.maxRetries = 0
+	}
+	if p.baseDelay <= 0 {
+		p.baseDelay = defaultRetryDelay
+	}
+	return p
+}
+
+// delay returns the exponential backoff before the given attempt (starting from 1).
+func (p retryPolicy) delay(attempt int) time.Duration {
+	return p.baseDelay << (attempt - 1)
+}
+
+// retryCount extracts the number of previous attempts from delivery headers.
+func retryCount(headers map[string]interface{}) int {
+	switch v := headers[retryCountHeader].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
+
+// declareDeadLetter declares the dead-letter exchange and queue.
+func (c *RabbitConsumer) declareDeadLetter(ctx context.Context, ch broker.Channel, cfg RabbitMQConf) error {
+	c.logger.DebugContext(ctx, "try declaring dead-letter exchange", "name", cfg.deadLetterExchange())
+
+	if err := ch.DeclareExchange(cfg.deadLetterExchange(), broker.ExchangeDirect); err != nil {
+		return logger.AddPrefix(ctx, fmt.Errorf("dead-letter exchange declare: %w", err))
+	}
+
+	dlq := cfg.DeadLetterQueue()
+	if err := ch.DeclareQueue(dlq, broker.QueueOptions{}); err != nil {
+		return logger.AddPrefix(ctx, fmt.Errorf("dead-letter queue declare: %w", err))
+	}
+
+	if err := ch.BindQueue(dlq, cfg.Queue, cfg.deadLetterExchange()); err != nil {
+		return logger.AddPrefix(ctx, fmt.Errorf("dead-letter queue bind: %w", err))
+	}
+
+	c.logger.InfoContext(ctx, "dead-letter queue declared", "name", dlq)
+	return nil
+}
+
+// declareRetry declares the retry exchange and one delay queue per attempt.
+// Messages expire from a delay queue back into the main queue. Every message of a queue
+// waits for the same delay, so expiring messages are always at its head.
+func (c *RabbitConsumer) declareRetry(ctx context.Context, ch broker.Channel, cfg RabbitMQConf) error {
+	if c.retry.maxRetries == 0 {
+		return nil
+	}
+
+	c.logger.DebugContext(ctx, "try declaring retry exchange", "name", cfg.retryExchange())
+
+	if err := ch.DeclareExchange(cfg.retryExchange(), broker.ExchangeDirect); err != nil {
+		return logger.AddPrefix(ctx, fmt.Errorf("retry exchange declare: %w", err))
+	}
+
+	for attempt := 1; attempt <= c.retry.maxRetries; attempt++ {
+		name := cfg.retryQueue(attempt)
+		if err := ch.DeclareQueue(name, broker.QueueOptions{DeadLetterRoutingKey: cfg.Queue}); err != nil {
+			return logger.AddPrefix(ctx, fmt.Errorf("retry queue declare: %w", err))
+		}
+		if err := ch.BindQueue(name, name, cfg.retryExchange()); err != nil {
+			return logger.AddPrefix(ctx, fmt.Errorf("retry queue bind: %w", err))
+		}
+	}
+
+	c.logger.InfoContext(ctx, "retry queues declared", "count", c.retry.maxRetries)
+	return nil
+}
+
+// processDelivery handles a single decoded delivery. Only broker errors are returned:
+// invalid messages go to the dead-letter queue and failed ones are retried.
+func (c *RabbitConsumer) processDelivery(ctx context.Context, h Handler, w work) error {
+	d := w.delivery
+	ctx = tracing.Extract(ctx, d.Headers)
+	ctx, span := tracing.Tracer().Start(ctx, d.Exchange+" process", trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.destination.name", d.Exchange),
+			attribute.String("messaging.rabbitmq.destination.routing_key", d.RoutingKey),
+			attribute.String("messaging.message.id", d.ID),
+			attribute.Int("messaging.rabbitmq.retry_count", retryCount(d.Headers)),
+		))
+	defer span.End()
+
+	c.logger.DebugContext(ctx,
+		"received delivery",
+		"size", len(d.Body),
+		"tag", d.DeliveryTag,
+		"body", string(d.Body),
+	)
+
+	c.logger.InfoContext(ctx, "message delivered", "delivery_tag", d.DeliveryTag)
+	observeDelivery(d)
+
+	if w.err != nil {
+		c.logger.ErrorContext(ctx, "poison message, sending to dead-letter queue",
+			"content_type", d.ContentType, "error", w.err)
+		span.SetStatus(codes.Error, w.err.Error())
+		return c.deadLetter(ctx, d, reasonPoison, w.err)
+	}
+	envelope := w.envelope
+	n := envelope.Payload
+	c.logger.InfoContext(ctx, "notification unmarshalled", "notification", n,
+		"schema_version", envelope.SchemaVersion, "idempotency_key", envelope.IdempotencyKey)
+
+	if err := h.Handle(ctx, envelope); err != nil {
+		c.logger.ErrorContext(ctx, "failed to handle notification", "error", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return c.retryDelivery(ctx, d, err)
+	}
+
+	if err := c.ackDelivery(ctx, d); err != nil {
+		return err
+	}
+	metrics.ConsumerDeliveries.WithLabelValues("acked").Inc()
+
+	c.logger.InfoContext(ctx, "notification event", "notification", n)
+	return nil
+}
+
+// retryDelivery republishes the delivery to the next delay queue and acknowledges the original.
+// When the retry limit is reached the delivery is dead-lettered.
+func (c *RabbitConsumer) retryDelivery(ctx context.Context, d broker.Delivery, cause error) error {
+	attempt := retryCount(d.Headers) + 1
+	if attempt > c.retry.maxRetries {
+		c.logger.WarnContext(ctx, "retry limit reached, sending to dead-letter queue",
+			slog.Int("attempts", attempt-1))
+		return c.deadLetter(ctx, d, reasonExhausted, cause)
+	}
+
+	msg := republished(d, cause)
+	msg.Headers[retryCountHeader] = int32(attempt) //nolint:gosec
+	msg.Expiration = c.retry.delay(attempt)
+
+	topology := c.retry.topology
+	if _, err := c.current().Publish(ctx, topology.retryExchange(), topology.retryQueue(attempt), msg); err != nil {
+		c.logger.ErrorContext(ctx, "failed to schedule retry, requeueing", "error", err)
+		return c.nackDelivery(ctx, d, true)
+	}
+
+	c.logger.InfoContext(ctx, "retry scheduled",
+		slog.Int("attempt", attempt), slog.Duration("delay", c.retry.delay(attempt)))
+	if err := c.ackDelivery(ctx, d); err != nil {
+		return err
+	}
+	metrics.ConsumerDeliveries.WithLabelValues("retried").Inc()
+	return nil
+}
+
+// deadLetter publishes the delivery to the dead-letter exchange and acknowledges the original.
+// If publishing fails the delivery is requeued.
+func (c *RabbitConsumer) deadLetter(ctx context.Context, d broker.Delivery, reason string, cause error) error {
+	msg := republished(d, cause)
+	msg.Headers[reasonHeader] = reason
+
+	topology := c.retry.topology
+	if _, err := c.current().Publish(ctx, topology.deadLetterExchange(), topology.Queue, msg); err != nil {
+		c.logger.ErrorContext(ctx, "failed to dead-letter message, requeueing", "error", err)
+		return c.nackDelivery(ctx, d, true)
+	}
+
+	if err := c.ackDelivery(ctx, d); err != nil {
+		return err
+	}
+	metrics.ConsumerDeliveries.WithLabelValues("dead_lettered").Inc()
+	return nil
+}
+
+// republished returns a copy of the delivered message carrying the error that caused republishing.
+func republished(d broker.Delivery, cause error) broker.Message {
+	msg := d.Message
+	msg.Expiration = 0
+	msg.Headers = make(map[string]interface{}, len(d.Headers)+3)
+	for k, v := range d.Headers {
+		msg.Headers[k] = v
+	}
+	msg.Headers[lastErrorHeader] = cause.Error()
+	return msg
+}
+
+// observeDelivery records lag and redeliveries of a received delivery.
+func observeDelivery(d broker.Delivery) {
+	if !d.Timestamp.IsZero() {
+		metrics.ConsumerLag.Observe(time.Since(d.Timestamp).Seconds())
+	}
+	if d.Redelivered || retryCount(d.Headers) > 0 {
+		metrics.ConsumerRedeliveries.Inc()
+	}
+}
+
+func (c *RabbitConsumer) nackDelivery(ctx context.Context, d broker.Delivery, requeue bool) error {
+	if err := d.Nack(requeue); err != nil {
+		c.logger.ErrorContext(ctx, "failed to negatively acknowledge message", slog.String("error", err.Error()))
+		return logger.AddPrefix(ctx, fmt.Errorf("nack: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package consumer
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/notification"
+)
+
+// work is a delivery with the notification decoded from it.
+type work struct {
+	delivery broker.Delivery
+	envelope notification.Envelope
+	// err is set when the delivery cannot be decoded.
+	err error
+}
+
+// decode unmarshals the delivery once, before it is dispatched to a worker.
+func decode(d broker.Delivery) work {
+	e, err := notification.Unmarshal(d.ContentType, d.Body)
+	return work{delivery: d, envelope: e, err: err}
+}
+
+// partitionKey returns the key that defines processing order of the work.
+// Deliveries that cannot be decoded share the empty key.
+func (w work) partitionKey() string {
+	if w.err != nil {
+		return ""
+	}
+	return w.envelope.Payload.UserID.String()
+}
+
+// workerPool processes deliveries concurrently. Deliveries with the same key
+// are sent to the same worker and therefore processed in order.
+type workerPool struct {
+	queues []chan work
+	wg     sync.WaitGroup
+}
+
+// newWorkerPool starts n workers, each with a queue of the given capacity.
+func newWorkerPool(n, capacity int, process func(work)) *workerPool {
+	if n < 1 {
+		n = 1
+	}
+	if capacity < 1 {
+		capacity = 1
+	}
+	p := &workerPool{queues: make([]chan work, n)}
+	for i := range p.queues {
+		q := make(chan work, capacity)
+		p.queues[i] = q
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for w := range q {
+				process(w)
+			}
+		}()
+	}
+	return p
+}
+
+func (p *workerPool) size() int {
+	return len(p.queues)
+}
+
+// dispatch passes the work to the worker owning its key. It blocks only while
+// the queue of that worker is full and fails when ctx is done first.
+func (p *workerPool) dispatch(ctx context.Context, w work) error {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(w.partitionKey()))
+	select {
+	case p.queues[h.Sum32()%uint32(len(p.queues))] <- w: //nolint:gosec
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stop waits until all dispatched deliveries are processed.
+func (p *workerPool) stop() {
+	for _, q := range p.queues {
+		close(q)
+	}
+	p.wg.Wait()
+}
@@ -0,0 +1,147 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/notification"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// userWork returns work of the user as if it was decoded from a delivery with the tag.
+func userWork(userID uuid.UUID, tag uint64) work {
+	return work{
+		delivery: broker.Delivery{DeliveryTag: tag},
+		envelope: notification.Envelope{Payload: storage.Notification{UserID: userID}},
+	}
+}
+
+func TestWorkerPool_PreservesOrderPerKey(t *testing.T) {
+	const (
+		users    = 8
+		messages = 50
+	)
+
+	var (
+		mu     sync.Mutex
+		got    = map[uuid.UUID][]uint64{}
+		active int32
+		peak   int32
+	)
+
+	pool := newWorkerPool(4, 2, func(w work) {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&active, -1)
+
+		mu.Lock()
+		userID := w.envelope.Payload.UserID
+		got[userID] = append(got[userID], w.delivery.DeliveryTag)
+		mu.Unlock()
+	})
+
+	userIDs := make([]uuid.UUID, users)
+	for u := range userIDs {
+		userIDs[u] = uuid.New()
+	}
+	for i := 0; i < messages; i++ {
+		for _, userID := range userIDs {
+			require.NoError(t, pool.dispatch(context.Background(), userWork(userID, uint64(i))))
+		}
+	}
+	pool.stop()
+
+	require.Len(t, got, users)
+	for userID, tags := range got {
+		require.Len(t, tags, messages, userID)
+		for i, tag := range tags {
+			require.Equal(t, uint64(i), tag, "order broken for %s", userID)
+		}
+	}
+	require.Greater(t, atomic.LoadInt32(&peak), int32(1), "deliveries were not processed in parallel")
+}
+
+func TestWorkerPool_DispatchDoesNotWaitForBusyWorker(t *testing.T) {
+	release := make(chan struct{})
+	pool := newWorkerPool(1, 3, func(work) { <-release })
+	defer pool.stop()
+	defer close(release)
+
+	userID := uuid.New()
+	dispatched := make(chan struct{})
+	go func() {
+		// Первое сообщение занимает обработчик, остальные ждут в его очереди
+		for i := 0; i < 4; i++ {
+			_ = pool.dispatch(context.Background(), userWork(userID, uint64(i)))
+		}
+		close(dispatched)
+	}()
+
+	select {
+	case <-dispatched:
+	case <-time.After(time.Second):
+		t.Fatal("dispatch blocked by a busy worker")
+	}
+}
+
+func TestWorkerPool_DispatchStopsWithContext(t *testing.T) {
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	pool := newWorkerPool(1, 1, func(work) {
+		started <- struct{}{}
+		<-release
+	})
+	defer pool.stop()
+	defer close(release)
+
+	// Обработчик занят, его очередь заполнена
+	userID := uuid.New()
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, pool.dispatch(ctx, userWork(userID, 0)))
+	<-started
+	require.NoError(t, pool.dispatch(ctx, userWork(userID, 1)))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- pool.dispatch(ctx, userWork(userID, 2)) }()
+	cancel()
+
+	select {
+	case err := <-errCh:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("dispatch ignored the cancelled context")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	userID := uuid.New()
+	msg, err := notification.Marshal(
+		notification.New(storage.Notification{ID: uuid.New(), UserID: userID}, time.Now()),
+		notification.ContentTypeProtobuf,
+	)
+	require.NoError(t, err)
+
+	w := decode(broker.Delivery{Message: msg})
+	require.NoError(t, w.err)
+	require.Equal(t, userID, w.envelope.Payload.UserID)
+	require.Equal(t, userID.String(), w.partitionKey())
+
+	legacy := []byte(`{"userId":"` + userID.String() + `"}`)
+	w = decode(broker.Delivery{Message: broker.Message{ContentType: "text/plain", Body: legacy}})
+	require.Equal(t, userID.String(), w.partitionKey())
+
+	w = decode(broker.Delivery{Message: broker.Message{Body: []byte(`{`)}})
+	require.Error(t, w.err)
+	require.Equal(t, "", w.partitionKey())
+}
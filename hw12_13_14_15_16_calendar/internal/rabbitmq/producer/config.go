@@ -1,10 +1,41 @@
 package producer
 
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/rabbitmq/connection"
+)
+
 // RabbitMQConf defines RabbitMQ producer configuration.
 type RabbitMQConf struct {
-	URI          string `toml:"uri" env:"URI"`
-	Exchange     string `toml:"exchange" env:"EXCHANGE"`
-	ExchangeType string `toml:"exchange_type" env:"EXCHANGE_TYPE"`
-	RoutingKey   string `toml:"routing_key" env:"ROUTING_KEY"`
-	Reliable     bool   `toml:"reliable" env:"RELIABLE"`
+	URI            string        `toml:"uri" env:"URI" secret:"credentials"`
+	Exchange       string        `toml:"exchange" env:"EXCHANGE"`
+	ExchangeType   string        `toml:"exchange_type" env:"EXCHANGE_TYPE"`
+	RoutingKey     string        `toml:"routing_key" env:"ROUTING_KEY"`
+	Reliable       bool          `toml:"reliable" env:"RELIABLE"`
+	ConfirmTimeout time.Duration `toml:"confirm_timeout" env:"CONFIRM_TIMEOUT"`
+
+	Reconnect connection.ReconnectConf `toml:"reconnect" envPrefix:"RECONNECT_"`
+}
+
+// Validate checks the connection and the exchange settings.
+func (c RabbitMQConf) Validate() error {
+	if err := connection.ValidateURI(c.URI); err != nil {
+		return fmt.Errorf("uri: %w", err)
+	}
+	if c.Exchange == "" {
+		return errors.New("exchange: required")
+	}
+	if err := connection.ValidateExchangeType(c.ExchangeType); err != nil {
+		return fmt.Errorf("exchange_type: %w", err)
+	}
+	if c.ConfirmTimeout < 0 {
+		return fmt.Errorf("confirm_timeout: must not be negative, got %s", c.ConfirmTimeout)
+	}
+	if err := c.Reconnect.Validate(); err != nil {
+		return fmt.Errorf("reconnect.%w", err)
+	}
+	return nil
 }
@@ -2,26 +2,34 @@ package producer
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
-	"github.com/streadway/amqp"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/metrics"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/rabbitmq/connection"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// RabbitProducer publishes messages to RabbitMQ.
+// RabbitProducer publishes messages to RabbitMQ or to another broker.Channel.
 type RabbitProducer struct {
-	conn       *amqp.Connection
-	channel    *amqp.Channel
-	exchange   string
-	routingKey string
-	reliable   bool
-	confirms   chan amqp.Confirmation
-	logger     *slog.Logger
-	ctx        context.Context
-	cancel     context.CancelFunc
+	// supervisor restores the connection to RabbitMQ; it is nil over other brokers.
+	supervisor   *connection.Supervisor
+	exchange     string
+	exchangeType string
+	routingKey   string
+	reliable     bool
+	timeout      time.Duration
+	logger       *slog.Logger
+
+	// mu guards the channel replaced on reconnection.
+	mu      sync.RWMutex
+	channel broker.Channel
 }
 
 func (p *RabbitProducer) setLogCompMeth(ctx context.Context, method string) context.Context {
@@ -29,215 +37,145 @@ func (p *RabbitProducer) setLogCompMeth(ctx context.Context, method string) cont
 	return logger.WithLogMethod(ctx, method)
 }
 
-// NewRabbitProducer creates and configures a RabbitMQ producer.
-func NewRabbitProducer(ctx context.Context, cfg RabbitMQConf, logger *slog.Logger) (*RabbitProducer, error) {
-	ctx, cancel := context.WithCancel(ctx)
-	p := &RabbitProducer{
-		exchange:   cfg.Exchange,
-		routingKey: cfg.RoutingKey,
-		reliable:   cfg.Reliable,
-		logger:     logger,
-		ctx:        ctx,
-		cancel:     cancel,
-	}
+const defaultConfirmTimeout = 5 * time.Second
 
-	if err := p.connectWithRetry(ctx, cfg.URI); err != nil {
-		return nil, err
+func newProducer(cfg RabbitMQConf, logger *slog.Logger) *RabbitProducer {
+	timeout := cfg.ConfirmTimeout
+	if timeout <= 0 {
+		timeout = defaultConfirmTimeout
 	}
-
-	if err := p.initChannel(ctx); err != nil {
-		return nil, err
+	return &RabbitProducer{
+		exchange:     cfg.Exchange,
+		exchangeType: cfg.ExchangeType,
+		routingKey:   cfg.RoutingKey,
+		reliable:     cfg.Reliable,
+		timeout:      timeout,
+		logger:       logger,
 	}
+}
 
-	if err := p.setupExchange(ctx, cfg); err != nil {
+// NewRabbitProducer creates and configures a RabbitMQ producer.
+func NewRabbitProducer(ctx context.Context, cfg RabbitMQConf, logger *slog.Logger) (*RabbitProducer, error) {
+	p := newProducer(cfg, logger)
+
+	p.supervisor = connection.New(cfg.URI, cfg.Reconnect, p.setupRabbitChannel, p.onStateChange, logger)
+	if err := p.supervisor.Start(ctx); err != nil {
 		return nil, err
 	}
 
-	go p.startReconnectLoop(p.ctx, cfg)
-
 	return p, nil
 }
 
-func (p *RabbitProducer) connectWithRetry(ctx context.Context, uri string) error {
-	ctx = p.setLogCompMeth(ctx, "connectWithRetry")
-	const (
-		maxAttempts = 5
-		retryDelay  = 2 * time.Second
-	)
-
-	var err error
-	for i := 1; i <= maxAttempts; i++ {
-		p.logger.DebugContext(ctx, "try connecting to RabbitMQ", slog.String("uri", uri),
-			slog.Int("attempt", i))
-
-		p.conn, err = amqp.Dial(uri)
-		if err == nil {
-			break
-		}
-
-		p.logger.WarnContext(ctx, "failed to connect to RabbitMQ", slog.Int("attempt", i),
-			slog.String("error", err.Error()))
-
-		select {
-		case <-ctx.Done():
-			p.logger.InfoContext(ctx, "connection cancelled", "error", ctx.Err())
-			return logger.AddPrefix(ctx, fmt.Errorf("connection cancelled: %w", ctx.Err()))
-		case <-time.After(retryDelay):
-			// Pause before the next attempt
-		}
-	}
-
-	if err != nil {
-		return logger.AddPrefix(ctx, fmt.Errorf("failed to connect to RabbitMQ after %d attempts: %w",
-			maxAttempts, err))
+// NewProducer creates a producer publishing through ch, e.g. the in-memory broker.
+// The URI and the reconnection settings of cfg are not used.
+func NewProducer(
+	ctx context.Context,
+	ch broker.Channel,
+	cfg RabbitMQConf,
+	logger *slog.Logger,
+) (*RabbitProducer, error) {
+	p := newProducer(cfg, logger)
+	if err := p.setupChannel(ctx, ch); err != nil {
+		return nil, err
 	}
-
-	p.logger.InfoContext(ctx, "connection established")
-
-	// отслеживаем закрытие подключения брокером
-	go func() {
-		p.logger.WarnContext(ctx, "connection closed", "error", <-p.conn.NotifyClose(make(chan *amqp.Error)))
-	}()
-
-	return nil
+	return p, nil
 }
 
-func (p *RabbitProducer) initChannel(ctx context.Context) error {
-	ctx = p.setLogCompMeth(ctx, "initChannel")
-	p.logger.DebugContext(ctx, "RabbitProducer.initChannel: trying to initialize channel")
-
-	var err error
-	p.channel, err = p.conn.Channel()
-	if err != nil {
-		return logger.AddPrefix(ctx, fmt.Errorf("channel: %w", err))
-	}
-
-	p.logger.InfoContext(ctx, "channel initialized")
-
-	// Включаем publisher confirms
+// setupRabbitChannel enables publisher confirms on every new RabbitMQ channel.
+func (p *RabbitProducer) setupRabbitChannel(ctx context.Context, ch *connection.Channel) error {
 	if p.reliable {
+		ctx := p.setLogCompMeth(ctx, "setupRabbitChannel")
 		p.logger.InfoContext(ctx, "enabling publishing confirms")
 
-		if err := p.channel.Confirm(false); err != nil {
+		if err := ch.EnableConfirms(p.timeout); err != nil {
 			return logger.AddPrefix(ctx, fmt.Errorf("could not enable confirms: %w", err))
 		}
-
-		p.confirms = p.channel.NotifyPublish(make(chan amqp.Confirmation, 1))
 	}
-
-	return nil
+	return p.setupChannel(ctx, ch)
 }
 
-func (p *RabbitProducer) setupExchange(ctx context.Context, cfg RabbitMQConf) error {
-	ctx = p.setLogCompMeth(ctx, "setupExchange")
-
-	p.logger.DebugContext(ctx, "RabbitProducer.setupExchange: trying to declare exchange",
-		"type", cfg.ExchangeType, "name", cfg.Exchange)
-
-	if err := p.channel.ExchangeDeclare(
-		cfg.Exchange,     // name
-		cfg.ExchangeType, // type
-		true,             // durable
-		false,            // auto-deleted
-		false,            // internal
-		false,            // noWait
-		nil,              // arguments
-	); err != nil {
-		p.channel.Close()
+// setupChannel declares the exchange and makes ch the channel to publish to.
+func (p *RabbitProducer) setupChannel(ctx context.Context, ch broker.Channel) error {
+	ctx = p.setLogCompMeth(ctx, "setupChannel")
+
+	p.logger.DebugContext(ctx, "trying to declare exchange", "type", p.exchangeType, "name", p.exchange)
+
+	if err := ch.DeclareExchange(p.exchange, p.exchangeType); err != nil {
 		return logger.AddPrefix(ctx, fmt.Errorf("exchange declare: %w", err))
 	}
 
-	p.logger.InfoContext(ctx, "RabbitProducer.setupExchange: exchange declared")
+	p.logger.InfoContext(ctx, "exchange declared")
+
+	p.mu.Lock()
+	p.channel = ch
+	p.mu.Unlock()
 
 	return nil
 }
 
-// Publish sends a message to RabbitMQ.
-func (p *RabbitProducer) Publish(ctx context.Context, body string) error {
-	ctx = p.setLogCompMeth(ctx, "Publish")
-	p.logger.DebugContext(ctx, "RabbitProducer.Publish: publishing message", "body", body)
-
-	if err := p.channel.Publish(
-		p.exchange,
-		p.routingKey,
-		false,
-		false,
-		amqp.Publishing{
-			Headers:         amqp.Table{},
-			ContentType:     "text/plain",
-			ContentEncoding: "",
-			Body:            []byte(body),
-			DeliveryMode:    amqp.Transient,
-			Priority:        0,
-		},
-	); err != nil {
-		return logger.AddPrefix(ctx, fmt.Errorf("failed to publish message: %w", err))
+// Check reports whether the connection to RabbitMQ is usable.
+func (p *RabbitProducer) Check(ctx context.Context) error {
+	if p.supervisor == nil {
+		return nil
 	}
+	return p.supervisor.Check(ctx)
+}
 
-	p.logger.InfoContext(ctx, "RabbitProducer.Publish: message published", "body", body)
+func (p *RabbitProducer) onStateChange(state connection.State, err error) {
+	ctx := p.setLogCompMeth(context.Background(), "onStateChange")
+	p.logger.DebugContext(ctx, "connection state changed", "state", state.String(), "error", err)
+	metrics.ObserveConnection("producer", state == connection.StateConnected)
+}
 
-	// Ожидание подтверждения (если reliable)
-	if p.reliable {
-		select {
-		case confirm := <-p.confirms:
-			if confirm.Ack {
-				p.logger.InfoContext(ctx, "RabbitProducer.Publish: message delivery confirmed",
-					slog.Uint64("deliveryTag", confirm.DeliveryTag))
-			} else {
-				p.logger.ErrorContext(ctx, "RabbitProducer.Publish: message delivery NOT confirmed",
-					slog.Uint64("deliveryTag", confirm.DeliveryTag))
-				return logger.AddPrefix(ctx, fmt.Errorf("message not acknowledged by broker"))
-			}
-		case <-time.After(5 * time.Second):
-			return logger.AddPrefix(ctx, fmt.Errorf("timeout waiting for confirmation"))
-		}
+// Publish sends a message without waiting for the broker.
+// The returned confirmation resolves once the broker acknowledges the message;
+// in unreliable mode it is resolved immediately.
+func (p *RabbitProducer) Publish(ctx context.Context, msg broker.Message) (*broker.Confirmation, error) {
+	ctx = p.setLogCompMeth(ctx, "Publish")
+
+	ctx, span := tracing.Tracer().Start(ctx, p.exchange+" publish", trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.destination.name", p.exchange),
+			attribute.String("messaging.rabbitmq.destination.routing_key", p.routingKey),
+			attribute.String("messaging.message.id", msg.ID),
+		))
+	defer span.End()
+
+	// Контекст трассировки передаётся потребителю в заголовках сообщения
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]interface{})
 	}
+	tracing.Inject(ctx, msg.Headers)
 
-	return nil
-}
+	p.logger.DebugContext(ctx, "RabbitProducer.Publish: publishing message",
+		"messageId", msg.ID, "contentType", msg.ContentType)
 
-// Shutdown closes producer resources.
-func (p *RabbitProducer) Shutdown() error {
-	var errs []error
+	p.mu.RLock()
+	ch := p.channel
+	p.mu.RUnlock()
 
-	if err := p.channel.Close(); err != nil {
-		errs = append(errs, fmt.Errorf("RabbitProducer.Shutdown: channel close failed: %w", err))
+	confirm, err := ch.Publish(ctx, p.exchange, p.routingKey, msg)
+	if err != nil {
+		return nil, logger.AddPrefix(ctx, fmt.Errorf("failed to publish message: %w", err))
 	}
 
-	if err := p.conn.Close(); err != nil {
-		errs = append(errs, fmt.Errorf("RabbitProducer.Shutdown: AMQP connection close error: %w", err))
+	if p.reliable {
+		p.logger.InfoContext(ctx, "RabbitProducer.Publish: message published, awaiting confirmation",
+			"messageId", msg.ID)
+	} else {
+		p.logger.InfoContext(ctx, "RabbitProducer.Publish: message published", "messageId", msg.ID)
 	}
-
-	p.cancel()
-
-	return errors.Join(errs...)
+	return confirm, nil
 }
 
-func (p *RabbitProducer) startReconnectLoop(ctx context.Context, cfg RabbitMQConf) {
-	notify := p.conn.NotifyClose(make(chan *amqp.Error))
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case errNotify := <-notify:
-			if errNotify != nil {
-				p.logger.WarnContext(ctx, "connection closed", slog.String("error", errNotify.Error()))
-			}
-
-			for ctx.Err() == nil {
-				if err := p.connectWithRetry(ctx, cfg.URI); err != nil {
-					continue
-				}
-				if err := p.initChannel(ctx); err != nil {
-					continue
-				}
-				if err := p.setupExchange(ctx, cfg); err != nil {
-					continue
-				}
-
-				notify = p.conn.NotifyClose(make(chan *amqp.Error))
-				break
-			}
-		}
+// Shutdown closes producer resources. The broker passed to NewProducer stays open.
+func (p *RabbitProducer) Shutdown() error {
+	if p.supervisor == nil {
+		return nil
+	}
+	if err := p.supervisor.Close(); err != nil {
+		return fmt.Errorf("RabbitProducer.Shutdown: %w", err)
 	}
+	return nil
 }
@@ -0,0 +1,180 @@
+// Package ratelimit ограничивает частоту запросов одного клиента к маршрутам
+// HTTP и методам gRPC по алгоритму token bucket.
+package ratelimit
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// defaultIdleTimeout is used when Config.IdleTimeout is not set.
+const defaultIdleTimeout = 10 * time.Minute
+
+// Rule limits requests of one client: Rate tokens per second with bursts up to Burst.
+// A non-positive Rate disables the limit.
+type Rule struct {
+	Rate  float64 `toml:"rate" env:"RATE"`
+	Burst int     `toml:"burst" env:"BURST"`
+}
+
+// Config defines rate limits. Routes are keyed by HTTP patterns such as
+// "POST /event" or gRPC methods such as "/calendar.Calendar/GetEventsMonth";
+// other routes use Rate and Burst.
+type Config struct {
+	Enabled bool    `toml:"enabled" env:"ENABLED"`
+	Rate    float64 `toml:"rate" env:"RATE"`
+	Burst   int     `toml:"burst" env:"BURST"`
+	// IdleTimeout is how long the state of an inactive client is kept.
+	IdleTimeout time.Duration   `toml:"idle_timeout" env:"IDLE_TIMEOUT"`
+	Routes      map[string]Rule `toml:"routes"`
+}
+
+func (r Rule) validate() error {
+	if r.Rate < 0 {
+		return fmt.Errorf("rate: must not be negative, got %v", r.Rate)
+	}
+	if r.Burst < 0 {
+		return fmt.Errorf("burst: must not be negative, got %d", r.Burst)
+	}
+	return nil
+}
+
+// Validate checks that rates, bursts and the idle timeout are not negative.
+func (c Config) Validate() error {
+	if err := (Rule{Rate: c.Rate, Burst: c.Burst}).validate(); err != nil {
+		return err
+	}
+	if c.IdleTimeout < 0 {
+		return fmt.Errorf("idle_timeout: must not be negative, got %s", c.IdleTimeout)
+	}
+	for _, route := range slices.Sorted(maps.Keys(c.Routes)) {
+		if err := c.Routes[route].validate(); err != nil {
+			return fmt.Errorf("routes.%q.%w", route, err)
+		}
+	}
+	return nil
+}
+
+func (c Config) withDefaults() Config {
+	if c.IdleTimeout <= 0 {
+		c.IdleTimeout = defaultIdleTimeout
+	}
+	return c
+}
+
+func (c Config) rule(route string) Rule {
+	if !c.Enabled {
+		return Rule{}
+	}
+	if r, ok := c.Routes[route]; ok {
+		return r
+	}
+	return Rule{Rate: c.Rate, Burst: c.Burst}
+}
+
+type key struct {
+	route  string
+	client string
+}
+
+type bucket struct {
+	limiter *rate.Limiter
+	seen    time.Time
+}
+
+// Limiter keeps a token bucket per route and client.
+type Limiter struct {
+	mu        sync.Mutex
+	cfg       Config
+	buckets   map[key]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// New creates a limiter. A disabled limiter allows everything until Update enables it.
+func New(cfg Config) *Limiter {
+	return &Limiter{
+		cfg:       cfg.withDefaults(),
+		buckets:   make(map[key]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow takes a token for the request of client to route. If the limit is
+// exceeded, it returns false and the time after which the request may be retried.
+// A nil Limiter allows everything.
+func (l *Limiter) Allow(route, client string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rule := l.cfg.rule(route)
+	if rule.Rate <= 0 {
+		return true, 0
+	}
+
+	now := l.now()
+	l.sweep(now)
+
+	k := key{route: route, client: client}
+	b, ok := l.buckets[k]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(rule.Rate), max(rule.Burst, 1))}
+		l.buckets[k] = b
+	}
+	b.seen = now
+
+	r := b.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// Update replaces the limits. Buckets of clients keep their tokens and get the
+// new rate and burst; buckets of routes that became unlimited are dropped.
+func (l *Limiter) Update(cfg Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cfg = cfg.withDefaults()
+	now := l.now()
+	for k, b := range l.buckets {
+		rule := l.cfg.rule(k.route)
+		if rule.Rate <= 0 {
+			delete(l.buckets, k)
+			continue
+		}
+		b.limiter.SetLimitAt(now, rate.Limit(rule.Rate))
+		b.limiter.SetBurstAt(now, max(rule.Burst, 1))
+	}
+}
+
+// sweep drops buckets of clients inactive for longer than IdleTimeout.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.cfg.IdleTimeout {
+		return
+	}
+	for k, b := range l.buckets {
+		if now.Sub(b.seen) >= l.cfg.IdleTimeout {
+			delete(l.buckets, k)
+		}
+	}
+	l.lastSweep = now
+}
+
+// RetryAfter formats delay as the value of the Retry-After header in whole seconds.
+func RetryAfter(delay time.Duration) int {
+	return max(int(math.Ceil(delay.Seconds())), 1)
+}
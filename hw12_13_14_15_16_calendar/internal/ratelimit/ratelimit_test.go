@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(cfg Config) (*Limiter, *time.Time) {
+	cfg.Enabled = true
+	l := New(cfg)
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	l.lastSweep = now
+	return l, &now
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	l, now := newTestLimiter(Config{
+		Rate:   1,
+		Burst:  2,
+		Routes: map[string]Rule{"POST /event": {Rate: 0.5, Burst: 1}},
+	})
+
+	// Бакет по умолчанию: всплеск из двух запросов, затем один в секунду
+	for range 2 {
+		ok, _ := l.Allow("GET /event/day", "10.0.0.1")
+		require.True(t, ok)
+	}
+	ok, delay := l.Allow("GET /event/day", "10.0.0.1")
+	require.False(t, ok)
+	require.Equal(t, time.Second, delay)
+
+	// Другой клиент и другой маршрут считаются отдельно
+	ok, _ = l.Allow("GET /event/day", "10.0.0.2")
+	require.True(t, ok)
+	ok, _ = l.Allow("POST /event", "10.0.0.1")
+	require.True(t, ok)
+	ok, delay = l.Allow("POST /event", "10.0.0.1")
+	require.False(t, ok)
+	require.Equal(t, 2*time.Second, delay)
+
+	*now = now.Add(time.Second)
+	ok, _ = l.Allow("GET /event/day", "10.0.0.1")
+	require.True(t, ok)
+}
+
+func TestLimiter_Unlimited(t *testing.T) {
+	var disabled *Limiter
+	ok, _ := disabled.Allow("POST /event", "10.0.0.1")
+	require.True(t, ok)
+	off := New(Config{Rate: 1, Burst: 1})
+	for range 10 {
+		ok, _ := off.Allow("POST /event", "10.0.0.1")
+		require.True(t, ok)
+	}
+
+	l, _ := newTestLimiter(Config{Rate: 1, Burst: 1, Routes: map[string]Rule{"GET /metrics": {}}})
+	for range 10 {
+		ok, _ := l.Allow("GET /metrics", "10.0.0.1")
+		require.True(t, ok)
+	}
+}
+
+func TestLimiter_Sweep(t *testing.T) {
+	l, now := newTestLimiter(Config{Rate: 1, Burst: 1, IdleTimeout: time.Minute})
+
+	l.Allow("GET /event/day", "10.0.0.1")
+	*now = now.Add(30 * time.Second)
+	l.Allow("GET /event/day", "10.0.0.2")
+	require.Len(t, l.buckets, 2)
+
+	*now = now.Add(45 * time.Second)
+	l.Allow("GET /event/day", "10.0.0.2")
+	require.Len(t, l.buckets, 1)
+}
+
+func TestLimiter_Update(t *testing.T) {
+	l, now := newTestLimiter(Config{Rate: 1, Burst: 1})
+
+	ok, _ := l.Allow("GET /event/day", "10.0.0.1")
+	require.True(t, ok)
+	ok, _ = l.Allow("GET /event/day", "10.0.0.1")
+	require.False(t, ok)
+
+	// Новый лимит применяется к уже существующим бакетам
+	l.Update(Config{Enabled: true, Rate: 10, Burst: 5})
+	*now = now.Add(time.Second)
+	for range 5 {
+		ok, _ = l.Allow("GET /event/day", "10.0.0.1")
+		require.True(t, ok)
+	}
+
+	l.Update(Config{Enabled: false, Rate: 1, Burst: 1})
+	require.Empty(t, l.buckets)
+	for range 10 {
+		ok, _ = l.Allow("GET /event/day", "10.0.0.1")
+		require.True(t, ok)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	require.NoError(t, Config{Rate: 1, Burst: 2, Routes: map[string]Rule{"GET /metrics": {}}}.Validate())
+	require.EqualError(t, Config{Rate: -1}.Validate(), "rate: must not be negative, got -1")
+	require.EqualError(t, Config{IdleTimeout: -time.Second}.Validate(), "idle_timeout: must not be negative, got -1s")
+	require.EqualError(t, Config{Routes: map[string]Rule{"POST /event": {Burst: -5}}}.Validate(),
+		`routes."POST /event".burst: must not be negative, got -5`)
+}
+
+func TestRetryAfter(t *testing.T) {
+	require.Equal(t, 1, RetryAfter(10*time.Millisecond))
+	require.Equal(t, 2, RetryAfter(1500*time.Millisecond))
+}
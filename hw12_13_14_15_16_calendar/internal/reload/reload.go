@@ -0,0 +1,51 @@
+// Package reload перечитывает конфигурацию сервиса по сигналу SIGHUP.
+package reload
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/metrics"
+)
+
+// Func re-reads the configuration and applies it. It must validate the new
+// configuration before changing anything, so that an error leaves the previous
+// configuration in effect.
+type Func func(ctx context.Context) error
+
+// Watch calls apply on every SIGHUP until ctx is done.
+func Watch(ctx context.Context, lg *slog.Logger, apply Func) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				Run(ctx, lg, apply)
+			}
+		}
+	}()
+}
+
+// Run applies the configuration once and logs the outcome.
+func Run(ctx context.Context, lg *slog.Logger, apply Func) {
+	ctx = logger.WithLogComponent(ctx, "reload")
+	ctx = logger.WithLogMethod(ctx, "Run")
+
+	lg.InfoContext(ctx, "reloading configuration")
+	if err := apply(ctx); err != nil {
+		metrics.ConfigReloads.WithLabelValues("rejected").Inc()
+		lg.ErrorContext(ctx, "configuration reload rejected, keeping previous configuration", "error", err)
+		return
+	}
+	metrics.ConfigReloads.WithLabelValues("applied").Inc()
+	lg.InfoContext(ctx, "configuration reloaded")
+}
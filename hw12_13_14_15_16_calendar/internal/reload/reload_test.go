@@ -0,0 +1,42 @@
+package reload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := make(chan struct{}, 1)
+	Watch(ctx, logger.New("debug", os.Stdout, false), func(context.Context) error {
+		calls <- struct{}{}
+		return nil
+	})
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	select {
+	case <-calls:
+	case <-time.After(time.Second):
+		t.Fatal("configuration was not reloaded on SIGHUP")
+	}
+}
+
+func TestRun_Rejected(t *testing.T) {
+	var buf bytes.Buffer
+	Run(context.Background(), logger.New("debug", &buf, true), func(context.Context) error {
+		return errors.New("logger.level: unknown level")
+	})
+
+	require.Contains(t, buf.String(), `"msg":"configuration reload rejected, keeping previous configuration"`)
+	require.Contains(t, buf.String(), `"error":"logger.level: unknown level"`)
+}
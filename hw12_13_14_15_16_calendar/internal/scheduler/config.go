@@ -1,9 +1,40 @@
 package scheduler
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/notification"
+)
 
 // NotificationsConf stores scheduler timing configuration.
 type NotificationsConf struct {
 	Tick     time.Duration `toml:"tick"`
 	EventTTL time.Duration `toml:"event_ttl"`
+	// Encoding of published notifications: "json" (default) or "protobuf".
+	Encoding string `toml:"encoding"`
+	// MaxTickAge is how long the scheduler may go without a successful tick
+	// before it is reported unhealthy; three ticks by default.
+	MaxTickAge time.Duration `toml:"max_tick_age"`
+}
+
+// Validate checks that the tick is positive, durations are not negative and the encoding is known.
+func (c NotificationsConf) Validate() error {
+	if c.Tick <= 0 {
+		return fmt.Errorf("tick: must be positive, got %s", c.Tick)
+	}
+	if c.EventTTL < 0 {
+		return fmt.Errorf("event_ttl: must not be negative, got %s", c.EventTTL)
+	}
+	if c.MaxTickAge < 0 {
+		return fmt.Errorf("max_tick_age: must not be negative, got %s", c.MaxTickAge)
+	}
+	if c.MaxTickAge > 0 && c.MaxTickAge < c.Tick {
+		return errors.New("max_tick_age: must not be shorter than tick")
+	}
+	if _, err := notification.ContentTypeFor(c.Encoding); err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+	return nil
 }
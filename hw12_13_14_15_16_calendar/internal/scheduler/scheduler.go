@@ -2,12 +2,18 @@ package scheduler
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"log/slog"
+	"sync/atomic"
 	"time"
 
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/metrics"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/notification"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Storage provides access to event data needed by the scheduler.
@@ -18,17 +24,44 @@ type Storage interface {
 
 // Publisher sends notifications about upcoming events.
 type Publisher interface {
-	Publish(ctx context.Context, body string) error
+	Publish(ctx context.Context, msg broker.Message) (*broker.Confirmation, error)
 	Shutdown() error
 }
 
 // Scheduler periodically publishes event notifications.
 type Scheduler struct {
-	storage   Storage
-	publisher Publisher
-	tick      time.Duration
-	EventTTL  time.Duration
-	logger    *slog.Logger
+	storage     Storage
+	publisher   Publisher
+	timing      atomic.Pointer[timing]
+	reloaded    chan struct{}
+	contentType string
+	// lastTick is the Unix time in nanoseconds of the last tick that read notifications
+	// and published all of them.
+	lastTick atomic.Int64
+	// retry holds notifications whose publishing was not confirmed;
+	// they are published again on the next tick.
+	retry  []notification.Envelope
+	logger *slog.Logger
+}
+
+// timing holds the settings that can be changed while the scheduler runs.
+type timing struct {
+	tick       time.Duration
+	eventTTL   time.Duration
+	maxTickAge time.Duration
+}
+
+func newTiming(cfg NotificationsConf) *timing {
+	maxTickAge := cfg.MaxTickAge
+	if maxTickAge <= 0 {
+		maxTickAge = 3 * cfg.Tick
+	}
+	return &timing{tick: cfg.Tick, eventTTL: cfg.EventTTL, maxTickAge: maxTickAge}
+}
+
+type pendingPublish struct {
+	envelope notification.Envelope
+	confirm  *broker.Confirmation
 }
 
 func (s *Scheduler) setLogCompMeth(ctx context.Context, method string) context.Context {
@@ -38,12 +71,40 @@ func (s *Scheduler) setLogCompMeth(ctx context.Context, method string) context.C
 
 // NewScheduler creates a new Scheduler instance.
 func NewScheduler(logger *slog.Logger, storage Storage, publisher Publisher, cfg NotificationsConf) *Scheduler {
-	return &Scheduler{
-		storage:   storage,
-		publisher: publisher,
-		tick:      cfg.Tick,
-		EventTTL:  cfg.EventTTL,
-		logger:    logger,
+	contentType, err := notification.ContentTypeFor(cfg.Encoding)
+	if err != nil {
+		logger.Warn("unknown notification encoding, using json", "encoding", cfg.Encoding)
+		contentType = notification.ContentTypeJSON
+	}
+	s := &Scheduler{
+		storage:     storage,
+		publisher:   publisher,
+		reloaded:    make(chan struct{}, 1),
+		contentType: contentType,
+		logger:      logger,
+	}
+	s.timing.Store(newTiming(cfg))
+	// До первого тика отсчёт идёт от создания планировщика
+	s.lastTick.Store(time.Now().UnixNano())
+	return s
+}
+
+// Check reports an error when no tick has succeeded for longer than MaxTickAge.
+func (s *Scheduler) Check(context.Context) error {
+	age := time.Since(time.Unix(0, s.lastTick.Load()))
+	if age > s.timing.Load().maxTickAge {
+		return fmt.Errorf("last successful tick was %s ago", age.Round(time.Second))
+	}
+	return nil
+}
+
+// Reconfigure applies new tick, event TTL and maximum tick age; the encoding is
+// not changed. The next tick happens one new interval after the call.
+func (s *Scheduler) Reconfigure(cfg NotificationsConf) {
+	s.timing.Store(newTiming(cfg))
+	select {
+	case s.reloaded <- struct{}{}:
+	default:
 	}
 }
 
@@ -53,7 +114,7 @@ func (s *Scheduler) Start(ctx context.Context) {
 
 	s.logger.InfoContext(ctx, "start scheduler")
 
-	ticker := time.NewTicker(s.tick)
+	ticker := time.NewTicker(s.timing.Load().tick)
 	defer ticker.Stop()
 
 	s.PublishNotifications(ctx)
@@ -68,6 +129,10 @@ func (s *Scheduler) Start(ctx context.Context) {
 			return
 		case <-ticker.C:
 			s.PublishNotifications(ctx)
+		case <-s.reloaded:
+			tick := s.timing.Load().tick
+			ticker.Reset(tick)
+			s.logger.InfoContext(ctx, "scheduler reconfigured", "tick", tick)
 		}
 	}
 }
@@ -76,40 +141,105 @@ func (s *Scheduler) Start(ctx context.Context) {
 func (s *Scheduler) PublishNotifications(ctx context.Context) {
 	ctx = s.setLogCompMeth(ctx, "PublishNotifications")
 
+	ctx, span := tracing.Tracer().Start(ctx, "scheduler.tick")
+	defer span.End()
+
 	currTime := time.Now()
 	ctx = logger.WithLogStart(ctx, currTime)
+	tm := s.timing.Load()
 
 	s.logger.DebugContext(ctx, "trying to publish notifications")
 	s.logger.DebugContext(ctx, "trying to get notifications")
 
-	notifications, err := s.storage.GetNotifications(ctx, currTime, s.tick)
+	notifications, err := s.storage.GetNotifications(ctx, currTime, tm.tick)
 	if err != nil {
 		s.logger.ErrorContext(ctx, "Scheduler.PublishNotifications: failed to get notifications", "error", err)
 		return
 	}
 	s.logger.InfoContext(ctx, "successfully got notifications", "count", len(notifications))
+
+	envelopes := s.takeRetries(ctx, currTime)
 	for _, n := range notifications {
-		s.logger.DebugContext(ctx, "trying to serialize notification", "id", n.ID)
-		jsonData, err := json.Marshal(n)
+		envelopes = append(envelopes, notification.New(n, currTime))
+	}
+
+	published, failed := 0, 0
+	defer func() {
+		metrics.NotificationsPublished.Add(float64(published))
+		metrics.NotificationsFailed.Add(float64(failed))
+		metrics.NotificationsPerTick.WithLabelValues("published").Observe(float64(published))
+		metrics.NotificationsPerTick.WithLabelValues("failed").Observe(float64(failed))
+		metrics.LastTick.SetToCurrentTime()
+		// Пока брокер недоступен, тики не считаются успешными и проба живости это видит
+		if failed == 0 {
+			s.lastTick.Store(time.Now().UnixNano())
+		}
+		span.SetAttributes(
+			attribute.Int("notifications.published", published),
+			attribute.Int("notifications.failed", failed),
+		)
+	}()
+
+	// Публикуем всё сразу, а подтверждения ждём после, чтобы не блокироваться на каждом сообщении
+	pending := make([]pendingPublish, 0, len(envelopes))
+	for _, e := range envelopes {
+		s.logger.DebugContext(ctx, "trying to serialize notification", "id", e.Payload.ID)
+		msg, err := notification.Marshal(e, s.contentType)
 		if err != nil {
 			s.logger.ErrorContext(ctx, "Scheduler.PublishNotifications: failed to serialize notification", "error", err)
+			failed++
 			continue
 		}
-		s.logger.DebugContext(ctx, "successfully serialized notification", "id", n.ID)
-		s.logger.DebugContext(ctx, "trying to publish notification", "id", n.ID)
-		if err := s.publisher.Publish(ctx, string(jsonData)); err != nil {
+		s.logger.DebugContext(ctx, "successfully serialized notification", "id", e.Payload.ID)
+		s.logger.DebugContext(ctx, "trying to publish notification", "id", e.Payload.ID, "messageId", msg.ID)
+		confirm, err := s.publisher.Publish(ctx, msg)
+		if err != nil {
 			s.logger.ErrorContext(ctx, "Scheduler.PublishNotifications: failed to publish notification", "error", err)
+			s.retry = append(s.retry, e)
+			failed++
+			continue
+		}
+		pending = append(pending, pendingPublish{envelope: e, confirm: confirm})
+	}
+
+	for _, p := range pending {
+		if err := p.confirm.Wait(ctx); err != nil {
+			s.logger.ErrorContext(ctx, "Scheduler.PublishNotifications: notification not confirmed, will retry",
+				"id", p.envelope.Payload.ID, "error", err)
+			s.retry = append(s.retry, p.envelope)
+			failed++
 			continue
 		}
-		s.logger.InfoContext(ctx, "Scheduler.PublishNotifications: notification published", "id", n.ID, "title", n.Title)
+		published++
+		s.logger.InfoContext(ctx, "Scheduler.PublishNotifications: notification published",
+			"id", p.envelope.Payload.ID, "title", p.envelope.Payload.Title)
 	}
 
 	s.logger.InfoContext(ctx, "Scheduler.PublishNotifications: events successfully published")
 
 	s.logger.DebugContext(ctx, "trying to delete old events")
-	err = s.storage.DeleteOldEvents(ctx, currTime.Add(-s.EventTTL))
+	err = s.storage.DeleteOldEvents(ctx, currTime.Add(-tm.eventTTL))
 	if err != nil {
 		s.logger.ErrorContext(ctx, "Scheduler.PublishNotifications: failed to delete old events", "error", err)
 	}
 	s.logger.InfoContext(ctx, "Scheduler.PublishNotifications: old events deleted")
 }
+
+// takeRetries returns notifications left from previous ticks, dropping expired ones.
+func (s *Scheduler) takeRetries(ctx context.Context, now time.Time) []notification.Envelope {
+	retry := s.retry
+	s.retry = nil
+
+	res := make([]notification.Envelope, 0, len(retry))
+	for _, e := range retry {
+		if !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt) {
+			s.logger.WarnContext(ctx, "notification expired before publishing", "id", e.Payload.ID)
+			continue
+		}
+		res = append(res, e)
+	}
+	if len(res) > 0 {
+		s.logger.InfoContext(ctx, "retrying unconfirmed notifications", "count", len(res))
+	}
+	return res
+}
@@ -0,0 +1,158 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/notification"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type storageMock struct {
+	notifications []storage.Notification
+}
+
+func (m *storageMock) GetNotifications(context.Context, time.Time, time.Duration) ([]storage.Notification, error) {
+	res := m.notifications
+	m.notifications = nil
+	return res, nil
+}
+
+func (m *storageMock) DeleteOldEvents(context.Context, time.Time) error {
+	return nil
+}
+
+// publisherMock resolves confirmations with errors taken from results in order.
+// While err is set, Publish fails.
+type publisherMock struct {
+	err     error
+	results []error
+	sent    []broker.Message
+}
+
+func (m *publisherMock) Publish(_ context.Context, msg broker.Message) (*broker.Confirmation, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.sent = append(m.sent, msg)
+	c := broker.NewConfirmation(0)
+	var err error
+	if len(m.results) > 0 {
+		err, m.results = m.results[0], m.results[1:]
+	}
+	c.Resolve(err)
+	return c, nil
+}
+
+func (m *publisherMock) Shutdown() error {
+	return nil
+}
+
+func TestPublishNotifications_RetriesUnconfirmed(t *testing.T) {
+	ctx := context.Background()
+	first := storage.Notification{ID: uuid.New(), Title: "first", Start: time.Now().Add(time.Hour), UserID: uuid.New()}
+	second := storage.Notification{ID: uuid.New(), Title: "second", Start: time.Now().Add(time.Hour), UserID: uuid.New()}
+
+	st := &storageMock{notifications: []storage.Notification{first, second}}
+	pub := &publisherMock{results: []error{nil, broker.ErrNotConfirmed}}
+	s := NewScheduler(logger.New("debug", os.Stdout, false), st, pub, NotificationsConf{Tick: time.Minute})
+
+	s.PublishNotifications(ctx)
+	require.Len(t, pub.sent, 2)
+	require.Len(t, s.retry, 1)
+	require.Equal(t, second.ID, s.retry[0].Payload.ID)
+
+	s.PublishNotifications(ctx)
+	require.Len(t, pub.sent, 3)
+	require.Empty(t, s.retry)
+
+	// Повторная публикация сохраняет идентификатор сообщения
+	require.Equal(t, pub.sent[1].ID, pub.sent[2].ID)
+	e, err := notification.Unmarshal(pub.sent[2].ContentType, pub.sent[2].Body)
+	require.NoError(t, err)
+	require.Equal(t, second.ID, e.Payload.ID)
+}
+
+func TestPublishNotifications_DropsExpiredRetries(t *testing.T) {
+	ctx := context.Background()
+	expired := storage.Notification{ID: uuid.New(), Title: "expired", Start: time.Now().Add(-time.Minute)}
+
+	pub := &publisherMock{}
+	s := NewScheduler(logger.New("debug", os.Stdout, false), &storageMock{}, pub, NotificationsConf{Tick: time.Minute})
+	s.retry = []notification.Envelope{notification.New(expired, time.Now().Add(-time.Hour))}
+
+	s.PublishNotifications(ctx)
+	require.Empty(t, pub.sent)
+	require.Empty(t, s.retry)
+}
+
+func TestScheduler_Check(t *testing.T) {
+	s := NewScheduler(logger.New("debug", os.Stdout, false), &storageMock{}, &publisherMock{},
+		NotificationsConf{Tick: time.Minute})
+	require.NoError(t, s.Check(context.Background()))
+
+	s.lastTick.Store(time.Now().Add(-4 * time.Minute).UnixNano())
+	require.ErrorContains(t, s.Check(context.Background()), "last successful tick was 4m0s ago")
+
+	s.PublishNotifications(context.Background())
+	require.NoError(t, s.Check(context.Background()))
+}
+
+func TestScheduler_CheckFailingPublisher(t *testing.T) {
+	ctx := context.Background()
+	n := storage.Notification{ID: uuid.New(), Title: "meeting", Start: time.Now().Add(time.Hour), UserID: uuid.New()}
+	st := &storageMock{notifications: []storage.Notification{n}}
+	pub := &publisherMock{err: errors.New("connection refused")}
+	s := NewScheduler(logger.New("debug", os.Stdout, false), st, pub, NotificationsConf{Tick: time.Minute})
+	s.lastTick.Store(time.Now().Add(-4 * time.Minute).UnixNano())
+
+	// Тик с неудачной публикацией не продлевает живость
+	s.PublishNotifications(ctx)
+	require.Len(t, s.retry, 1)
+	require.Error(t, s.Check(ctx))
+
+	// Неподтверждённая публикация тоже
+	pub.err = nil
+	pub.results = []error{broker.ErrNotConfirmed}
+	s.PublishNotifications(ctx)
+	require.Len(t, s.retry, 1)
+	require.Error(t, s.Check(ctx))
+
+	s.PublishNotifications(ctx)
+	require.Empty(t, s.retry)
+	require.NoError(t, s.Check(ctx))
+}
+
+func TestScheduler_Reconfigure(t *testing.T) {
+	s := NewScheduler(logger.New("debug", os.Stdout, false), &storageMock{}, &publisherMock{},
+		NotificationsConf{Tick: time.Minute, EventTTL: time.Hour})
+	s.lastTick.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	require.NoError(t, s.Check(context.Background()))
+
+	s.Reconfigure(NotificationsConf{Tick: 10 * time.Second, EventTTL: time.Minute})
+	require.Equal(t, timing{tick: 10 * time.Second, eventTTL: time.Minute, maxTickAge: 30 * time.Second},
+		*s.timing.Load())
+	require.Error(t, s.Check(context.Background()))
+
+	// Повторная перенастройка до того, как цикл её заметил, не блокируется
+	s.Reconfigure(NotificationsConf{Tick: 20 * time.Second})
+	require.Len(t, s.reloaded, 1)
+}
+
+func TestNotificationsConf_Validate(t *testing.T) {
+	require.NoError(t, NotificationsConf{Tick: time.Minute, Encoding: "protobuf"}.Validate())
+	require.EqualError(t, NotificationsConf{}.Validate(), "tick: must be positive, got 0s")
+	require.EqualError(t, NotificationsConf{Tick: time.Minute, EventTTL: -time.Minute}.Validate(),
+		"event_ttl: must not be negative, got -1m0s")
+	require.EqualError(t, NotificationsConf{Tick: time.Minute, MaxTickAge: time.Second}.Validate(),
+		"max_tick_age: must not be shorter than tick")
+	require.ErrorIs(t, NotificationsConf{Tick: time.Minute, Encoding: "xml"}.Validate(),
+		notification.ErrUnsupportedContentType)
+}
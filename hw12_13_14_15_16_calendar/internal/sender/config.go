@@ -0,0 +1,85 @@
+package sender
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SMTPConf defines connection parameters of the mail server.
+type SMTPConf struct {
+	Host     string        `toml:"host" env:"HOST"`
+	Port     int           `toml:"port" env:"PORT"`
+	Username string        `toml:"username" env:"USERNAME"`
+	Password string        `toml:"password" env:"PASSWORD" secret:"password"`
+	From     string        `toml:"from" env:"FROM"`
+	StartTLS bool          `toml:"starttls" env:"STARTTLS"`
+	Timeout  time.Duration `toml:"timeout" env:"TIMEOUT"`
+}
+
+// WebhookConf defines parameters of the webhook HTTP client.
+type WebhookConf struct {
+	Timeout time.Duration `toml:"timeout" env:"TIMEOUT"`
+}
+
+// Preference describes how notifications of a single user are delivered.
+// A notification is sent to every non-empty channel.
+type Preference struct {
+	UserID  uuid.UUID `toml:"user_id"`
+	Email   string    `toml:"email"`
+	Webhook string    `toml:"webhook"`
+}
+
+// Validate checks the server settings; an empty host disables email notifications.
+func (c SMTPConf) Validate() error {
+	if c.Host == "" {
+		return nil
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("port: must be between 1 and 65535, got %d", c.Port)
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("from: %w", err)
+	}
+	if c.Password != "" && c.Username == "" {
+		return errors.New("username: required with password")
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("timeout: must not be negative, got %s", c.Timeout)
+	}
+	return nil
+}
+
+// Validate checks that the timeout is not negative.
+func (c WebhookConf) Validate() error {
+	if c.Timeout < 0 {
+		return fmt.Errorf("timeout: must not be negative, got %s", c.Timeout)
+	}
+	return nil
+}
+
+// Validate checks that the user is set and the channels are well-formed.
+func (p Preference) Validate() error {
+	if p.UserID == uuid.Nil {
+		return errors.New("user_id: required")
+	}
+	if p.Email != "" {
+		if _, err := mail.ParseAddress(p.Email); err != nil {
+			return fmt.Errorf("email: %w", err)
+		}
+	}
+	if p.Webhook != "" {
+		u, err := url.Parse(p.Webhook)
+		if err != nil {
+			return fmt.Errorf("webhook: %w", err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook: want an absolute http or https URL, got %q", p.Webhook)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package sender
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMTPConf_Validate(t *testing.T) {
+	require.NoError(t, SMTPConf{}.Validate(), "empty host disables SMTP")
+	require.NoError(t, SMTPConf{Host: "mail", Port: 25, From: "calendar@example.com"}.Validate())
+
+	require.EqualError(t, SMTPConf{Host: "mail", From: "calendar@example.com"}.Validate(),
+		"port: must be between 1 and 65535, got 0")
+	require.ErrorContains(t, SMTPConf{Host: "mail", Port: 25, From: "calendar"}.Validate(), "from: ")
+	require.EqualError(t, SMTPConf{Host: "mail", Port: 25, From: "calendar@example.com", Password: "p"}.Validate(),
+		"username: required with password")
+}
+
+func TestPreference_Validate(t *testing.T) {
+	id := uuid.New()
+	require.NoError(t, Preference{
+		UserID: id, Email: "user@example.com", Webhook: "https://example.com/hook",
+	}.Validate())
+
+	require.EqualError(t, Preference{Email: "user@example.com"}.Validate(), "user_id: required")
+	require.ErrorContains(t, Preference{UserID: id, Email: "user"}.Validate(), "email: ")
+	require.EqualError(t, Preference{UserID: id, Webhook: "example.com/hook"}.Validate(),
+		`webhook: want an absolute http or https URL, got "example.com/hook"`)
+}
@@ -0,0 +1,58 @@
+package sender
+
+import (
+	"sync"
+	"time"
+)
+
+// deliveredRetention is how long delivered channels of a notification are remembered.
+// A message retried or replayed from the dead-letter queue later than that is
+// delivered through all channels again.
+const deliveredRetention = 24 * time.Hour
+
+// deliveredLog remembers channels that already delivered a notification, so that
+// a redelivered message is sent only through the channels that failed.
+type deliveredLog struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	entries map[string]*deliveredEntry
+}
+
+type deliveredEntry struct {
+	channels map[string]bool
+	expires  time.Time
+}
+
+func newDeliveredLog() *deliveredLog {
+	return &deliveredLog{now: time.Now, entries: make(map[string]*deliveredEntry)}
+}
+
+// has reports whether the notification with the key was delivered through the channel.
+func (l *deliveredLog) has(key, channel string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[key]
+	return ok && l.now().Before(e.expires) && e.channels[channel]
+}
+
+// add records that the notification with the key was delivered through the channel.
+func (l *deliveredLog) add(key, channel string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	// Устаревшие записи удаляются при каждой доставке, отдельная горутина не нужна
+	for k, e := range l.entries {
+		if !now.Before(e.expires) {
+			delete(l.entries, k)
+		}
+	}
+
+	e, ok := l.entries[key]
+	if !ok {
+		e = &deliveredEntry{channels: make(map[string]bool), expires: now.Add(deliveredRetention)}
+		l.entries[key] = e
+	}
+	e.channels[channel] = true
+}
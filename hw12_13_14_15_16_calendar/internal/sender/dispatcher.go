@@ -0,0 +1,122 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/notification"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
+)
+
+// ErrNotifierDisabled is returned when a user prefers a channel that is not configured.
+var ErrNotifierDisabled = errors.New("notifier is not configured")
+
+// Notifier delivers a notification to a single recipient address.
+type Notifier interface {
+	Notify(ctx context.Context, to string, n storage.Notification) error
+}
+
+// Dispatcher routes notifications to notifiers according to user preferences.
+type Dispatcher struct {
+	email     Notifier
+	webhook   Notifier
+	prefs     map[uuid.UUID]Preference
+	delivered *deliveredLog
+	logger    *slog.Logger
+}
+
+func (d *Dispatcher) setLogCompMeth(ctx context.Context, method string) context.Context {
+	ctx = logger.WithLogComponent(ctx, "sender.dispatcher")
+	return logger.WithLogMethod(ctx, method)
+}
+
+// NewDispatcher creates a dispatcher. Nil notifiers disable the corresponding channel.
+func NewDispatcher(logger *slog.Logger, prefs []Preference, email, webhook Notifier) *Dispatcher {
+	m := make(map[uuid.UUID]Preference, len(prefs))
+	for _, p := range prefs {
+		m[p.UserID] = p
+	}
+	return &Dispatcher{
+		email:     email,
+		webhook:   webhook,
+		prefs:     m,
+		delivered: newDeliveredLog(),
+		logger:    logger,
+	}
+}
+
+// Handle delivers the notification to every channel preferred by its user.
+// It returns an error if at least one delivery failed. Channels that already
+// delivered the notification are skipped when the message is redelivered.
+func (d *Dispatcher) Handle(ctx context.Context, e notification.Envelope) error {
+	n := e.Payload
+	ctx = d.setLogCompMeth(ctx, "Handle")
+	ctx = logger.WithLogEventID(ctx, n.ID)
+
+	pref, ok := d.prefs[n.UserID]
+	if !ok {
+		d.logger.InfoContext(ctx, "no delivery preferences for user, notification skipped",
+			"userId", n.UserID.String(), "title", n.Title)
+		return nil
+	}
+
+	// Повторы и сообщения из очереди недоставленных несут тот же ключ идемпотентности
+	key := e.IdempotencyKey
+	if key == "" {
+		key = e.ID.String()
+	}
+
+	var errs []error
+	for _, c := range []struct {
+		name     string
+		notifier Notifier
+		to       string
+	}{
+		{name: "email", notifier: d.email, to: pref.Email},
+		{name: "webhook", notifier: d.webhook, to: pref.Webhook},
+	} {
+		if c.to == "" {
+			continue
+		}
+		if d.delivered.has(key, c.name) {
+			d.logger.InfoContext(ctx, "notification already delivered, channel skipped", "channel", c.name)
+			continue
+		}
+		if err := d.deliver(ctx, c.name, c.notifier, c.to, n); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		d.delivered.add(key, c.name)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return logger.AddPrefix(ctx, err)
+	}
+	return nil
+}
+
+func (d *Dispatcher) deliver(
+	ctx context.Context,
+	channel string,
+	notifier Notifier,
+	to string,
+	n storage.Notification,
+) error {
+	if notifier == nil {
+		d.logger.WarnContext(ctx, "channel is not configured", "channel", channel)
+		return fmt.Errorf("%s: %w", channel, ErrNotifierDisabled)
+	}
+
+	d.logger.DebugContext(ctx, "trying to deliver notification", "channel", channel)
+	if err := notifier.Notify(ctx, to, n); err != nil {
+		d.logger.ErrorContext(ctx, "failed to deliver notification", "channel", channel, "error", err)
+		return fmt.Errorf("%s: %w", channel, err)
+	}
+
+	d.logger.InfoContext(ctx, "notification delivered", "channel", channel, "title", n.Title)
+	return nil
+}
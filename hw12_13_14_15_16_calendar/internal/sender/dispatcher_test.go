@@ -0,0 +1,143 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/notification"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type notifierMock struct {
+	err  error
+	sent []string
+}
+
+func (m *notifierMock) Notify(_ context.Context, to string, _ storage.Notification) error {
+	m.sent = append(m.sent, to)
+	return m.err
+}
+
+func newEnvelope(userID uuid.UUID) notification.Envelope {
+	return notification.New(storage.Notification{ID: uuid.New(), UserID: userID, Start: time.Now()}, time.Now())
+}
+
+func TestDispatcher_Handle(t *testing.T) {
+	lg := logger.New("debug", os.Stdout, false)
+	userID := uuid.New()
+	prefs := []Preference{{UserID: userID, Email: "user@example.com", Webhook: "http://hook"}}
+
+	t.Run("delivers to all preferred channels", func(t *testing.T) {
+		email, webhook := &notifierMock{}, &notifierMock{}
+		d := NewDispatcher(lg, prefs, email, webhook)
+
+		err := d.Handle(context.Background(), newEnvelope(userID))
+		require.NoError(t, err)
+		require.Equal(t, []string{"user@example.com"}, email.sent)
+		require.Equal(t, []string{"http://hook"}, webhook.sent)
+	})
+
+	t.Run("user without preferences is skipped", func(t *testing.T) {
+		email, webhook := &notifierMock{}, &notifierMock{}
+		d := NewDispatcher(lg, prefs, email, webhook)
+
+		err := d.Handle(context.Background(), newEnvelope(uuid.New()))
+		require.NoError(t, err)
+		require.Empty(t, email.sent)
+		require.Empty(t, webhook.sent)
+	})
+
+	t.Run("failed channel is reported", func(t *testing.T) {
+		boom := errors.New("boom")
+		email, webhook := &notifierMock{err: boom}, &notifierMock{}
+		d := NewDispatcher(lg, prefs, email, webhook)
+
+		err := d.Handle(context.Background(), newEnvelope(userID))
+		require.ErrorIs(t, err, boom)
+		require.Len(t, webhook.sent, 1)
+	})
+
+	t.Run("disabled channel is reported", func(t *testing.T) {
+		d := NewDispatcher(lg, prefs, nil, &notifierMock{})
+
+		err := d.Handle(context.Background(), newEnvelope(userID))
+		require.ErrorIs(t, err, ErrNotifierDisabled)
+	})
+
+	t.Run("redelivery skips delivered channels", func(t *testing.T) {
+		boom := errors.New("boom")
+		email, webhook := &notifierMock{err: boom}, &notifierMock{}
+		d := NewDispatcher(lg, prefs, email, webhook)
+		e := newEnvelope(userID)
+
+		require.ErrorIs(t, d.Handle(context.Background(), e), boom)
+		email.err = nil
+		require.NoError(t, d.Handle(context.Background(), e))
+		require.NoError(t, d.Handle(context.Background(), e))
+		require.Len(t, email.sent, 2)
+		require.Len(t, webhook.sent, 1)
+
+		// Сообщение о перенесённом событии имеет другой ключ и доставляется заново
+		e.Payload.Start = e.Payload.Start.Add(time.Hour)
+		e.IdempotencyKey = notification.IdempotencyKey(e.Payload)
+		require.NoError(t, d.Handle(context.Background(), e))
+		require.Len(t, email.sent, 3)
+		require.Len(t, webhook.sent, 2)
+	})
+}
+
+func TestDeliveredLog(t *testing.T) {
+	now := time.Now()
+	l := newDeliveredLog()
+	l.now = func() time.Time { return now }
+
+	l.add("key", "email")
+	require.True(t, l.has("key", "email"))
+	require.False(t, l.has("key", "webhook"))
+	require.False(t, l.has("other", "email"))
+
+	now = now.Add(deliveredRetention)
+	require.False(t, l.has("key", "email"))
+	l.add("other", "email")
+	require.NotContains(t, l.entries, "key")
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	lg := logger.New("debug", os.Stdout, false)
+	n := storage.Notification{
+		ID:     uuid.New(),
+		Title:  "webhook event",
+		Start:  time.Now().Truncate(time.Second).UTC(),
+		UserID: uuid.New(),
+	}
+
+	var got storage.Notification
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	err := NewWebhookNotifier(WebhookConf{}, lg).Notify(context.Background(), srv.URL, n)
+	require.NoError(t, err)
+	require.Equal(t, n, got)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	err = NewWebhookNotifier(WebhookConf{}, lg).Notify(context.Background(), failing.URL, n)
+	require.ErrorContains(t, err, "unexpected status")
+}
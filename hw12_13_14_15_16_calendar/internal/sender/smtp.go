@@ -0,0 +1,189 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+//go:embed templates/*.tmpl
+var templatesFS embed.FS
+
+const defaultSMTPTimeout = 10 * time.Second
+
+// SMTPNotifier sends notifications as multipart (plain text and HTML) emails.
+type SMTPNotifier struct {
+	addr     string
+	host     string
+	from     string
+	auth     smtp.Auth
+	startTLS bool
+	timeout  time.Duration
+	html     *htmltemplate.Template
+	text     *texttemplate.Template
+	logger   *slog.Logger
+}
+
+func (s *SMTPNotifier) setLogCompMeth(ctx context.Context, method string) context.Context {
+	ctx = logger.WithLogComponent(ctx, "sender.smtp")
+	return logger.WithLogMethod(ctx, method)
+}
+
+// NewSMTPNotifier creates an email notifier from configuration.
+func NewSMTPNotifier(cfg SMTPConf, logger *slog.Logger) (*SMTPNotifier, error) {
+	html, err := htmltemplate.ParseFS(templatesFS, "templates/notification.html.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("parse html template: %w", err)
+	}
+	text, err := texttemplate.ParseFS(templatesFS, "templates/notification.txt.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("parse text template: %w", err)
+	}
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultSMTPTimeout
+	}
+
+	return &SMTPNotifier{
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		host:     cfg.Host,
+		from:     cfg.From,
+		auth:     auth,
+		startTLS: cfg.StartTLS,
+		timeout:  timeout,
+		html:     html,
+		text:     text,
+		logger:   logger,
+	}, nil
+}
+
+// Notify sends the notification to the given email address.
+func (s *SMTPNotifier) Notify(ctx context.Context, to string, n storage.Notification) error {
+	ctx = s.setLogCompMeth(ctx, "Notify")
+
+	msg, err := s.buildMessage(to, n)
+	if err != nil {
+		return logger.AddPrefix(ctx, err)
+	}
+
+	s.logger.DebugContext(ctx, "trying to send email", "to", to, "addr", s.addr)
+	if err := s.send(ctx, to, msg); err != nil {
+		return logger.AddPrefix(ctx, err)
+	}
+	s.logger.InfoContext(ctx, "email sent", "to", to)
+	return nil
+}
+
+func (s *SMTPNotifier) send(ctx context.Context, to string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp client: %w", err)
+	}
+	defer c.Close()
+
+	if s.startTLS {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if s.auth != nil {
+		if err := c.Auth(s.auth); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+	if err := c.Mail(s.from); err != nil {
+		return fmt.Errorf("mail from: %w", err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return fmt.Errorf("rcpt to: %w", err)
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("write body: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("close body: %w", err)
+	}
+
+	return c.Quit()
+}
+
+func (s *SMTPNotifier) buildMessage(to string, n storage.Notification) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", s.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "Reminder: "+n.Title))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+
+	parts := []struct {
+		contentType string
+		execute     func(w *quotedprintable.Writer) error
+	}{
+		{"text/plain; charset=utf-8", func(w *quotedprintable.Writer) error { return s.text.Execute(w, n) }},
+		{"text/html; charset=utf-8", func(w *quotedprintable.Writer) error { return s.html.Execute(w, n) }},
+	}
+	for _, p := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create part: %w", err)
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if err := p.execute(qw); err != nil {
+			return nil, fmt.Errorf("render template: %w", err)
+		}
+		if err := qw.Close(); err != nil {
+			return nil, fmt.Errorf("encode part: %w", err)
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("close multipart: %w", err)
+	}
+	return buf.Bytes(), nil
+}
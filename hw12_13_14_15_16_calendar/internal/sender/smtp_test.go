@@ -0,0 +1,176 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer is a minimal SMTP server that stores received messages.
+type fakeSMTPServer struct {
+	lis      net.Listener
+	rejectTo string
+
+	mu       sync.Mutex
+	rcpts    []string
+	messages []string
+}
+
+func newFakeSMTPServer(t *testing.T, rejectTo string) *fakeSMTPServer {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeSMTPServer{lis: lis, rejectTo: rejectTo}
+	go s.serve()
+	t.Cleanup(func() { lis.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) conf() SMTPConf {
+	host, port, _ := net.SplitHostPort(s.lis.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return SMTPConf{Host: host, Port: p, From: "calendar@example.com", Timeout: time.Second}
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.lis.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+
+	_ = tp.PrintfLine("220 localhost ESMTP fake")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			_ = tp.PrintfLine("250 localhost")
+		case "MAIL":
+			_ = tp.PrintfLine("250 OK")
+		case "RCPT":
+			to := strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>")
+			if s.rejectTo != "" && to == s.rejectTo {
+				_ = tp.PrintfLine("550 mailbox unavailable")
+				continue
+			}
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, to)
+			s.mu.Unlock()
+			_ = tp.PrintfLine("250 OK")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, string(data))
+			s.mu.Unlock()
+			_ = tp.PrintfLine("250 OK")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) received() ([]string, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.rcpts...), append([]string(nil), s.messages...)
+}
+
+func TestSMTPNotifier_Notify(t *testing.T) {
+	srv := newFakeSMTPServer(t, "")
+
+	n, err := NewSMTPNotifier(srv.conf(), logger.New("debug", os.Stdout, false))
+	require.NoError(t, err)
+
+	notification := storage.Notification{
+		ID:     uuid.New(),
+		Title:  "Встреча <команды>",
+		Start:  time.Date(2030, 1, 2, 15, 4, 0, 0, time.UTC),
+		UserID: uuid.New(),
+	}
+
+	err = n.Notify(context.Background(), "user@example.com", notification)
+	require.NoError(t, err)
+
+	rcpts, messages := srv.received()
+	require.Equal(t, []string{"user@example.com"}, rcpts)
+	require.Len(t, messages, 1)
+
+	msg, err := mail.ReadMessage(strings.NewReader(messages[0]))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	require.Equal(t, "Reminder: "+notification.Title, subject)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	parts := map[string]string{}
+	for {
+		p, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(p)
+		require.NoError(t, err)
+		ct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts[ct] = string(body)
+	}
+
+	require.Contains(t, parts["text/plain"], notification.Title)
+	require.Contains(t, parts["text/plain"], "2030-01-02 15:04 UTC")
+	require.Contains(t, parts["text/html"], "Встреча &lt;команды&gt;")
+	require.Contains(t, parts["text/html"], notification.ID.String())
+}
+
+func TestSMTPNotifier_RecipientRejected(t *testing.T) {
+	srv := newFakeSMTPServer(t, "bad@example.com")
+
+	n, err := NewSMTPNotifier(srv.conf(), logger.New("debug", os.Stdout, false))
+	require.NoError(t, err)
+
+	err = n.Notify(context.Background(), "bad@example.com", storage.Notification{ID: uuid.New(), Title: "t"})
+	require.ErrorContains(t, err, "rcpt to")
+
+	_, messages := srv.received()
+	require.Empty(t, messages)
+}
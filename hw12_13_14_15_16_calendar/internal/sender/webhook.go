@@ -0,0 +1,75 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const defaultWebhookTimeout = 10 * time.Second
+
+// WebhookNotifier posts notifications as JSON to user-defined URLs.
+type WebhookNotifier struct {
+	client *http.Client
+	logger *slog.Logger
+}
+
+func (w *WebhookNotifier) setLogCompMeth(ctx context.Context, method string) context.Context {
+	ctx = logger.WithLogComponent(ctx, "sender.webhook")
+	return logger.WithLogMethod(ctx, method)
+}
+
+// NewWebhookNotifier creates a webhook notifier from configuration.
+func NewWebhookNotifier(cfg WebhookConf, logger *slog.Logger) *WebhookNotifier {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &WebhookNotifier{
+		client: &http.Client{Timeout: timeout},
+		logger: logger,
+	}
+}
+
+// Notify posts the notification to the given URL. Any non-2xx response is an error.
+func (w *WebhookNotifier) Notify(ctx context.Context, url string, n storage.Notification) error {
+	ctx = w.setLogCompMeth(ctx, "Notify")
+
+	body, err := json.Marshal(n)
+	if err != nil {
+		return logger.AddPrefix(ctx, fmt.Errorf("marshal notification: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return logger.AddPrefix(ctx, fmt.Errorf("build request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "calendar-sender")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	w.logger.DebugContext(ctx, "trying to call webhook", "url", url)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return logger.AddPrefix(ctx, fmt.Errorf("post: %w", err))
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return logger.AddPrefix(ctx, fmt.Errorf("unexpected status: %s", resp.Status))
+	}
+
+	w.logger.InfoContext(ctx, "webhook called", "url", url, "status", resp.StatusCode)
+	return nil
+}
@@ -27,4 +27,13 @@ var (
 	ErrUpdateEvent = errors.New("error updating event")
 	// ErrDeleteEvent reports a failure during event deletion.
 	ErrDeleteEvent = errors.New("error deleting event")
+	// ErrWatchEvents reports a failure to subscribe to event changes.
+	ErrWatchEvents = errors.New("error watching events")
+	// ErrWatchInterrupted means the change feed ended before the client left;
+	// the client should reconnect with the last resume token.
+	ErrWatchInterrupted = errors.New("watching interrupted, resume with the last token")
+	// ErrRateLimited means the client sent too many requests.
+	ErrRateLimited = errors.New("rate limit exceeded, retry later")
+	// ErrInternal reports an unexpected server failure.
+	ErrInternal = errors.New("internal server error")
 )
@@ -0,0 +1,92 @@
+package grpcserver
+
+import (
+	"time"
+
+	pb "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api"
+	server "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
+	storage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/watch"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// EventToProto converts an event to its protobuf representation.
+func EventToProto(e storage.Event) *pb.Event {
+	return &pb.Event{
+		Id:          e.ID.String(),
+		UserId:      e.UserID.String(),
+		Title:       e.Title,
+		StartTime:   timestamppb.New(e.Start),
+		EndTime:     timestamppb.New(e.End),
+		Description: e.Description,
+		TimeBefore:  int64(e.TimeBefore.Seconds()),
+	}
+}
+
+// EventFromProto converts a protobuf event to an event.
+func EventFromProto(e *pb.Event) (storage.Event, error) {
+	if e == nil {
+		return storage.Event{}, server.ErrMissingEvent
+	}
+	id, err := uuid.Parse(e.Id)
+	if err != nil {
+		return storage.Event{}, server.ErrInvalidEventID
+	}
+	userID, err := uuid.Parse(e.UserId)
+	if err != nil {
+		return storage.Event{}, server.ErrInvalidUserID
+	}
+
+	return storage.Event{
+		ID:          id,
+		UserID:      userID,
+		Title:       e.Title,
+		Start:       e.StartTime.AsTime(),
+		End:         e.EndTime.AsTime(),
+		Description: e.Description,
+		TimeBefore:  time.Duration(e.TimeBefore) * time.Second,
+	}, nil
+}
+
+var changeTypes = map[watch.ChangeType]pb.EventChange_Type{
+	watch.Created: pb.EventChange_CREATED,
+	watch.Updated: pb.EventChange_UPDATED,
+	watch.Deleted: pb.EventChange_DELETED,
+}
+
+// ChangeToProto converts an event change to its protobuf representation.
+func ChangeToProto(c watch.Change) *pb.EventChange {
+	event := &pb.Event{Id: c.Event.ID.String()}
+	if c.Type != watch.Deleted {
+		event = EventToProto(c.Event)
+	}
+	return &pb.EventChange{
+		Type:        changeTypes[c.Type],
+		Event:       event,
+		Time:        timestamppb.New(c.Time),
+		ResumeToken: c.Token,
+	}
+}
+
+// FilterFromProto converts a watch request to a change filter.
+func FilterFromProto(req *pb.WatchEventsReq) (watch.Filter, error) {
+	var filter watch.Filter
+	if req.GetUserId() != "" {
+		userID, err := uuid.Parse(req.GetUserId())
+		if err != nil {
+			return watch.Filter{}, server.ErrInvalidUserID
+		}
+		filter.UserID = userID
+	}
+	if req.GetFrom() != nil {
+		filter.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		filter.To = req.GetTo().AsTime()
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return watch.Filter{}, server.ErrInvalidPeriod
+	}
+	return filter, nil
+}
@@ -0,0 +1,129 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	server "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
+	storage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/watch"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ErrorDomain is the domain of google.rpc.ErrorInfo details returned by the server.
+const ErrorDomain = "calendar"
+
+// Причины ошибок в google.rpc.ErrorInfo, по ним клиенты различают ошибки без разбора текста.
+const (
+	ReasonInvalidEvent  = "INVALID_EVENT"
+	ReasonInvalidInput  = "INVALID_ARGUMENT"
+	ReasonIDRepeated    = "EVENT_ID_REPEATED"
+	ReasonEventNotFound = "EVENT_NOT_FOUND"
+	ReasonDateBusy      = "DATE_BUSY"
+	ReasonTokenExpired  = "RESUME_TOKEN_EXPIRED"
+	ReasonRateLimited   = "RATE_LIMITED"
+)
+
+// requestFields maps request parsing errors to the offending request field.
+var requestFields = []struct {
+	err   error
+	field string
+}{
+	{server.ErrMissingEvent, "event"},
+	{server.ErrMissingEventID, "id"},
+	{server.ErrInvalidEventID, "id"},
+	{server.ErrInvalidUserID, "user_id"},
+	{server.ErrInvalidStartPeriod, "start"},
+	{server.ErrInvalidPeriod, "to"},
+	{watch.ErrInvalidToken, "resume_token"},
+}
+
+// toStatus converts an error to a gRPC status the same way checkError does for HTTP.
+// Unknown errors are reported as Internal with the message of internalError.
+func toStatus(err, internalError error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var ve *storage.ErrInvalidEvent
+	if errors.As(err, &ve) {
+		return invalidArgument(ve.Error(), ReasonInvalidEvent, ve.Field, ve.Message)
+	}
+
+	for _, rf := range requestFields {
+		if errors.Is(err, rf.err) {
+			return invalidArgument(rf.err.Error(), ReasonInvalidInput, rf.field, rf.err.Error())
+		}
+	}
+
+	switch {
+	case errors.Is(err, storage.ErrIDRepeated):
+		return withInfo(codes.AlreadyExists, storage.ErrIDRepeated.Error(), ReasonIDRepeated)
+	case errors.Is(err, storage.ErrIDNotExist):
+		return withInfo(codes.NotFound, storage.ErrIDNotExist.Error(), ReasonEventNotFound)
+	case errors.Is(err, storage.ErrDateBusy):
+		return withInfo(codes.FailedPrecondition, storage.ErrDateBusy.Error(), ReasonDateBusy)
+	case errors.Is(err, watch.ErrTokenExpired):
+		return withInfo(codes.OutOfRange, watch.ErrTokenExpired.Error(), ReasonTokenExpired)
+	case errors.Is(err, server.ErrWatchInterrupted):
+		return status.Error(codes.Unavailable, server.ErrWatchInterrupted.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+
+	return status.Error(codes.Internal, internalError.Error())
+}
+
+func invalidArgument(msg, reason, field, description string) error {
+	st := status.New(codes.InvalidArgument, msg)
+	detailed, err := st.WithDetails(
+		&errdetails.ErrorInfo{
+			Reason:   reason,
+			Domain:   ErrorDomain,
+			Metadata: map[string]string{"field": field},
+		},
+		&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: field, Description: description},
+			},
+		},
+	)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func withInfo(code codes.Code, msg, reason string) error {
+	st := status.New(code, msg)
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: ErrorDomain,
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// rateLimited reports an exceeded rate limit; RetryInfo tells when to retry.
+func rateLimited(delay time.Duration) error {
+	st := status.New(codes.ResourceExhausted, server.ErrRateLimited.Error())
+	detailed, err := st.WithDetails(
+		&errdetails.ErrorInfo{
+			Reason: ReasonRateLimited,
+			Domain: ErrorDomain,
+		},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)},
+	)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,112 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	pb "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api"
+	server "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
+	storage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   codes.Code
+		reason string
+		field  string
+	}{
+		{
+			name:   "invalid event",
+			err:    fmt.Errorf("app: %w", &storage.ErrInvalidEvent{Field: "start", Message: "in the past"}),
+			code:   codes.InvalidArgument,
+			reason: ReasonInvalidEvent,
+			field:  "start",
+		},
+		{
+			name:   "invalid user id",
+			err:    fmt.Errorf("server.grpc: %w", server.ErrInvalidUserID),
+			code:   codes.InvalidArgument,
+			reason: ReasonInvalidInput,
+			field:  "user_id",
+		},
+		{name: "id repeated", err: storage.ErrIDRepeated, code: codes.AlreadyExists, reason: ReasonIDRepeated},
+		{name: "not found", err: storage.ErrIDNotExist, code: codes.NotFound, reason: ReasonEventNotFound},
+		{name: "date busy", err: storage.ErrDateBusy, code: codes.FailedPrecondition, reason: ReasonDateBusy},
+		{name: "deadline", err: context.DeadlineExceeded, code: codes.DeadlineExceeded},
+		{name: "unknown", err: errors.New("db is down"), code: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(toStatus(tt.err, server.ErrCreateEvent))
+			require.Equal(t, tt.code, st.Code())
+
+			var info *errdetails.ErrorInfo
+			var badRequest *errdetails.BadRequest
+			for _, d := range st.Details() {
+				switch d := d.(type) {
+				case *errdetails.ErrorInfo:
+					info = d
+				case *errdetails.BadRequest:
+					badRequest = d
+				}
+			}
+
+			if tt.reason == "" {
+				require.Nil(t, info)
+				return
+			}
+			require.NotNil(t, info)
+			require.Equal(t, tt.reason, info.GetReason())
+			require.Equal(t, ErrorDomain, info.GetDomain())
+
+			if tt.field == "" {
+				require.Nil(t, badRequest)
+				return
+			}
+			require.Equal(t, tt.field, info.GetMetadata()["field"])
+			require.NotNil(t, badRequest)
+			require.Len(t, badRequest.GetFieldViolations(), 1)
+			require.Equal(t, tt.field, badRequest.GetFieldViolations()[0].GetField())
+		})
+	}
+
+	require.Equal(t, server.ErrCreateEvent.Error(),
+		status.Convert(toStatus(errors.New("db is down"), server.ErrCreateEvent)).Message())
+}
+
+func TestCreateEvent_StatusCodes(t *testing.T) {
+	app := &mockApp{
+		CreateEventFn: func(context.Context, storage.Event) error {
+			return fmt.Errorf("app: %w", storage.ErrDateBusy)
+		},
+	}
+	client, cleanup := newTestServer(t, app)
+	defer cleanup()
+
+	event := &pb.Event{
+		Id:        uuid.NewString(),
+		UserId:    uuid.NewString(),
+		Title:     "busy",
+		StartTime: timestamppb.New(time.Now().Add(time.Hour)),
+		EndTime:   timestamppb.New(time.Now().Add(2 * time.Hour)),
+	}
+	_, err := client.CreateEvent(context.Background(), &pb.CreateEventReq{Event: event})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	event.UserId = "not-a-uuid"
+	_, err = client.CreateEvent(context.Background(), &pb.CreateEventReq{Event: event})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Len(t, status.Convert(err).Details(), 2)
+}
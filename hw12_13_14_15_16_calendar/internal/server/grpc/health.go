@@ -0,0 +1,59 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	pb "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/health"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// NewHealthServer returns the grpc.health.v1 service reporting readiness of checker
+// for the server as a whole ("") and for the Calendar service. The status is
+// refreshed every interval until ctx is done, after which it is NOT_SERVING.
+func NewHealthServer(ctx context.Context, lg *slog.Logger, checker *health.Checker,
+	interval time.Duration,
+) *grpchealth.Server {
+	srv := grpchealth.NewServer()
+	services := []string{"", pb.Calendar_ServiceDesc.ServiceName}
+
+	ctx = logger.WithLogComponent(ctx, "server.grpc")
+	ctx = logger.WithLogMethod(ctx, "health")
+
+	current := healthpb.HealthCheckResponse_UNKNOWN
+	update := func() {
+		status := healthpb.HealthCheckResponse_SERVING
+		if err := checker.Ready(ctx).Err(); err != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+			if current != status && !errors.Is(err, health.ErrNotReady) {
+				lg.WarnContext(ctx, "service is not serving", "error", err)
+			}
+		}
+		current = status
+		for _, name := range services {
+			srv.SetServingStatus(name, status)
+		}
+	}
+	update()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				// Shutdown переводит все сервисы в NOT_SERVING и не даёт их вернуть
+				srv.Shutdown()
+				return
+			case <-ticker.C:
+				update()
+			}
+		}
+	}()
+	return srv
+}
@@ -0,0 +1,50 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/health"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealthServer(t *testing.T) {
+	var dbDown atomic.Bool
+	checker := health.New(time.Second)
+	checker.AddReadiness("postgres", func(context.Context) error {
+		if dbDown.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := NewHealthServer(ctx, logger.New("debug", os.Stdout, false), checker, 10*time.Millisecond)
+
+	status := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := srv.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.GetStatus()
+	}
+
+	// Пока сервис не запущен, он не принимает запросы
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(""))
+
+	checker.SetReady(true)
+	require.Eventually(t, func() bool {
+		return status(pb.Calendar_ServiceDesc.ServiceName) == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+
+	dbDown.Store(true)
+	require.Eventually(t, func() bool {
+		return status("") == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,355 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"runtime/debug"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/metrics"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/ratelimit"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tlsconfig"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ServerOptions returns the interceptor chains shared by calendar gRPC servers.
+// A nil limiter disables rate limits.
+func ServerOptions(lg *slog.Logger, timeout time.Duration, limiter *ratelimit.Limiter) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			RequestIDInterceptor(),
+			ClientInterceptor(),
+			TracingInterceptor(),
+			LoggingInterceptor(lg),
+			MetricsInterceptor(),
+			RateLimitInterceptor(limiter),
+			RecoveryInterceptor(lg),
+			TimeoutInterceptor(timeout),
+		),
+		grpc.ChainStreamInterceptor(
+			RequestIDStreamInterceptor(),
+			ClientStreamInterceptor(),
+			TracingStreamInterceptor(),
+			LoggingStreamInterceptor(lg),
+			MetricsStreamInterceptor(),
+			RateLimitStreamInterceptor(limiter),
+			RecoveryStreamInterceptor(lg),
+		),
+	}
+}
+
+// RequestIDInterceptor takes the request ID from metadata or generates a new one,
+// returns it in the response header and stores it in the logging context.
+func RequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		id := requestID(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(server.RequestIDMetadataKey, id))
+		return handler(logger.WithLogRequestID(ctx, id), req)
+	}
+}
+
+// RequestIDStreamInterceptor is the streaming counterpart of RequestIDInterceptor.
+func RequestIDStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		id := requestID(ss.Context())
+		_ = ss.SetHeader(metadata.Pairs(server.RequestIDMetadataKey, id))
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: logger.WithLogRequestID(ss.Context(), id)})
+	}
+}
+
+func requestID(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return server.RequestID(metadataCarrier(md).Get(server.RequestIDMetadataKey))
+}
+
+// ClientInterceptor stores the identity of a client authenticated by a TLS
+// certificate in the logging context.
+func ClientInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if client := peerIdentity(ctx); client != "" {
+			ctx = logger.WithLogClient(ctx, client)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// ClientStreamInterceptor is the streaming counterpart of ClientInterceptor.
+func ClientStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if client := peerIdentity(ss.Context()); client != "" {
+			ss = &wrappedStream{ServerStream: ss, ctx: logger.WithLogClient(ss.Context(), client)}
+		}
+		return handler(srv, ss)
+	}
+}
+
+func peerIdentity(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ""
+	}
+	return tlsconfig.Identity(&info.State)
+}
+
+// LoggingInterceptor writes an access log record for every unary call.
+func LoggingInterceptor(lg *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, lg, "grpc request finished", info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// LoggingStreamInterceptor writes an access log record when a stream finishes.
+func LoggingStreamInterceptor(lg *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), lg, "grpc stream finished", info.FullMethod, start, err)
+		return err
+	}
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	ip := p.Addr.String()
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return ip
+}
+
+func logCall(ctx context.Context, lg *slog.Logger, msg, method string, start time.Time, err error) {
+	ip := peerIP(ctx)
+
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case grpccodes.OK, grpccodes.Canceled:
+	case grpccodes.Internal, grpccodes.Unknown, grpccodes.DataLoss, grpccodes.Unavailable:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+
+	attrs := []slog.Attr{
+		slog.String("ip", ip),
+		slog.String("grpc_method", method),
+		slog.String("code", code.String()),
+		slog.Int64("latency_ms", time.Since(start).Milliseconds()),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	lg.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// RateLimitInterceptor rejects calls of a client exceeding the limit of the method
+// with ResourceExhausted and RetryInfo.
+func RateLimitInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := rateLimit(ctx, limiter, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitStreamInterceptor is the streaming counterpart of RateLimitInterceptor.
+func RateLimitStreamInterceptor(limiter *ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := rateLimit(ss.Context(), limiter, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func rateLimit(ctx context.Context, limiter *ratelimit.Limiter, method string) error {
+	// Аутентифицированный клиент ограничивается по сертификату, остальные — по адресу
+	client := logger.ClientFromContext(ctx)
+	if client == "" {
+		client = peerIP(ctx)
+	}
+	ok, delay := limiter.Allow(method, client)
+	if ok {
+		return nil
+	}
+	metrics.RateLimited.WithLabelValues("grpc", method).Inc()
+	return rateLimited(delay)
+}
+
+// RecoveryInterceptor turns a panic in a handler into an Internal error.
+func RecoveryInterceptor(lg *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ctx, lg, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// RecoveryStreamInterceptor is the streaming counterpart of RecoveryInterceptor.
+func RecoveryStreamInterceptor(lg *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ss.Context(), lg, info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func recovered(ctx context.Context, lg *slog.Logger, method string, r any) error {
+	lg.ErrorContext(ctx, "panic in grpc handler",
+		"grpc_method", method, "panic", r, "stack", string(debug.Stack()))
+	return status.Error(grpccodes.Internal, "internal error")
+}
+
+// TimeoutInterceptor limits the duration of unary calls. The client deadline
+// is kept if it is shorter. Streams are long-lived and are not limited.
+func TimeoutInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		resp, err := handler(ctx, req)
+		// Без этого истёкший таймаут превратился бы в codes.Unknown
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && status.Code(err) == grpccodes.Unknown {
+			return resp, status.Error(grpccodes.DeadlineExceeded, err.Error())
+		}
+		return resp, err
+	}
+}
+
+// MetricsInterceptor records the number and latency of unary calls.
+func MetricsInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeCall(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// MetricsStreamInterceptor is the streaming counterpart of MetricsInterceptor.
+// The latency of a stream is its whole lifetime.
+func MetricsStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		observeCall(info.FullMethod, start, err)
+		return err
+	}
+}
+
+func observeCall(method string, start time.Time, err error) {
+	metrics.GRPCRequests.WithLabelValues(method, status.Code(err).String()).Inc()
+	metrics.GRPCDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// TracingInterceptor starts a server span for every unary call, continuing
+// the trace passed by the client in metadata.
+func TracingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, span := startSpan(ctx, info.FullMethod)
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+		finishSpan(span, err)
+		return resp, err
+	}
+}
+
+// TracingStreamInterceptor is the streaming counterpart of TracingInterceptor.
+// The span covers the whole stream.
+func TracingStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startSpan(ss.Context(), info.FullMethod)
+		defer span.End()
+
+		err := handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+		finishSpan(span, err)
+		return err
+	}
+}
+
+func startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	return tracing.Tracer().Start(ctx, method, trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", method),
+		))
+}
+
+func finishSpan(span trace.Span, err error) {
+	code := status.Code(err)
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", code.String()))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// metadataCarrier adapts gRPC metadata to propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// wrappedStream overrides the context of a server stream.
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *wrappedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,192 @@
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	pb "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/admin"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/ratelimit"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRequestIDInterceptor(t *testing.T) {
+	var got string
+	app := &mockApp{
+		GetEventsDayFn: func(ctx context.Context, _ time.Time) ([]storage.Event, error) {
+			got = logger.RequestIDFromContext(ctx)
+			return nil, nil
+		},
+	}
+	client, cleanup := newTestServer(t, app)
+	defer cleanup()
+
+	req := &pb.GetEventsReq{Start: timestamppb.Now()}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), server.RequestIDMetadataKey, "req-42")
+	var header metadata.MD
+	_, err := client.GetEventsDay(ctx, req, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Equal(t, "req-42", got)
+	require.Equal(t, []string{"req-42"}, header.Get(server.RequestIDMetadataKey))
+
+	_, err = client.GetEventsDay(context.Background(), req, grpc.Header(&header))
+	require.NoError(t, err)
+	require.NotEmpty(t, got)
+	require.NotEqual(t, "req-42", got)
+	require.Equal(t, []string{got}, header.Get(server.RequestIDMetadataKey))
+}
+
+func TestClientInterceptor(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "scheduler"}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}},
+	})
+
+	var got string
+	handler := func(ctx context.Context, _ any) (any, error) {
+		got = logger.ClientFromContext(ctx)
+		return nil, nil
+	}
+	_, err := ClientInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	require.NoError(t, err)
+	require.Equal(t, "scheduler", got)
+
+	// Без TLS клиент остаётся анонимным
+	_, err = ClientInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	require.NoError(t, err)
+	require.Empty(t, got)
+}
+
+func TestRecoveryInterceptor(t *testing.T) {
+	app := &mockApp{
+		GetEventsDayFn: func(context.Context, time.Time) ([]storage.Event, error) {
+			panic("boom")
+		},
+	}
+	client, cleanup := newTestServer(t, app)
+	defer cleanup()
+
+	_, err := client.GetEventsDay(context.Background(), &pb.GetEventsReq{Start: timestamppb.Now()})
+	require.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestTimeoutInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/test/Slow"}
+	slow := func(ctx context.Context, _ any) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	_, err := TimeoutInterceptor(10*time.Millisecond)(context.Background(), nil, info, slow)
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	// Более короткий дедлайн клиента сохраняется
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = TimeoutInterceptor(time.Hour)(ctx, nil, info, slow)
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestLoggingInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	lg := logger.New("debug", &buf, true)
+	info := &grpc.UnaryServerInfo{FullMethod: "/calendar.Calendar/DeleteEvent"}
+
+	ctx := logger.WithLogRequestID(context.Background(), "req-7")
+	_, err := LoggingInterceptor(lg)(ctx, nil, info, func(context.Context, any) (any, error) {
+		return nil, status.Error(codes.NotFound, "no event")
+	})
+	require.Error(t, err)
+
+	out := buf.String()
+	require.Contains(t, out, `"msg":"grpc request finished"`)
+	require.Contains(t, out, `"request_id":"req-7"`)
+	require.Contains(t, out, `"grpc_method":"/calendar.Calendar/DeleteEvent"`)
+	require.Contains(t, out, `"code":"NotFound"`)
+	require.Contains(t, out, `"level":"WARN"`)
+}
+
+func TestRateLimitInterceptor(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.Config{
+		Enabled: true,
+		Routes:  map[string]ratelimit.Rule{"/test/Limited": {Rate: 0.5, Burst: 1}},
+	})
+	info := &grpc.UnaryServerInfo{FullMethod: "/test/Limited"}
+	ok := func(context.Context, any) (any, error) { return nil, nil }
+
+	_, err := RateLimitInterceptor(limiter)(context.Background(), nil, info, ok)
+	require.NoError(t, err)
+
+	_, err = RateLimitInterceptor(limiter)(context.Background(), nil, info, ok)
+	st := status.Convert(err)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	var retry *errdetails.RetryInfo
+	for _, d := range st.Details() {
+		if r, isRetry := d.(*errdetails.RetryInfo); isRetry {
+			retry = r
+		}
+	}
+	require.NotNil(t, retry)
+	require.InDelta(t, 2*time.Second, retry.GetRetryDelay().AsDuration(), float64(100*time.Millisecond))
+
+	// Без ограничителя вызовы не ограничиваются
+	_, err = RateLimitInterceptor(nil)(context.Background(), nil, info, ok)
+	require.NoError(t, err)
+}
+
+func TestStreamInterceptors_MetricsAndTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	const method = "/test.Stream/Watch"
+	info := &grpc.StreamServerInfo{FullMethod: method, IsServerStream: true}
+	ss := &wrappedStream{ctx: context.Background()}
+
+	var spanInHandler bool
+	handler := func(_ any, ss grpc.ServerStream) error {
+		spanInHandler = trace.SpanFromContext(ss.Context()).SpanContext().IsValid()
+		return status.Error(codes.Unavailable, "stream closed")
+	}
+	err := TracingStreamInterceptor()(nil, ss, info, func(srv any, ss grpc.ServerStream) error {
+		return MetricsStreamInterceptor()(srv, ss, info, handler)
+	})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.True(t, spanInHandler)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, method, spans[0].Name())
+	require.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+
+	rec := httptest.NewRecorder()
+	admin.NewServer(admin.Config{}, logger.New("debug", os.Stdout, false)).Handler().
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Contains(t, rec.Body.String(), `calendar_grpc_requests_total{code="Unavailable",method="`+method+`"} 1`)
+	require.Contains(t, rec.Body.String(), `calendar_grpc_request_duration_seconds_count{method="`+method+`"} 1`)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
 	server "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
 	storage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -41,7 +42,7 @@ func (s *CalendarServer) CreateEvent(ctx context.Context, req *pb.CreateEventReq
 	event, err := getEventFromBody(ctx, s.logger, req)
 	if err != nil {
 		s.logger.ErrorContext(ctx, err.Error())
-		return &emptypb.Empty{}, server.ErrInvalidEventData
+		return &emptypb.Empty{}, toStatus(err, server.ErrInvalidEventData)
 	}
 	ctx = logger.WithLogEventID(ctx, event.ID)
 
@@ -49,7 +50,7 @@ func (s *CalendarServer) CreateEvent(ctx context.Context, req *pb.CreateEventReq
 	err = s.app.CreateEvent(ctx, event)
 	if err != nil {
 		s.logger.ErrorContext(ctx, err.Error())
-		return &emptypb.Empty{}, err
+		return &emptypb.Empty{}, toStatus(err, server.ErrCreateEvent)
 	}
 
 	s.logger.InfoContext(ctx, "event successfully created")
@@ -62,12 +63,12 @@ func (s *CalendarServer) UpdateEvent(ctx context.Context, req *pb.UpdateEventReq
 	event, err := getEventFromBody(ctx, s.logger, req)
 	if err != nil {
 		s.logger.ErrorContext(ctx, err.Error())
-		return &emptypb.Empty{}, server.ErrInvalidEventData
+		return &emptypb.Empty{}, toStatus(err, server.ErrInvalidEventData)
 	}
 	uuID, err := getEventIDFromBody(ctx, s.logger, req)
 	if err != nil {
 		s.logger.ErrorContext(ctx, err.Error())
-		return &emptypb.Empty{}, err
+		return &emptypb.Empty{}, toStatus(err, server.ErrInvalidEventID)
 	}
 	ctx = logger.WithLogEventID(ctx, uuID)
 	event.ID = uuID
@@ -75,7 +76,7 @@ func (s *CalendarServer) UpdateEvent(ctx context.Context, req *pb.UpdateEventReq
 	err = s.app.UpdateEvent(ctx, event.ID, event)
 	if err != nil {
 		s.logger.ErrorContext(ctx, err.Error())
-		return &emptypb.Empty{}, err
+		return &emptypb.Empty{}, toStatus(err, server.ErrUpdateEvent)
 	}
 	s.logger.InfoContext(ctx, "event successfully updated")
 	return &emptypb.Empty{}, nil
@@ -87,7 +88,7 @@ func (s *CalendarServer) DeleteEvent(ctx context.Context, req *pb.DeleteEventReq
 	id, err := getEventIDFromBody(ctx, s.logger, req)
 	if err != nil {
 		s.logger.ErrorContext(ctx, err.Error())
-		return &emptypb.Empty{}, err
+		return &emptypb.Empty{}, toStatus(err, server.ErrInvalidEventID)
 	}
 	ctx = logger.WithLogEventID(ctx, id)
 	s.logger.DebugContext(ctx, "attempting to delete event")
@@ -95,7 +96,7 @@ func (s *CalendarServer) DeleteEvent(ctx context.Context, req *pb.DeleteEventReq
 	err = s.app.DeleteEvent(ctx, id)
 	if err != nil {
 		s.logger.ErrorContext(ctx, err.Error())
-		return &emptypb.Empty{}, err
+		return &emptypb.Empty{}, toStatus(err, server.ErrDeleteEvent)
 	}
 	s.logger.InfoContext(ctx, "event successfully deleted")
 	return &emptypb.Empty{}, nil
@@ -127,20 +128,52 @@ func (s *CalendarServer) getEvents(
 	start, err := getStartTime(ctx, s.logger, req)
 	if err != nil {
 		s.logger.ErrorContext(ctx, err.Error())
-		return nil, err
+		return nil, toStatus(err, server.ErrInvalidStartPeriod)
 	}
 	ctx = logger.WithLogStart(ctx, start)
 
 	events, err := getFunc(ctx, start)
 	if err != nil {
 		s.logger.ErrorContext(ctx, err.Error())
-		return nil, server.ErrEventRetrieval
+		return nil, toStatus(err, server.ErrEventRetrieval)
 	}
 
 	resp := &pb.GetEventsResp{}
 	for _, e := range events {
-		resp.Events = append(resp.Events, convertToEventProto(e))
+		resp.Events = append(resp.Events, EventToProto(e))
 	}
 	s.logger.InfoContext(ctx, "events successfully retrieved")
 	return resp, nil
 }
+
+// WatchEvents streams changes of events via gRPC until the client leaves.
+func (s *CalendarServer) WatchEvents(req *pb.WatchEventsReq, stream grpc.ServerStreamingServer[pb.EventChange]) error {
+	ctx := s.setLogCompMeth(stream.Context(), "WatchEvents")
+
+	filter, err := FilterFromProto(req)
+	if err != nil {
+		s.logger.ErrorContext(ctx, err.Error())
+		return toStatus(err, server.ErrInvalidEventData)
+	}
+
+	changes, err := s.app.WatchEvents(ctx, filter, req.GetResumeToken())
+	if err != nil {
+		s.logger.ErrorContext(ctx, err.Error())
+		return toStatus(err, server.ErrWatchEvents)
+	}
+	s.logger.InfoContext(ctx, "watching event changes")
+
+	for c := range changes {
+		if err := stream.Send(ChangeToProto(c)); err != nil {
+			s.logger.ErrorContext(ctx, err.Error())
+			return err
+		}
+	}
+
+	// Канал закрыт: либо клиент ушёл, либо поток прерван и клиенту нужно переподключиться
+	if err := ctx.Err(); err != nil {
+		return toStatus(err, server.ErrWatchEvents)
+	}
+	s.logger.WarnContext(ctx, server.ErrWatchInterrupted.Error())
+	return toStatus(server.ErrWatchInterrupted, server.ErrWatchEvents)
+}
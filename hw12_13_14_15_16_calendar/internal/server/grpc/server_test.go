@@ -12,10 +12,13 @@ import (
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
 	server "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
 	storage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/watch"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -26,6 +29,7 @@ type mockApp struct {
 	GetEventsDayFn   func(ctx context.Context, start time.Time) ([]storage.Event, error)
 	GetEventsWeekFn  func(ctx context.Context, start time.Time) ([]storage.Event, error)
 	GetEventsMonthFn func(ctx context.Context, start time.Time) ([]storage.Event, error)
+	WatchEventsFn    func(ctx context.Context, filter watch.Filter, token string) (<-chan watch.Change, error)
 }
 
 func (m *mockApp) CreateEvent(ctx context.Context, event storage.Event) error {
@@ -52,14 +56,18 @@ func (m *mockApp) GetEventsMonth(ctx context.Context, start time.Time) ([]storag
 	return m.GetEventsMonthFn(ctx, start)
 }
 
+func (m *mockApp) WatchEvents(ctx context.Context, filter watch.Filter, token string) (<-chan watch.Change, error) {
+	return m.WatchEventsFn(ctx, filter, token)
+}
+
 func newTestServer(t *testing.T, app server.Application) (pb.CalendarClient, func()) {
 	t.Helper()
 
 	lis, err := net.Listen("tcp", "127.0.0.1:0")
 	assert.NoError(t, err)
 
-	s := grpc.NewServer()
 	log := logger.New("info", os.Stdout, false)
+	s := grpc.NewServer(ServerOptions(log, time.Second, nil)...)
 	pb.RegisterCalendarServer(s, NewServerGRPC(log, lis, app))
 
 	// Канал для отслеживания ошибок сервера
@@ -251,3 +259,58 @@ func TestGetEventsMonth(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, resp.Events, 1)
 }
+
+func TestWatchEvents(t *testing.T) {
+	userID := uuid.New()
+	event := storage.Event{
+		ID:     uuid.New(),
+		UserID: userID,
+		Title:  "Test",
+		Start:  time.Now().Add(time.Hour),
+		End:    time.Now().Add(2 * time.Hour),
+	}
+
+	client, shutdown := newTestServer(t, &mockApp{
+		WatchEventsFn: func(_ context.Context, filter watch.Filter, token string) (<-chan watch.Change, error) {
+			assert.Equal(t, userID, filter.UserID)
+			if token == "expired" {
+				return nil, watch.ErrTokenExpired
+			}
+			ch := make(chan watch.Change, 2)
+			ch <- watch.Change{Token: "t-1", Type: watch.Updated, Event: event}
+			ch <- watch.Change{Token: "t-2", Type: watch.Deleted, Event: storage.Event{ID: event.ID}}
+			close(ch)
+			return ch, nil
+		},
+	})
+	defer shutdown()
+
+	stream, err := client.WatchEvents(context.Background(), &pb.WatchEventsReq{UserId: userID.String()})
+	assert.NoError(t, err)
+
+	c, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, pb.EventChange_UPDATED, c.GetType())
+	assert.Equal(t, "t-1", c.GetResumeToken())
+	assert.Equal(t, event.Title, c.GetEvent().GetTitle())
+
+	c, err = stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, pb.EventChange_DELETED, c.GetType())
+	assert.Equal(t, event.ID.String(), c.GetEvent().GetId())
+
+	// Лента закрыта сервером: клиенту нужно переподключиться с последним токеном
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	stream, err = client.WatchEvents(context.Background(),
+		&pb.WatchEventsReq{UserId: userID.String(), ResumeToken: "expired"})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.OutOfRange, status.Code(err))
+
+	stream, err = client.WatchEvents(context.Background(), &pb.WatchEventsReq{UserId: "abc"})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
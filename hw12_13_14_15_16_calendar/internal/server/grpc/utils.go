@@ -13,18 +13,6 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-func convertToEventProto(e storage.Event) *pb.Event {
-	return &pb.Event{
-		Id:          e.ID.String(),
-		UserId:      e.UserID.String(),
-		Title:       e.Title,
-		StartTime:   timestamppb.New(e.Start),
-		EndTime:     timestamppb.New(e.End),
-		Description: e.Description,
-		TimeBefore:  int64(e.TimeBefore.Seconds()),
-	}
-}
-
 func getEventFromBody[T interface{ GetEvent() *pb.Event }](
 	ctx context.Context,
 	log *slog.Logger,
@@ -33,29 +21,11 @@ func getEventFromBody[T interface{ GetEvent() *pb.Event }](
 	ctx = logger.WithLogComponent(ctx, "server.grpc")
 	ctx = logger.WithLogMethod(ctx, "getEventFromBody")
 	log.DebugContext(ctx, "attempting to extract event from request body")
-	eventPB := req.GetEvent()
-	if eventPB == nil {
-		return storage.Event{}, logger.AddPrefix(ctx, server.ErrMissingEvent)
-	}
-	id, err := uuid.Parse(eventPB.Id)
+	event, err := EventFromProto(req.GetEvent())
 	if err != nil {
-		return storage.Event{}, logger.AddPrefix(ctx, server.ErrInvalidEventID)
+		return storage.Event{}, logger.AddPrefix(ctx, err)
 	}
-
-	userID, err := uuid.Parse(eventPB.UserId)
-	if err != nil {
-		return storage.Event{}, logger.AddPrefix(ctx, server.ErrInvalidUserID)
-	}
-
-	return storage.Event{
-		ID:          id,
-		UserID:      userID,
-		Title:       eventPB.Title,
-		Start:       eventPB.StartTime.AsTime(),
-		End:         eventPB.EndTime.AsTime(),
-		Description: eventPB.Description,
-		TimeBefore:  time.Duration(eventPB.TimeBefore * int64(time.Second)),
-	}, nil
+	return event, nil
 }
 
 func getEventIDFromBody[T interface{ GetId() string }](
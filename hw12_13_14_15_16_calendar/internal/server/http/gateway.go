@@ -0,0 +1,44 @@
+package internalhttp
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	pb "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// gateway serves the REST API generated from the HTTP annotations
+// in CalendarService.proto.
+func (s *Server) gateway() http.Handler {
+	gw := runtime.NewServeMux(
+		runtime.WithErrorHandler(
+			func(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler,
+				w http.ResponseWriter, r *http.Request, err error,
+			) {
+				writeProblem(w, r, newProblem(err, server.ErrInternal))
+			},
+		),
+		runtime.WithMiddlewares(s.gatewayRateLimit),
+	)
+	// RegisterCalendarHandlerServer возвращает ошибку только при nil-аргументах
+	_ = pb.RegisterCalendarHandlerServer(context.Background(), gw, s.calendar)
+	return gw
+}
+
+// gatewayRateLimit applies rate limits to gateway routes keyed like
+// "DELETE /v1/events/{id}".
+func (s *Server) gatewayRateLimit(next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		route := r.Method + " /v1/"
+		if pattern, ok := runtime.HTTPPattern(r.Context()); ok {
+			// Шаблон печатается как /v1/events/{id=*}
+			route = r.Method + " " + strings.ReplaceAll(pattern.String(), "=*}", "}")
+		}
+		s.rateLimitMiddleware(route, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next(w, r, pathParams)
+		})).ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,141 @@
+package internalhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGateway_CreateEvent(t *testing.T) {
+	var created storage.Event
+	app := &mockApp{createEvent: func(_ context.Context, event storage.Event) error {
+		created = event
+		return nil
+	}}
+	s := NewServerHTTP("localhost", 8080, logger.New("debug", os.Stdout, false), app, nil)
+
+	id, userID := uuid.New(), uuid.New()
+	body := `{"id":"` + id.String() + `","userId":"` + userID.String() + `","title":"meeting",` +
+		`"startTime":"2030-01-01T10:00:00Z","endTime":"2030-01-01T11:00:00Z","timeBefore":"600"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/events", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	s.Handler().ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, id, created.ID)
+	require.Equal(t, userID, created.UserID)
+	require.Equal(t, "meeting", created.Title)
+	require.Equal(t, 10*time.Minute, created.TimeBefore)
+}
+
+func TestGateway_GetEventsDay(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	event := storage.Event{
+		ID:         uuid.New(),
+		UserID:     uuid.New(),
+		Title:      "meeting",
+		Start:      start.Add(time.Hour),
+		End:        start.Add(2 * time.Hour),
+		TimeBefore: time.Minute,
+	}
+	app := &mockApp{getEventsDay: func(_ context.Context, got time.Time) ([]storage.Event, error) {
+		require.True(t, start.Equal(got))
+		return []storage.Event{event}, nil
+	}}
+	s := NewServerHTTP("localhost", 8080, logger.New("debug", os.Stdout, false), app, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/events/day?start=2030-01-01T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	s.Handler().ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Events []struct {
+			ID        string    `json:"id"`
+			Title     string    `json:"title"`
+			StartTime time.Time `json:"startTime"`
+		} `json:"events"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp.Events, 1)
+	require.Equal(t, event.ID.String(), resp.Events[0].ID)
+	require.Equal(t, "meeting", resp.Events[0].Title)
+	require.True(t, event.Start.Equal(resp.Events[0].StartTime))
+}
+
+func TestGateway_Errors(t *testing.T) {
+	app := &mockApp{
+		deleteEvent: func(context.Context, uuid.UUID) error {
+			return storage.ErrIDNotExist
+		},
+		createEvent: func(context.Context, storage.Event) error {
+			return storage.ErrDateBusy
+		},
+	}
+	s := NewServerHTTP("localhost", 8080, logger.New("debug", os.Stdout, false), app, nil)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		kind   string
+		field  string
+	}{
+		{
+			name:   "not found",
+			method: http.MethodDelete,
+			target: "/v1/events/" + uuid.NewString(),
+			status: http.StatusNotFound,
+			kind:   "event-not-found",
+		},
+		{
+			name:   "invalid id",
+			method: http.MethodDelete,
+			target: "/v1/events/abc",
+			status: http.StatusBadRequest,
+			kind:   "invalid-request",
+			field:  "id",
+		},
+		{
+			name:   "date busy",
+			method: http.MethodPost,
+			target: "/v1/events",
+			body: `{"id":"` + uuid.NewString() + `","userId":"` + uuid.NewString() + `","title":"t",` +
+				`"startTime":"2030-01-01T10:00:00Z","endTime":"2030-01-01T11:00:00Z"}`,
+			status: http.StatusConflict,
+			kind:   "date-busy",
+			field:  "start",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			s.Handler().ServeHTTP(w, req)
+
+			require.Equal(t, tt.status, w.Code)
+			p := decodeProblem(t, w)
+			require.Equal(t, problemTypePrefix+tt.kind, p.Type)
+			require.Equal(t, tt.field, p.InvalidField)
+			require.Equal(t, tt.target, p.Instance)
+		})
+	}
+}
@@ -6,24 +6,48 @@ import (
 	"net/http"
 	"time"
 
+	pb "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/metrics"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
+	grpcserver "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server/grpc"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-func (s *Server) routes() http.Handler {
+// apiRoutes lists the routes described in api/openapi.yaml.
+func (s *Server) apiRoutes() []route {
+	return []route{
+		{method: http.MethodPost, path: "/event", handler: s.CreateEvent, jsonBody: true},
+		{method: http.MethodPut, path: "/event", handler: s.UpdateEvent, jsonBody: true},
+		{method: http.MethodDelete, path: "/event", handler: s.DeleteEvent},
+		{method: http.MethodGet, path: "/event/day", handler: s.GetEventsDay},
+		{method: http.MethodGet, path: "/event/week", handler: s.GetEventsWeek},
+		{method: http.MethodGet, path: "/event/month", handler: s.GetEventsMonth},
+		{method: http.MethodGet, path: "/event/watch", handler: s.WatchEvents},
+	}
+}
+
+func (s *Server) routes() *http.ServeMux {
 	mux := http.NewServeMux()
 
-	mux.Handle("POST /event", s.checkContentTypeMiddleware(http.HandlerFunc(s.CreateEvent)))
-	mux.Handle("PUT /event", s.checkContentTypeMiddleware(http.HandlerFunc(s.UpdateEvent)))
-	mux.Handle("DELETE /event", http.HandlerFunc(s.DeleteEvent))
-	mux.Handle("GET /event/day", http.HandlerFunc(s.GetEventsDay))
-	mux.Handle("GET /event/week", http.HandlerFunc(s.GetEventsWeek))
-	mux.Handle("GET /event/month", http.HandlerFunc(s.GetEventsMonth))
+	for _, rt := range s.apiRoutes() {
+		h := s.validationMiddleware(rt, rt.handler)
+		if rt.jsonBody {
+			h = s.checkContentTypeMiddleware(h)
+		}
+		mux.Handle(rt.Pattern(), s.rateLimitMiddleware(rt.Pattern(), h))
+	}
+
+	mux.Handle("/v1/", s.gateway())
+	mux.Handle("GET /metrics", metrics.Handler())
 
 	return mux
 }
 
+// Обработчики /event сохранены для совместимости: они лишь переводят запрос
+// в формат CalendarService.proto и вызывают ту же реализацию, что и gRPC.
+
 // CreateEvent handles event creation request.
 func (s *Server) CreateEvent(w http.ResponseWriter, r *http.Request) {
 	ctx := s.setLogCompMeth(r.Context(), "CreateEvent")
@@ -31,7 +55,7 @@ func (s *Server) CreateEvent(w http.ResponseWriter, r *http.Request) {
 	event, err := s.getEventFromBody(ctx, r)
 	if err != nil {
 		s.logger.ErrorContext(ctx, err.Error())
-		http.Error(w, server.ErrInvalidEventData.Error(), http.StatusBadRequest)
+		s.checkError(w, r, err, server.ErrInvalidEventData)
 		return
 	}
 
@@ -39,8 +63,9 @@ func (s *Server) CreateEvent(w http.ResponseWriter, r *http.Request) {
 
 	s.logger.DebugContext(ctx, "attempting to create event")
 
-	if err := s.app.CreateEvent(ctx, event); err != nil {
-		s.checkError(w, err, server.ErrCreateEvent)
+	req := &pb.CreateEventReq{Event: grpcserver.EventToProto(event)}
+	if _, err := s.calendar.CreateEvent(ctx, req); err != nil {
+		s.checkError(w, r, err, server.ErrCreateEvent)
 		s.logger.ErrorContext(ctx, err.Error())
 		return
 	}
@@ -53,28 +78,19 @@ func (s *Server) CreateEvent(w http.ResponseWriter, r *http.Request) {
 func (s *Server) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 	ctx := s.setLogCompMeth(r.Context(), "UpdateEvent")
 
-	uuID, err := s.getEventIDFromBody(ctx, r)
-	if err != nil {
-		s.logger.ErrorContext(ctx, err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	ctx = logger.WithLogEventID(ctx, uuID)
-
 	event, err := s.getEventFromBody(ctx, r)
 	if err != nil {
 		s.logger.ErrorContext(ctx, err.Error())
-		http.Error(w, server.ErrInvalidEventData.Error(), http.StatusBadRequest)
+		s.checkError(w, r, err, server.ErrInvalidEventData)
 		return
 	}
-	// Поля ID у события может быть пустым
-	event.ID = uuID
 
 	s.logger.DebugContext(ctx, "attempting to update event")
 
-	if err := s.app.UpdateEvent(ctx, uuID, event); err != nil {
-		s.checkError(w, err, server.ErrUpdateEvent)
+	// ID события берётся из query, поле id в теле может быть пустым
+	req := &pb.UpdateEventReq{Id: r.URL.Query().Get("id"), Event: grpcserver.EventToProto(event)}
+	if _, err := s.calendar.UpdateEvent(ctx, req); err != nil {
+		s.checkError(w, r, err, server.ErrUpdateEvent)
 		s.logger.ErrorContext(ctx, err.Error())
 		return
 	}
@@ -87,19 +103,10 @@ func (s *Server) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 func (s *Server) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	ctx := s.setLogCompMeth(r.Context(), "DeleteEvent")
 
-	uuID, err := s.getEventIDFromBody(ctx, r)
-	if err != nil {
-		s.logger.ErrorContext(ctx, err.Error())
-		http.Error(w, server.ErrInvalidEventData.Error(), http.StatusBadRequest)
-		return
-	}
-
-	ctx = logger.WithLogEventID(ctx, uuID)
-
 	s.logger.DebugContext(ctx, "attempting to delete event")
 
-	if err := s.app.DeleteEvent(ctx, uuID); err != nil {
-		s.checkError(w, err, server.ErrDeleteEvent)
+	if _, err := s.calendar.DeleteEvent(ctx, &pb.DeleteEventReq{Id: r.URL.Query().Get("id")}); err != nil {
+		s.checkError(w, r, err, server.ErrDeleteEvent)
 		s.logger.ErrorContext(ctx, err.Error())
 		return
 	}
@@ -110,24 +117,24 @@ func (s *Server) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 
 // GetEventsDay returns events for a single day.
 func (s *Server) GetEventsDay(w http.ResponseWriter, r *http.Request) {
-	s.handleGetEvents(w, r, "Day", s.app.GetEventsDay)
+	s.handleGetEvents(w, r, "Day", s.calendar.GetEventsDay)
 }
 
 // GetEventsWeek returns events for a week.
 func (s *Server) GetEventsWeek(w http.ResponseWriter, r *http.Request) {
-	s.handleGetEvents(w, r, "Week", s.app.GetEventsWeek)
+	s.handleGetEvents(w, r, "Week", s.calendar.GetEventsWeek)
 }
 
 // GetEventsMonth returns events for a month.
 func (s *Server) GetEventsMonth(w http.ResponseWriter, r *http.Request) {
-	s.handleGetEvents(w, r, "Month", s.app.GetEventsMonth)
+	s.handleGetEvents(w, r, "Month", s.calendar.GetEventsMonth)
 }
 
 func (s *Server) handleGetEvents(
 	w http.ResponseWriter,
 	r *http.Request,
 	period string,
-	getEventsFunc func(ctx context.Context, start time.Time) ([]storage.Event, error),
+	getEventsFunc func(ctx context.Context, req *pb.GetEventsReq) (*pb.GetEventsResp, error),
 ) {
 	ctx := s.setLogCompMeth(r.Context(), "GetEvents"+period)
 
@@ -135,25 +142,32 @@ func (s *Server) handleGetEvents(
 	start, err := time.Parse(time.RFC3339, startStr)
 	if err != nil {
 		s.logger.ErrorContext(ctx, err.Error())
-		http.Error(w, server.ErrInvalidStartPeriod.Error(), http.StatusBadRequest)
+		s.checkError(w, r, server.ErrInvalidStartPeriod, server.ErrInvalidStartPeriod)
 		return
 	}
 
 	s.logger.DebugContext(ctx, "attempting to get events")
 
-	events, err := getEventsFunc(r.Context(), start)
+	resp, err := getEventsFunc(ctx, &pb.GetEventsReq{Start: timestamppb.New(start)})
 	if err != nil {
 		s.logger.ErrorContext(ctx, err.Error())
-		http.Error(w, server.ErrEventRetrieval.Error(), http.StatusInternalServerError)
+		s.checkError(w, r, err, server.ErrEventRetrieval)
 		return
 	}
 
-	eventsDTO := make([]storage.EventDTO, len(events))
-	for i := range events {
-		eventsDTO[i] = storage.ToDTO(events[i])
+	eventsDTO := make([]storage.EventDTO, 0, len(resp.GetEvents()))
+	for _, e := range resp.GetEvents() {
+		event, err := grpcserver.EventFromProto(e)
+		if err != nil {
+			s.logger.ErrorContext(ctx, err.Error())
+			s.checkError(w, r, err, server.ErrEventRetrieval)
+			return
+		}
+		eventsDTO = append(eventsDTO, storage.ToDTO(event))
 	}
 
-	s.logger.InfoContext(ctx, "events successfully retrieved", "count", len(events))
+	s.logger.InfoContext(ctx, "events successfully retrieved", "count", len(eventsDTO))
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(eventsDTO)
 }
@@ -2,12 +2,22 @@ package internalhttp
 
 import (
 	"log/slog"
-	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/metrics"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/ratelimit"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tlsconfig"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Обёртка для записи статуса ответа.
@@ -21,19 +31,21 @@ func (r *statusRecorder) WriteHeader(code int) {
 	r.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap позволяет http.ResponseController добраться до исходного writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		ip, _, err := net.SplitHostPort(r.RemoteAddr)
-		if err != nil {
-			ip = r.RemoteAddr
-		}
+		ip := s.proxies.clientIP(r)
 
 		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
 		next.ServeHTTP(recorder, r)
 
-		s.logger.Info("http request finished",
+		s.logger.InfoContext(r.Context(), "http request finished",
 			slog.String("ip", ip),
 			slog.String("method", r.Method),
 			slog.String("path", r.URL.RequestURI()),
@@ -45,13 +57,106 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// requestIDMiddleware assigns a request ID, echoes it in the response and stores it in the logging context.
+func (s *Server) requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := server.RequestID(r.Header.Get(server.RequestIDHeader))
+		w.Header().Set(server.RequestIDHeader, id)
+
+		ctx := logger.WithLogRequestID(r.Context(), id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// clientMiddleware stores the identity of a client authenticated by a TLS certificate in the logging context.
+func (s *Server) clientMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if client := tlsconfig.Identity(r.TLS); client != "" {
+			r = r.WithContext(logger.WithLogClient(r.Context(), client))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tracingMiddleware starts a server span continuing the trace of the caller, if any.
+func (s *Server) tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			))
+		defer span.End()
+
+		r = r.WithContext(ctx)
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+			span.SetAttributes(attribute.String("http.route", r.Pattern))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", recorder.statusCode))
+		if recorder.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.statusCode))
+		}
+	})
+}
+
+// metricsMiddleware must wrap the mux directly: the route is known only after the mux matched the request.
+func (s *Server) metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// Используем шаблон маршрута, а не путь, чтобы не плодить метки
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(recorder.statusCode)).Inc()
+		metrics.HTTPDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// rateLimitMiddleware rejects requests of a client exceeding the limit of route.
+func (s *Server) rateLimitMiddleware(route string, next http.Handler) http.Handler {
+	if s.limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, delay := s.limiter.Allow(route, s.clientKey(r))
+		if !ok {
+			ctx := s.setLogCompMeth(r.Context(), "rateLimitMiddleware")
+			s.logger.WarnContext(ctx, server.ErrRateLimited.Error(), "route", route, "retry_after", delay)
+			metrics.RateLimited.WithLabelValues("http", route).Inc()
+			w.Header().Set("Retry-After", strconv.Itoa(ratelimit.RetryAfter(delay)))
+			s.checkError(w, r, server.ErrRateLimited, server.ErrRateLimited)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey identifies the client the limits are applied to: by its certificate
+// when it is authenticated, otherwise by its address.
+func (s *Server) clientKey(r *http.Request) string {
+	if client := logger.ClientFromContext(r.Context()); client != "" {
+		return client
+	}
+	return s.proxies.clientIP(r)
+}
+
 func (s *Server) checkContentTypeMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		const requiredContentType = "application/json"
 		contentType := r.Header.Get("Content-Type")
 		if !strings.HasPrefix(contentType, requiredContentType) {
 			s.logger.Error(server.ErrInvalidContentType.Error(), "receivedContentType", contentType)
-			http.Error(w, server.ErrInvalidContentType.Error(), http.StatusBadRequest)
+			s.checkError(w, r, server.ErrInvalidContentType, server.ErrInvalidContentType)
 			return
 		}
 		s.logger.Debug("valid Content-Type", "Content-Type", contentType)
@@ -0,0 +1,114 @@
+package internalhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	pb "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/google/uuid"
+)
+
+// route binds an operation of api/openapi.yaml to its handler.
+type route struct {
+	method  string
+	path    string
+	handler http.HandlerFunc
+	// jsonBody marks operations accepting a JSON request body.
+	jsonBody bool
+}
+
+// Pattern returns the http.ServeMux pattern of the route.
+func (rt route) Pattern() string {
+	return rt.method + " " + rt.path
+}
+
+// loadOpenAPI parses and validates the embedded OpenAPI document.
+func loadOpenAPI() (*openapi3.T, error) {
+	// Формат uuid по умолчанию не проверяется
+	openapi3.DefineStringFormatCallback("uuid", func(s string) error {
+		_, err := uuid.Parse(s)
+		return err
+	})
+
+	doc, err := openapi3.NewLoader().LoadFromData(pb.OpenAPI)
+	if err != nil {
+		return nil, fmt.Errorf("load OpenAPI document: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("validate OpenAPI document: %w", err)
+	}
+	return doc, nil
+}
+
+// validationMiddleware rejects requests that do not match the operation of rt in the OpenAPI document.
+func (s *Server) validationMiddleware(rt route, next http.Handler) http.Handler {
+	pathItem := s.spec.Paths.Find(rt.path)
+	if pathItem == nil || pathItem.GetOperation(rt.method) == nil {
+		s.logger.Warn("route is not described in OpenAPI document", "route", rt.Pattern())
+		return next
+	}
+	specRoute := &routers.Route{
+		Spec:      s.spec,
+		Path:      rt.path,
+		PathItem:  pathItem,
+		Method:    rt.method,
+		Operation: pathItem.GetOperation(rt.method),
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := s.setLogCompMeth(r.Context(), "validationMiddleware")
+		err := openapi3filter.ValidateRequest(ctx, &openapi3filter.RequestValidationInput{
+			Request: r,
+			Route:   specRoute,
+		})
+		if err != nil {
+			s.logger.ErrorContext(ctx, "request does not match OpenAPI document", "error", err)
+			s.checkError(w, r, validationError(err), server.ErrInvalidEventData)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validationError converts a validation failure into the error the handlers
+// would have returned for the same request.
+func validationError(err error) error {
+	var reqErr *openapi3filter.RequestError
+	if !errors.As(err, &reqErr) {
+		return fmt.Errorf("%w: %w", server.ErrInvalidEventData, err)
+	}
+
+	if p := reqErr.Parameter; p != nil {
+		switch p.Name {
+		case "id":
+			if errors.Is(reqErr.Err, openapi3filter.ErrInvalidRequired) {
+				return server.ErrMissingEventID
+			}
+			return server.ErrInvalidEventID
+		case "start":
+			return server.ErrInvalidStartPeriod
+		case "userId":
+			return server.ErrInvalidUserID
+		case "from", "to":
+			return server.ErrInvalidPeriod
+		}
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if reqErr.RequestBody != nil && errors.As(reqErr.Err, &schemaErr) {
+		return &storage.ErrInvalidEvent{
+			Field:   strings.Join(schemaErr.JSONPointer(), "."),
+			Message: schemaErr.Reason,
+		}
+	}
+
+	return fmt.Errorf("%w: %w", server.ErrInvalidEventData, err)
+}
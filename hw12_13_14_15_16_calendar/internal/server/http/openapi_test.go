@@ -0,0 +1,175 @@
+package internalhttp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api/httpclient"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	serverpkg "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	spec, err := loadOpenAPI()
+	require.NoError(t, err)
+
+	var documented []string
+	for path, item := range spec.Paths.Map() {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+path)
+		}
+	}
+
+	s := NewServerHTTP("localhost", 8080, logger.New("info", os.Stdout, false), &mockApp{}, nil)
+	var served []string
+	for _, rt := range s.apiRoutes() {
+		served = append(served, rt.Pattern())
+	}
+
+	sort.Strings(documented)
+	sort.Strings(served)
+	require.Equal(t, documented, served)
+}
+
+func TestValidation(t *testing.T) {
+	s := NewServerHTTP("localhost", 8080, logger.New("info", os.Stdout, false), &mockApp{}, nil)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		detail string
+		field  string
+	}{
+		{
+			name:   "missing title",
+			method: http.MethodPost,
+			target: "/event",
+			body:   `{"id":"` + uuid.NewString() + `","start":"2030-01-01T10:00:00Z","end":"2030-01-01T11:00:00Z","userId":"` + uuid.NewString() + `"}`,
+			detail: `property "title" is missing`,
+			field:  "title",
+		},
+		{
+			name:   "negative timeBefore",
+			method: http.MethodPost,
+			target: "/event",
+			body: `{"title":"t","start":"2030-01-01T10:00:00Z","end":"2030-01-01T11:00:00Z","userId":"` +
+				uuid.NewString() + `","timeBefore":-1}`,
+			field: "timeBefore",
+		},
+		{
+			name:   "bad user id",
+			method: http.MethodPut,
+			target: "/event?id=" + uuid.NewString(),
+			body:   `{"title":"t","start":"2030-01-01T10:00:00Z","end":"2030-01-01T11:00:00Z","userId":"42"}`,
+			field:  "userId",
+		},
+		{
+			name:   "missing id",
+			method: http.MethodDelete,
+			target: "/event",
+			detail: serverpkg.ErrMissingEventID.Error(),
+			field:  "id",
+		},
+		{
+			name:   "bad start",
+			method: http.MethodGet,
+			target: "/event/week?start=yesterday",
+			detail: serverpkg.ErrInvalidStartPeriod.Error(),
+			field:  "start",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusBadRequest, w.Code)
+			p := decodeProblem(t, w)
+			require.Equal(t, tt.field, p.InvalidField)
+			if tt.detail != "" {
+				require.Equal(t, tt.detail, p.Detail)
+			}
+		})
+	}
+}
+
+func TestGeneratedClient(t *testing.T) {
+	ev := storage.Event{
+		ID:          uuid.New(),
+		Title:       "meeting",
+		Description: "weekly",
+		UserID:      uuid.New(),
+		Start:       time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC),
+		End:         time.Date(2030, 1, 1, 11, 0, 0, 0, time.UTC),
+		TimeBefore:  15 * time.Minute,
+	}
+
+	var created storage.Event
+	app := &mockApp{
+		createEvent: func(_ context.Context, e storage.Event) error {
+			created = e
+			return nil
+		},
+		deleteEvent: func(context.Context, uuid.UUID) error {
+			return storage.ErrIDNotExist
+		},
+		getEventsWeek: func(context.Context, time.Time) ([]storage.Event, error) {
+			return []storage.Event{ev}, nil
+		},
+	}
+	s := NewServerHTTP("localhost", 8080, logger.New("info", os.Stdout, false), app, nil)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	client, err := httpclient.NewClientWithResponses(ts.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	timeBefore := int64(ev.TimeBefore.Seconds())
+	createResp, err := client.CreateEventWithResponse(ctx, httpclient.Event{
+		Id:          &ev.ID,
+		Title:       ev.Title,
+		Description: &ev.Description,
+		UserId:      ev.UserID,
+		Start:       ev.Start,
+		End:         ev.End,
+		TimeBefore:  &timeBefore,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, createResp.StatusCode())
+	require.Equal(t, ev, created)
+
+	weekResp, err := client.GetEventsWeekWithResponse(ctx, &httpclient.GetEventsWeekParams{Start: ev.Start})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, weekResp.StatusCode())
+	require.NotNil(t, weekResp.JSON200)
+	require.Len(t, *weekResp.JSON200, 1)
+	require.Equal(t, ev.Title, (*weekResp.JSON200)[0].Title)
+
+	deleteResp, err := client.DeleteEventWithResponse(ctx, &httpclient.DeleteEventParams{Id: ev.ID})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, deleteResp.StatusCode())
+	require.NotNil(t, deleteResp.ApplicationproblemJSON404)
+	require.Equal(t, storage.ErrIDNotExist.Error(), *deleteResp.ApplicationproblemJSON404.Detail)
+
+	badResp, err := client.CreateEventWithBodyWithResponse(ctx, "application/json", bytes.NewBufferString("{"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, badResp.StatusCode())
+}
@@ -0,0 +1,183 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
+	grpcserver "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server/grpc"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/watch"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ProblemContentType is the media type of error responses (RFC 7807).
+const ProblemContentType = "application/problem+json"
+
+const problemTypePrefix = "urn:calendar:problem:"
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// InvalidField names the request field that caused the error.
+	InvalidField string `json:"invalidField,omitempty"`
+	RequestID    string `json:"requestId,omitempty"`
+}
+
+// problemKind describes how an error is reported to the client.
+type problemKind struct {
+	err    error
+	reason string // причина в google.rpc.ErrorInfo от gRPC-слоя
+	kind   string
+	title  string
+	status int
+	field  string
+}
+
+var problemKinds = []problemKind{
+	{
+		storage.ErrIDRepeated, grpcserver.ReasonIDRepeated,
+		"id-repeated", "Event ID already exists", http.StatusConflict, "id",
+	},
+	{
+		storage.ErrIDNotExist, grpcserver.ReasonEventNotFound,
+		"event-not-found", "Event not found", http.StatusNotFound, "",
+	},
+	{
+		storage.ErrDateBusy, grpcserver.ReasonDateBusy,
+		"date-busy", "Time is already occupied", http.StatusConflict, "start",
+	},
+	{
+		watch.ErrTokenExpired, grpcserver.ReasonTokenExpired,
+		"resume-token-expired", "Resume token expired", http.StatusGone, "resumeToken",
+	},
+	{watch.ErrInvalidToken, "", "invalid-request", "Invalid request", http.StatusBadRequest, "resumeToken"},
+	{server.ErrInvalidUserID, "", "invalid-request", "Invalid request", http.StatusBadRequest, "userId"},
+	{server.ErrInvalidPeriod, "", "invalid-request", "Invalid request", http.StatusBadRequest, "to"},
+	{server.ErrRateLimited, grpcserver.ReasonRateLimited, "rate-limited", "Too many requests", http.StatusTooManyRequests, ""},
+	{server.ErrInvalidContentType, "", "invalid-request", "Invalid request", http.StatusBadRequest, ""},
+	{server.ErrInvalidEventData, "", "invalid-request", "Invalid request", http.StatusBadRequest, ""},
+	{server.ErrMissingEventID, "", "invalid-request", "Invalid request", http.StatusBadRequest, "id"},
+	{server.ErrInvalidEventID, "", "invalid-request", "Invalid request", http.StatusBadRequest, "id"},
+	{server.ErrInvalidStartPeriod, "", "invalid-request", "Invalid request", http.StatusBadRequest, "start"},
+}
+
+// newProblem maps an error to problem details; unknown errors are reported
+// as internal with the message of internalServerError.
+func newProblem(err error, internalServerError error) Problem {
+	if st, ok := status.FromError(err); ok && st.Code() != codes.OK {
+		return statusProblem(st)
+	}
+
+	var ve *storage.ErrInvalidEvent
+	if errors.As(err, &ve) {
+		return Problem{
+			Type:         problemTypePrefix + "invalid-event",
+			Title:        "Invalid event",
+			Status:       http.StatusBadRequest,
+			Detail:       ve.Message,
+			InvalidField: ve.Field,
+		}
+	}
+
+	for _, k := range problemKinds {
+		if errors.Is(err, k.err) {
+			return Problem{
+				Type:         problemTypePrefix + k.kind,
+				Title:        k.title,
+				Status:       k.status,
+				Detail:       k.err.Error(),
+				InvalidField: k.field,
+			}
+		}
+	}
+
+	return Problem{
+		Type:   problemTypePrefix + "internal",
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+		Detail: internalServerError.Error(),
+	}
+}
+
+// statusProblem maps a gRPC status returned by the calendar service
+// to problem details, using the ErrorInfo reason when it is present.
+func statusProblem(st *status.Status) Problem {
+	var (
+		reason    string
+		violation *errdetails.BadRequest_FieldViolation
+	)
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			reason = d.GetReason()
+		case *errdetails.BadRequest:
+			if v := d.GetFieldViolations(); len(v) > 0 {
+				violation = v[0]
+			}
+		}
+	}
+
+	switch reason {
+	case grpcserver.ReasonInvalidEvent:
+		return Problem{
+			Type:         problemTypePrefix + "invalid-event",
+			Title:        "Invalid event",
+			Status:       http.StatusBadRequest,
+			Detail:       violation.GetDescription(),
+			InvalidField: violation.GetField(),
+		}
+	case grpcserver.ReasonInvalidInput:
+		return Problem{
+			Type:         problemTypePrefix + "invalid-request",
+			Title:        "Invalid request",
+			Status:       http.StatusBadRequest,
+			Detail:       st.Message(),
+			InvalidField: violation.GetField(),
+		}
+	}
+
+	for _, k := range problemKinds {
+		if reason != "" && k.reason == reason {
+			return Problem{
+				Type:         problemTypePrefix + k.kind,
+				Title:        k.title,
+				Status:       k.status,
+				Detail:       st.Message(),
+				InvalidField: k.field,
+			}
+		}
+	}
+
+	code := runtime.HTTPStatusFromCode(st.Code())
+	kind := "about:blank"
+	if code == http.StatusInternalServerError {
+		kind = problemTypePrefix + "internal"
+	}
+	return Problem{
+		Type:   kind,
+		Title:  http.StatusText(code),
+		Status: code,
+		Detail: st.Message(),
+	}
+}
+
+// writeProblem replies with an application/problem+json body.
+func writeProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	p.Instance = r.URL.Path
+	p.RequestID = logger.RequestIDFromContext(r.Context())
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
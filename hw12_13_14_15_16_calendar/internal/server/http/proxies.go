@@ -0,0 +1,93 @@
+package internalhttp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies lists reverse proxies, e.g. the ingress controller, whose
+// X-Forwarded-For and X-Real-IP headers identify the client.
+type TrustedProxies []netip.Prefix
+
+// ParseTrustedProxies parses IP addresses and CIDR ranges.
+func ParseTrustedProxies(values []string) (TrustedProxies, error) {
+	res := make(TrustedProxies, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if strings.Contains(v, "/") {
+			prefix, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", v, err)
+			}
+			res = append(res, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", v, err)
+		}
+		res = append(res, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return res, nil
+}
+
+func (p TrustedProxies) contains(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address of the client. Forwarding headers are used only
+// when the request comes from a trusted proxy, otherwise any client could spoof them.
+func (p TrustedProxies) clientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !p.contains(remote) {
+		return remote
+	}
+
+	// Справа налево: первый адрес не из доверенных прокси добавлен последним прокси перед нами
+	if forwarded := forwardedFor(r); len(forwarded) > 0 {
+		for i := len(forwarded) - 1; i >= 0; i-- {
+			if !p.contains(forwarded[i]) {
+				return forwarded[i]
+			}
+		}
+		return forwarded[0]
+	}
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); isIP(ip) {
+		return ip
+	}
+	return remote
+}
+
+// forwardedFor returns valid addresses from all X-Forwarded-For headers in order.
+func forwardedFor(r *http.Request) []string {
+	var res []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		for _, ip := range strings.Split(h, ",") {
+			if ip = strings.TrimSpace(ip); isIP(ip) {
+				res = append(res, ip)
+			}
+		}
+	}
+	return res
+}
+
+func isIP(s string) bool {
+	_, err := netip.ParseAddr(s)
+	return err == nil
+}
@@ -0,0 +1,91 @@
+package internalhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.244.0.0/16", " 192.168.1.10 ", "::1"})
+	require.NoError(t, err)
+	require.Len(t, proxies, 3)
+	assert.True(t, proxies.contains("10.244.3.7"))
+	assert.True(t, proxies.contains("192.168.1.10"))
+	assert.True(t, proxies.contains("::1"))
+	assert.True(t, proxies.contains("::ffff:10.244.0.1"))
+	assert.False(t, proxies.contains("192.168.1.11"))
+	assert.False(t, proxies.contains("not-an-ip"))
+
+	_, err = ParseTrustedProxies([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+	_, err = ParseTrustedProxies([]string{"ingress"})
+	require.Error(t, err)
+}
+
+func TestTrustedProxies_ClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.244.0.0/16"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string][]string
+		want       string
+	}{
+		{
+			name:       "direct client",
+			remoteAddr: "203.0.113.5:1234",
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "untrusted peer cannot spoof headers",
+			remoteAddr: "203.0.113.5:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}, "X-Real-Ip": {"198.51.100.2"}},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "client behind ingress",
+			remoteAddr: "10.244.0.12:5678",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "spoofed entries left of the last untrusted address are ignored",
+			remoteAddr: "10.244.0.12:5678",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.1.1.1, 198.51.100.1", "10.244.1.1"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "real ip header",
+			remoteAddr: "10.244.0.12:5678",
+			headers:    map[string][]string{"X-Real-Ip": {"198.51.100.2"}},
+			want:       "198.51.100.2",
+		},
+		{
+			name:       "invalid headers",
+			remoteAddr: "10.244.0.12:5678",
+			headers:    map[string][]string{"X-Forwarded-For": {"unknown"}, "X-Real-Ip": {"-"}},
+			want:       "10.244.0.12",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header[k] = v
+			}
+			assert.Equal(t, tt.want, proxies.clientIP(req))
+		})
+	}
+
+	// Без доверенных прокси заголовки не учитываются
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.244.0.12:5678"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	assert.Equal(t, "10.244.0.12", TrustedProxies(nil).clientIP(req))
+}
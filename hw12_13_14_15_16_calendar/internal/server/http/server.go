@@ -2,21 +2,33 @@ package internalhttp
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
 
+	pb "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/ratelimit"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
+	grpcserver "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server/grpc"
+	"github.com/getkin/kin-openapi/openapi3"
 )
 
 // Server provides HTTP access to the calendar application.
 type Server struct {
 	logger     *slog.Logger
 	app        server.Application
+	calendar   pb.CalendarServer // общая с gRPC реализация CalendarService.proto
 	httpServer *http.Server
-	handler    http.Handler
+	mux        *http.ServeMux
+	// root serves handlers registered by Handle and passes other requests to the middleware chain.
+	root    *http.ServeMux
+	handler http.Handler
+	spec    *openapi3.T
+	limiter *ratelimit.Limiter
+	proxies TrustedProxies
 }
 
 func (s *Server) setLogCompMeth(ctx context.Context, method string) context.Context {
@@ -24,37 +36,80 @@ func (s *Server) setLogCompMeth(ctx context.Context, method string) context.Cont
 	return logger.WithLogMethod(ctx, method)
 }
 
+// Handle registers an additional handler outside the server middleware, e.g. health probes:
+// its requests are not logged, traced, counted in metrics or rate limited.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.root.Handle(pattern, handler)
+}
+
 // Handler returns http.Handler used by the server.
 func (s *Server) Handler() http.Handler {
 	return s.handler
 }
 
-// NewServerHTTP creates and configures a new HTTP server.
-func NewServerHTTP(host string, port int, logger *slog.Logger, app server.Application) *Server {
+// NewServerHTTP creates and configures a new HTTP server; a nil limiter disables rate limits.
+// It panics if the embedded OpenAPI document is invalid.
+func NewServerHTTP(
+	host string,
+	port int,
+	logger *slog.Logger,
+	app server.Application,
+	limiter *ratelimit.Limiter,
+) *Server {
+	spec, err := loadOpenAPI()
+	if err != nil {
+		panic(err)
+	}
 	s := &Server{
-		logger: logger,
-		app:    app,
+		logger:   logger,
+		app:      app,
+		calendar: grpcserver.NewServerGRPC(logger, nil, app),
+		spec:     spec,
+		limiter:  limiter,
 	}
 
 	mux := s.routes()
+	s.mux = mux
 
-	wrapped := s.loggingMiddleware(mux)
+	wrapped := s.tracingMiddleware(s.requestIDMiddleware(s.clientMiddleware(
+		s.loggingMiddleware(s.metricsMiddleware(mux)))))
+
+	// Более конкретные шаблоны из Handle обслуживаются в обход middleware
+	s.root = http.NewServeMux()
+	s.root.Handle("/", wrapped)
 
 	httpServer := &http.Server{
 		Addr:              fmt.Sprintf("%s:%d", host, port),
-		Handler:           wrapped,
+		Handler:           s.root,
 		ReadHeaderTimeout: 5 * time.Second,
 		WriteTimeout:      10 * time.Second,
 		IdleTimeout:       120 * time.Second,
 	}
 	s.httpServer = httpServer
-	s.handler = wrapped
+	s.handler = s.root
 	return s
 }
 
+// SetTLSConfig makes the server accept TLS connections only; cfg must provide the certificate.
+func (s *Server) SetTLSConfig(cfg *tls.Config) {
+	s.httpServer.TLSConfig = cfg
+}
+
+// SetTrustedProxies makes the server take the client address from forwarding headers
+// of requests coming from the proxies.
+func (s *Server) SetTrustedProxies(proxies TrustedProxies) {
+	s.proxies = proxies
+}
+
 // Start runs the HTTP server.
 func (s *Server) Start() error {
-	err := s.httpServer.ListenAndServe()
+	var err error
+	if s.httpServer.TLSConfig != nil {
+		// Сертификат берётся из TLSConfig, чтобы его можно было заменить без перезапуска
+		err = s.httpServer.ListenAndServeTLS("", "")
+	} else {
+		err = s.httpServer.ListenAndServe()
+	}
 	if err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("server run error: %w", err)
 	}
@@ -3,6 +3,9 @@ package internalhttp
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -12,10 +15,15 @@ import (
 	"time"
 
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/ratelimit"
 	serverpkg "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/watch"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type mockApp struct {
@@ -25,6 +33,7 @@ type mockApp struct {
 	getEventsDay   func(ctx context.Context, start time.Time) ([]storage.Event, error)
 	getEventsWeek  func(ctx context.Context, start time.Time) ([]storage.Event, error)
 	getEventsMonth func(ctx context.Context, start time.Time) ([]storage.Event, error)
+	watchEvents    func(ctx context.Context, filter watch.Filter, token string) (<-chan watch.Change, error)
 }
 
 func (m *mockApp) CreateEvent(ctx context.Context, event storage.Event) error {
@@ -51,6 +60,10 @@ func (m *mockApp) GetEventsMonth(ctx context.Context, start time.Time) ([]storag
 	return m.getEventsMonth(ctx, start)
 }
 
+func (m *mockApp) WatchEvents(ctx context.Context, filter watch.Filter, token string) (<-chan watch.Change, error) {
+	return m.watchEvents(ctx, filter, token)
+}
+
 func TestCreateEvent(t *testing.T) {
 	app := &mockApp{
 		createEvent: func(ctx context.Context, event storage.Event) error {
@@ -61,7 +74,7 @@ func TestCreateEvent(t *testing.T) {
 	}
 
 	logger := logger.New("info", os.Stdout, false)
-	server := NewServerHTTP("localhost", 8080, logger, app)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
 
 	event := storage.Event{
 		ID:          uuid.New(),
@@ -73,7 +86,7 @@ func TestCreateEvent(t *testing.T) {
 		TimeBefore:  10 * time.Minute,
 	}
 
-	body, _ := json.Marshal(event)
+	body, _ := json.Marshal(storage.ToDTO(event))
 	req := httptest.NewRequest(http.MethodPost, "/event", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
@@ -94,7 +107,7 @@ func TestUpdateEvent(t *testing.T) {
 	}
 
 	logger := logger.New("info", os.Stdout, false)
-	server := NewServerHTTP("localhost", 8080, logger, app)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
 
 	event := storage.Event{
 		Title:       "updated event",
@@ -105,7 +118,7 @@ func TestUpdateEvent(t *testing.T) {
 		TimeBefore:  5 * time.Minute,
 	}
 
-	body, _ := json.Marshal(event)
+	body, _ := json.Marshal(storage.ToDTO(event))
 	req := httptest.NewRequest(http.MethodPut, "/event?id="+eventID.String(), bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
@@ -125,7 +138,7 @@ func TestDeleteEvent(t *testing.T) {
 	}
 
 	logger := logger.New("info", os.Stdout, false)
-	server := NewServerHTTP("localhost", 8080, logger, app)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
 	req := httptest.NewRequest(http.MethodDelete, "/event?id="+eventID.String(), nil)
 	w := httptest.NewRecorder()
 	server.Handler().ServeHTTP(w, req)
@@ -144,7 +157,7 @@ func TestGetEventsDay(t *testing.T) {
 	}
 
 	logger := logger.New("info", os.Stdout, false)
-	server := NewServerHTTP("localhost", 8080, logger, app)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
 	req := httptest.NewRequest(http.MethodGet, "/event/day?start=2025-01-01T00:00:00Z", nil)
 	w := httptest.NewRecorder()
 	server.Handler().ServeHTTP(w, req)
@@ -163,7 +176,7 @@ func TestGetEventsWeek(t *testing.T) {
 	}
 
 	logger := logger.New("info", os.Stdout, false)
-	server := NewServerHTTP("localhost", 8080, logger, app)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
 	req := httptest.NewRequest(http.MethodGet, "/event/week?start=2025-01-01T00:00:00Z", nil)
 	w := httptest.NewRecorder()
 	server.Handler().ServeHTTP(w, req)
@@ -182,7 +195,7 @@ func TestGetEventsMonth(t *testing.T) {
 	}
 
 	logger := logger.New("info", os.Stdout, false)
-	server := NewServerHTTP("localhost", 8080, logger, app)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
 	req := httptest.NewRequest(http.MethodGet, "/event/month?start=2025-01-01T00:00:00Z", nil)
 	w := httptest.NewRecorder()
 
@@ -194,7 +207,7 @@ func TestGetEventsMonth(t *testing.T) {
 func TestCreateEvent_BadJSON(t *testing.T) {
 	app := &mockApp{}
 	logger := logger.New("info", os.Stdout, false)
-	server := NewServerHTTP("localhost", 8080, logger, app)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/event", bytes.NewBufferString("{"))
 	req.Header.Set("Content-Type", "application/json")
@@ -203,7 +216,8 @@ func TestCreateEvent_BadJSON(t *testing.T) {
 	server.Handler().ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
-	assert.Equal(t, serverpkg.ErrInvalidEventData.Error()+"\n", w.Body.String())
+	p := decodeProblem(t, w)
+	assert.Equal(t, serverpkg.ErrInvalidEventData.Error(), p.Detail)
 }
 
 func TestCreateEvent_StorageError(t *testing.T) {
@@ -213,7 +227,7 @@ func TestCreateEvent_StorageError(t *testing.T) {
 		return storage.ErrIDRepeated
 	}}
 	logger := logger.New("info", os.Stdout, false)
-	server := NewServerHTTP("localhost", 8080, logger, app)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
 
 	event := storage.Event{
 		ID:         uuid.New(),
@@ -223,7 +237,7 @@ func TestCreateEvent_StorageError(t *testing.T) {
 		End:        time.Now().Add(2 * time.Hour),
 		TimeBefore: time.Minute,
 	}
-	body, _ := json.Marshal(event)
+	body, _ := json.Marshal(storage.ToDTO(event))
 	req := httptest.NewRequest(http.MethodPost, "/event", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
@@ -231,19 +245,21 @@ func TestCreateEvent_StorageError(t *testing.T) {
 	server.Handler().ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusConflict, w.Result().StatusCode)
-	assert.Equal(t, storage.ErrIDRepeated.Error()+"\n", w.Body.String())
+	p := decodeProblem(t, w)
+	assert.Equal(t, storage.ErrIDRepeated.Error(), p.Detail)
+	assert.Equal(t, "urn:calendar:problem:id-repeated", p.Type)
 }
 
 func TestUpdateEvent_InvalidID(t *testing.T) {
 	app := &mockApp{}
 	logger := logger.New("info", os.Stdout, false)
-	server := NewServerHTTP("localhost", 8080, logger, app)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
 
 	event := storage.Event{
 		Title: "title", UserID: uuid.New(), Start: time.Now().Add(time.Hour),
 		End: time.Now().Add(2 * time.Hour),
 	}
-	body, _ := json.Marshal(event)
+	body, _ := json.Marshal(storage.ToDTO(event))
 	req := httptest.NewRequest(http.MethodPut, "/event?id=bad", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
@@ -251,7 +267,9 @@ func TestUpdateEvent_InvalidID(t *testing.T) {
 	server.Handler().ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
-	assert.Equal(t, "server.http.getEventIDFromBody: "+serverpkg.ErrInvalidEventID.Error()+"\n", w.Body.String())
+	p := decodeProblem(t, w)
+	assert.Equal(t, serverpkg.ErrInvalidEventID.Error(), p.Detail)
+	assert.Equal(t, "id", p.InvalidField)
 }
 
 func TestDeleteEvent_NotFound(t *testing.T) {
@@ -262,7 +280,7 @@ func TestDeleteEvent_NotFound(t *testing.T) {
 		return storage.ErrIDNotExist
 	}}
 	logger := logger.New("info", os.Stdout, false)
-	server := NewServerHTTP("localhost", 8080, logger, app)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
 
 	req := httptest.NewRequest(http.MethodDelete, "/event?id="+eventID.String(), nil)
 	w := httptest.NewRecorder()
@@ -270,13 +288,15 @@ func TestDeleteEvent_NotFound(t *testing.T) {
 	server.Handler().ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
-	assert.Equal(t, storage.ErrIDNotExist.Error()+"\n", w.Body.String())
+	p := decodeProblem(t, w)
+	assert.Equal(t, storage.ErrIDNotExist.Error(), p.Detail)
+	assert.Equal(t, http.StatusNotFound, p.Status)
 }
 
 func TestGetEventsDay_InvalidStart(t *testing.T) {
 	app := &mockApp{}
 	logger := logger.New("info", os.Stdout, false)
-	server := NewServerHTTP("localhost", 8080, logger, app)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/event/day?start=bad", nil)
 	w := httptest.NewRecorder()
@@ -284,7 +304,9 @@ func TestGetEventsDay_InvalidStart(t *testing.T) {
 	server.Handler().ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
-	assert.Equal(t, serverpkg.ErrInvalidStartPeriod.Error()+"\n", w.Body.String())
+	p := decodeProblem(t, w)
+	assert.Equal(t, serverpkg.ErrInvalidStartPeriod.Error(), p.Detail)
+	assert.Equal(t, "start", p.InvalidField)
 }
 
 func TestGetEventsDay_AppError(t *testing.T) {
@@ -294,7 +316,7 @@ func TestGetEventsDay_AppError(t *testing.T) {
 		return nil, errors.New("boom")
 	}}
 	logger := logger.New("info", os.Stdout, false)
-	server := NewServerHTTP("localhost", 8080, logger, app)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/event/day?start=2025-01-01T00:00:00Z", nil)
 	w := httptest.NewRecorder()
@@ -302,7 +324,9 @@ func TestGetEventsDay_AppError(t *testing.T) {
 	server.Handler().ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
-	assert.Equal(t, serverpkg.ErrEventRetrieval.Error()+"\n", w.Body.String())
+	p := decodeProblem(t, w)
+	assert.Equal(t, serverpkg.ErrEventRetrieval.Error(), p.Detail)
+	assert.Equal(t, "/event/day", p.Instance)
 }
 
 func TestGetEventsDay_Response(t *testing.T) {
@@ -313,7 +337,7 @@ func TestGetEventsDay_Response(t *testing.T) {
 		return []storage.Event{ev}, nil
 	}}
 	logger := logger.New("info", os.Stdout, false)
-	server := NewServerHTTP("localhost", 8080, logger, app)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/event/day?start=2025-01-01T00:00:00Z", nil)
 	w := httptest.NewRecorder()
@@ -329,3 +353,222 @@ func TestGetEventsDay_Response(t *testing.T) {
 	assert.Equal(t, ev.ID, resp[0].ID)
 	assert.Equal(t, ev.Title, resp[0].Title)
 }
+
+func TestMetrics(t *testing.T) {
+	app := &mockApp{
+		getEventsDay: func(context.Context, time.Time) ([]storage.Event, error) {
+			return nil, nil
+		},
+	}
+
+	logger := logger.New("info", os.Stdout, false)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/event/day?start=2025-01-01T00:00:00Z", nil)
+	server.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Contains(t, w.Body.String(),
+		`calendar_http_requests_total{code="200",method="GET",route="GET /event/day"}`)
+}
+
+func TestTracingPropagation(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var got trace.SpanContext
+	app := &mockApp{
+		getEventsDay: func(ctx context.Context, _ time.Time) ([]storage.Event, error) {
+			got = trace.SpanContextFromContext(ctx)
+			return nil, nil
+		},
+	}
+
+	logger := logger.New("info", os.Stdout, false)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/event/day?start=2025-01-01T00:00:00Z", nil)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	server.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", got.TraceID().String())
+}
+
+func TestRequestID(t *testing.T) {
+	var got string
+	app := &mockApp{
+		getEventsDay: func(ctx context.Context, _ time.Time) ([]storage.Event, error) {
+			got = logger.RequestIDFromContext(ctx)
+			return nil, nil
+		},
+	}
+
+	lg := logger.New("info", os.Stdout, false)
+	server := NewServerHTTP("localhost", 8080, lg, app, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/event/day?start=2025-01-01T00:00:00Z", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	assert.Equal(t, "req-42", got)
+	assert.Equal(t, "req-42", w.Header().Get("X-Request-ID"))
+
+	req = httptest.NewRequest(http.MethodGet, "/event/day?start=2025-01-01T00:00:00Z", nil)
+	req.Header.Set("X-Request-ID", "bad id\n")
+	w = httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	assert.NotEmpty(t, got)
+	assert.NotEqual(t, "bad id\n", got)
+	assert.Equal(t, got, w.Header().Get("X-Request-ID"))
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) Problem {
+	t.Helper()
+
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	var p Problem
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+	assert.Equal(t, w.Code, p.Status)
+	assert.NotEmpty(t, p.RequestID)
+	return p
+}
+
+func TestCreateEvent_InvalidEvent(t *testing.T) {
+	app := &mockApp{createEvent: func(context.Context, storage.Event) error {
+		return &storage.ErrInvalidEvent{Field: "start", Message: "start time cannot be in the past"}
+	}}
+	logger := logger.New("info", os.Stdout, false)
+	server := NewServerHTTP("localhost", 8080, logger, app, nil)
+
+	body, _ := json.Marshal(storage.ToDTO(storage.Event{ID: uuid.New(), UserID: uuid.New()}))
+	req := httptest.NewRequest(http.MethodPost, "/event", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	server.Handler().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	p := decodeProblem(t, w)
+	assert.Equal(t, "urn:calendar:problem:invalid-event", p.Type)
+	assert.Equal(t, "start", p.InvalidField)
+	assert.Equal(t, "start time cannot be in the past", p.Detail)
+}
+
+func TestRateLimit(t *testing.T) {
+	app := &mockApp{
+		getEventsDay: func(context.Context, time.Time) ([]storage.Event, error) {
+			return nil, nil
+		},
+	}
+	limiter := ratelimit.New(ratelimit.Config{
+		Enabled: true,
+		Routes: map[string]ratelimit.Rule{
+			"GET /event/day":     {Rate: 0.1, Burst: 1},
+			"GET /v1/events/day": {Rate: 0.1, Burst: 1},
+		},
+	})
+	server := NewServerHTTP("localhost", 8080, logger.New("info", os.Stdout, false), app, limiter)
+
+	for _, target := range []string{"/event/day", "/v1/events/day"} {
+		t.Run(target, func(t *testing.T) {
+			serve := func(remoteAddr string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodGet, target+"?start=2025-01-01T00:00:00Z", nil)
+				req.RemoteAddr = remoteAddr
+				w := httptest.NewRecorder()
+				server.Handler().ServeHTTP(w, req)
+				return w
+			}
+
+			assert.Equal(t, http.StatusOK, serve("10.0.0.1:1234").Code)
+
+			w := serve("10.0.0.1:1235")
+			assert.Equal(t, http.StatusTooManyRequests, w.Code)
+			assert.Equal(t, "10", w.Header().Get("Retry-After"))
+			p := decodeProblem(t, w)
+			assert.Equal(t, problemTypePrefix+"rate-limited", p.Type)
+
+			// Лимит считается для каждого клиента отдельно
+			assert.Equal(t, http.StatusOK, serve("10.0.0.2:1234").Code)
+		})
+	}
+}
+
+func TestRateLimit_ClientCertificate(t *testing.T) {
+	app := &mockApp{
+		getEventsDay: func(context.Context, time.Time) ([]storage.Event, error) {
+			return nil, nil
+		},
+	}
+	limiter := ratelimit.New(ratelimit.Config{
+		Enabled: true,
+		Routes:  map[string]ratelimit.Rule{"GET /event/day": {Rate: 0.1, Burst: 1}},
+	})
+	server := NewServerHTTP("localhost", 8080, logger.New("info", os.Stdout, false), app, limiter)
+
+	serve := func(client string) int {
+		req := httptest.NewRequest(http.MethodGet, "/event/day?start=2025-01-01T00:00:00Z", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: client}}
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Клиенты за одним адресом различаются по сертификату
+	assert.Equal(t, http.StatusOK, serve("scheduler"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("scheduler"))
+	assert.Equal(t, http.StatusOK, serve("calendarctl"))
+}
+
+func TestRateLimit_TrustedProxy(t *testing.T) {
+	app := &mockApp{
+		getEventsDay: func(context.Context, time.Time) ([]storage.Event, error) {
+			return nil, nil
+		},
+	}
+	limiter := ratelimit.New(ratelimit.Config{
+		Enabled: true,
+		Routes:  map[string]ratelimit.Rule{"GET /event/day": {Rate: 0.1, Burst: 1}},
+	})
+	server := NewServerHTTP("localhost", 8080, logger.New("info", os.Stdout, false), app, limiter)
+	proxies, err := ParseTrustedProxies([]string{"10.244.0.0/16"})
+	assert.NoError(t, err)
+	server.SetTrustedProxies(proxies)
+
+	serve := func(client string) int {
+		req := httptest.NewRequest(http.MethodGet, "/event/day?start=2025-01-01T00:00:00Z", nil)
+		req.RemoteAddr = "10.244.0.12:1234"
+		req.Header.Set("X-Forwarded-For", client)
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Клиенты за ingress не расходуют лимит друг друга
+	assert.Equal(t, http.StatusOK, serve("198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("198.51.100.1"))
+	assert.Equal(t, http.StatusOK, serve("198.51.100.2"))
+}
+
+func TestHandle_BypassesMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	server := NewServerHTTP("localhost", 8080, logger.New("debug", &buf, false), &mockApp{}, nil)
+	server.Handle("GET /healthz", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get(serverpkg.RequestIDHeader))
+	assert.NotContains(t, buf.String(), "/healthz")
+
+	// Остальные маршруты по-прежнему проходят через middleware
+	w = httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.NotEmpty(t, w.Header().Get(serverpkg.RequestIDHeader))
+	assert.NotContains(t, w.Body.String(), `route="GET /healthz"`)
+	assert.Contains(t, buf.String(), "http request finished")
+}
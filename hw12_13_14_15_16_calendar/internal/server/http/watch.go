@@ -0,0 +1,123 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/server"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/watch"
+	"github.com/google/uuid"
+)
+
+// sseKeepAlive is the interval of comments keeping idle streams open behind proxies.
+const sseKeepAlive = 15 * time.Second
+
+// WatchEvents streams event changes as Server-Sent Events.
+func (s *Server) WatchEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := s.setLogCompMeth(r.Context(), "WatchEvents")
+
+	filter, err := watchFilter(r)
+	if err != nil {
+		s.logger.ErrorContext(ctx, err.Error())
+		s.checkError(w, r, err, server.ErrInvalidEventData)
+		return
+	}
+
+	// EventSource сам присылает Last-Event-ID при переподключении
+	token := r.Header.Get("Last-Event-ID")
+	if token == "" {
+		token = r.URL.Query().Get("resumeToken")
+	}
+
+	changes, err := s.app.WatchEvents(ctx, filter, token)
+	if err != nil {
+		s.logger.ErrorContext(ctx, err.Error())
+		s.checkError(w, r, err, server.ErrWatchEvents)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// Поток живёт дольше WriteTimeout сервера
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		s.logger.WarnContext(ctx, "cannot disable write deadline", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		s.logger.ErrorContext(ctx, err.Error())
+		return
+	}
+	s.logger.InfoContext(ctx, "watching event changes")
+
+	ticker := time.NewTicker(sseKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case c, ok := <-changes:
+			if !ok {
+				// Клиент переподключится и продолжит с Last-Event-ID
+				s.logger.InfoContext(ctx, "event changes stream closed")
+				return
+			}
+			err = writeChange(w, c)
+		case <-ticker.C:
+			_, err = io.WriteString(w, ": keep-alive\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			s.logger.ErrorContext(ctx, err.Error())
+			return
+		}
+	}
+}
+
+func watchFilter(r *http.Request) (watch.Filter, error) {
+	var filter watch.Filter
+	q := r.URL.Query()
+
+	if v := q.Get("userId"); v != "" {
+		userID, err := uuid.Parse(v)
+		if err != nil {
+			return watch.Filter{}, server.ErrInvalidUserID
+		}
+		filter.UserID = userID
+	}
+	for name, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return watch.Filter{}, server.ErrInvalidPeriod
+			}
+			*dst = t
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return watch.Filter{}, server.ErrInvalidPeriod
+	}
+	return filter, nil
+}
+
+func writeChange(w io.Writer, c watch.Change) error {
+	var data any = storage.ToDTO(c.Event)
+	if c.Type == watch.Deleted {
+		data = struct {
+			ID uuid.UUID `json:"id"`
+		}{c.Event.ID}
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", c.Token, c.Type, body)
+	return err
+}
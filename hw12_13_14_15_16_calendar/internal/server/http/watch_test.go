@@ -0,0 +1,96 @@
+package internalhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/watch"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchEvents(t *testing.T) {
+	userID := uuid.New()
+	event := storage.Event{
+		ID:     uuid.New(),
+		UserID: userID,
+		Title:  "meeting",
+		Start:  time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC),
+		End:    time.Date(2030, 1, 1, 11, 0, 0, 0, time.UTC),
+	}
+
+	var (
+		gotFilter watch.Filter
+		gotToken  string
+	)
+	ch := make(chan watch.Change, 2)
+	ch <- watch.Change{Token: "t-1", Type: watch.Created, Event: event}
+	ch <- watch.Change{Token: "t-2", Type: watch.Deleted, Event: storage.Event{ID: event.ID}}
+	close(ch)
+	app := &mockApp{watchEvents: func(_ context.Context, filter watch.Filter, token string) (<-chan watch.Change, error) {
+		gotFilter, gotToken = filter, token
+		return ch, nil
+	}}
+	s := NewServerHTTP("localhost", 8080, logger.New("debug", os.Stdout, false), app, nil)
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/event/watch?userId="+userID.String()+"&from=2030-01-01T00:00:00Z&resumeToken=t-0", nil)
+	req.Header.Set("Last-Event-ID", "t-5")
+	w := httptest.NewRecorder()
+
+	s.Handler().ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	require.Equal(t, userID, gotFilter.UserID)
+	require.True(t, gotFilter.From.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)))
+	require.True(t, gotFilter.To.IsZero())
+	require.Equal(t, "t-5", gotToken)
+
+	messages := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+	require.Len(t, messages, 2)
+	require.Contains(t, messages[0], "id: t-1\nevent: created\ndata: {")
+	require.Contains(t, messages[0], `"title":"meeting"`)
+	require.Equal(t, "id: t-2\nevent: deleted\ndata: {\"id\":\""+event.ID.String()+"\"}", messages[1])
+}
+
+func TestWatchEvents_Errors(t *testing.T) {
+	app := &mockApp{watchEvents: func(context.Context, watch.Filter, string) (<-chan watch.Change, error) {
+		return nil, watch.ErrTokenExpired
+	}}
+	s := NewServerHTTP("localhost", 8080, logger.New("debug", os.Stdout, false), app, nil)
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+		kind   string
+		field  string
+	}{
+		{"invalid user", "/event/watch?userId=abc", http.StatusBadRequest, "invalid-request", "userId"},
+		{
+			"inverted window", "/event/watch?from=2030-01-02T00:00:00Z&to=2030-01-01T00:00:00Z",
+			http.StatusBadRequest, "invalid-request", "to",
+		},
+		{"expired token", "/event/watch?resumeToken=old-1", http.StatusGone, "resume-token-expired", "resumeToken"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			require.Equal(t, tt.status, w.Code)
+			p := decodeProblem(t, w)
+			require.Equal(t, problemTypePrefix+tt.kind, p.Type)
+			require.Equal(t, tt.field, p.InvalidField)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package server
+
+import "github.com/google/uuid"
+
+const (
+	// RequestIDHeader is the HTTP header carrying the request ID.
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDMetadataKey is the gRPC metadata key carrying the request ID.
+	RequestIDMetadataKey = "x-request-id"
+
+	maxRequestIDLen = 128
+)
+
+// RequestID returns the request ID passed by the client if it is acceptable,
+// otherwise it generates a new one.
+func RequestID(passed string) string {
+	if validRequestID(passed) {
+		return passed
+	}
+	return uuid.NewString()
+}
+
+// validRequestID ограничивает длину и набор символов, чтобы клиент не мог засорить логи.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/watch"
 	"github.com/google/uuid"
 )
 
@@ -16,4 +17,5 @@ type Application interface {
 	GetEventsDay(ctx context.Context, start time.Time) ([]storage.Event, error)
 	GetEventsWeek(ctx context.Context, start time.Time) ([]storage.Event, error)
 	GetEventsMonth(ctx context.Context, start time.Time) ([]storage.Event, error)
+	WatchEvents(ctx context.Context, filter watch.Filter, token string) (<-chan watch.Change, error)
 }
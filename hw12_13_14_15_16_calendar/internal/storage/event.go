@@ -67,6 +67,16 @@ type Interval struct {
 
 // CheckValid validates Event fields.
 func (e Event) CheckValid() error {
+	return e.check(true)
+}
+
+// CheckRestored validates Event fields except that the start is in the future,
+// so that exported past events can be loaded back.
+func (e Event) CheckRestored() error {
+	return e.check(false)
+}
+
+func (e Event) check(future bool) error {
 	if e.ID == uuid.Nil {
 		return &ErrInvalidEvent{
 			Field:   "id",
@@ -79,7 +89,7 @@ func (e Event) CheckValid() error {
 			Message: "user ID is required",
 		}
 	}
-	if e.Start.Before(time.Now()) {
+	if future && e.Start.Before(time.Now()) {
 		return &ErrInvalidEvent{
 			Field:   "start",
 			Message: "start time cannot be in the past",
@@ -0,0 +1,15 @@
+package memorystorage
+
+import (
+	"os"
+	"testing"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(*testing.T) storagetest.Storage {
+		return New(logger.New("info", os.Stdout, false))
+	})
+}
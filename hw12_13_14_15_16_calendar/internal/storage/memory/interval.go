@@ -26,10 +26,10 @@ func (s *IntervalSlice) AddIfFree(newInterval storage.Interval) bool {
 	return false
 }
 
-// Удаляет точное совпадение интервала. Возвращает true, если удалён.
+// Удаляет интервал события target.ID. Возвращает true, если удалён.
 func (s *IntervalSlice) Remove(target storage.Interval) bool {
 	for i, interval := range s.Intervals {
-		if interval.Start.Equal(target.Start) && interval.End.Equal(target.End) {
+		if interval.ID == target.ID {
 			s.Intervals = append(s.Intervals[:i], s.Intervals[i+1:]...)
 			return true
 		}
@@ -61,12 +61,12 @@ func (s *IntervalSlice) GetInInterval(interval storage.Interval) []storage.Inter
 	return res
 }
 
-// Проверяет пересечение интервалов.
+// Проверяет пересечение интервалов. Конец не входит в интервал: события могут идти встык.
 func intervalsOverlap(a, b storage.Interval) bool {
 	return a.Start.Before(b.End) && b.Start.Before(a.End)
 }
 
-// Проверяет пересечение интервалов.
+// Проверяет, попадает ли интервал a в период b; как и при пересечении, конец не включается.
 func interInInterval(a, b storage.Interval) bool {
-	return (a.Start.Equal(b.End) || a.Start.Before(b.End)) && (b.Start.Before(a.End) || b.Start.Equal(a.End))
+	return a.Start.Before(b.End) && a.End.After(b.Start)
 }
@@ -8,15 +8,17 @@ import (
 	"time"
 
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/metrics"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
 	"github.com/google/uuid"
 )
 
 // Storage keeps events in memory.
 type Storage struct {
-	mu        sync.RWMutex
-	eventMap  map[uuid.UUID]storage.Event
-	intervals IntervalSlice
+	mu       sync.RWMutex
+	eventMap map[uuid.UUID]storage.Event
+	// Занятое время каждого пользователя: события разных пользователей могут пересекаться
+	intervals map[uuid.UUID]*IntervalSlice
 	logger    *slog.Logger
 }
 
@@ -25,11 +27,33 @@ func New(logger *slog.Logger) *Storage {
 	return &Storage{
 		mu:        sync.RWMutex{},
 		eventMap:  make(map[uuid.UUID]storage.Event),
-		intervals: IntervalSlice{Intervals: []storage.Interval{}},
+		intervals: make(map[uuid.UUID]*IntervalSlice),
 		logger:    logger,
 	}
 }
 
+// userIntervals returns the occupied time of the user, creating it if needed.
+func (s *Storage) userIntervals(userID uuid.UUID) *IntervalSlice {
+	intervals, ok := s.intervals[userID]
+	if !ok {
+		intervals = &IntervalSlice{Intervals: []storage.Interval{}}
+		s.intervals[userID] = intervals
+	}
+	return intervals
+}
+
+// removeInterval frees the time of the event, forgetting users without events.
+func (s *Storage) removeInterval(event storage.Event) {
+	intervals, ok := s.intervals[event.UserID]
+	if !ok {
+		return
+	}
+	intervals.Remove(event.GetInterval())
+	if len(intervals.Intervals) == 0 {
+		delete(s.intervals, event.UserID)
+	}
+}
+
 // CreateEvent adds a new event to storage.
 func (s *Storage) setLogCompMeth(ctx context.Context, method string) context.Context {
 	ctx = logger.WithLogComponent(ctx, "storage.memory")
@@ -37,7 +61,8 @@ func (s *Storage) setLogCompMeth(ctx context.Context, method string) context.Con
 }
 
 // CreateEvent adds a new event to storage.
-func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) error {
+func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) (err error) {
+	defer observe("CreateEvent", time.Now(), &err)
 	ctx = s.setLogCompMeth(ctx, "CreateEvent")
 	s.logger.DebugContext(ctx, "attempting to create event")
 
@@ -51,7 +76,7 @@ func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) error {
 	if _, ok := s.eventMap[event.ID]; ok {
 		return logger.AddPrefix(ctx, storage.ErrIDRepeated)
 	}
-	if !s.intervals.AddIfFree(event.GetInterval()) {
+	if !s.userIntervals(event.UserID).AddIfFree(event.GetInterval()) {
 		return logger.AddPrefix(ctx, storage.ErrDateBusy)
 	}
 
@@ -61,7 +86,8 @@ func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) error {
 }
 
 // UpdateEvent replaces an existing event.
-func (s *Storage) UpdateEvent(ctx context.Context, id uuid.UUID, newEvent storage.Event) error {
+func (s *Storage) UpdateEvent(ctx context.Context, id uuid.UUID, newEvent storage.Event) (err error) {
+	defer observe("UpdateEvent", time.Now(), &err)
 	ctx = s.setLogCompMeth(ctx, "UpdateEvent")
 	s.logger.DebugContext(ctx, "attempting to update event")
 
@@ -77,8 +103,18 @@ func (s *Storage) UpdateEvent(ctx context.Context, id uuid.UUID, newEvent storag
 		return logger.AddPrefix(ctx, storage.ErrIDNotExist)
 	}
 
-	if !s.intervals.Replace(newEvent.GetInterval(), oldEvent.GetInterval()) {
-		return logger.AddPrefix(ctx, storage.ErrDateBusy)
+	// Событие остаётся под своим ID, как в SQL-хранилищах
+	newEvent.ID = id
+	if newEvent.UserID == oldEvent.UserID {
+		if !s.userIntervals(newEvent.UserID).Replace(newEvent.GetInterval(), oldEvent.GetInterval()) {
+			return logger.AddPrefix(ctx, storage.ErrDateBusy)
+		}
+	} else {
+		// Смена владельца: время проверяется у нового пользователя
+		if !s.userIntervals(newEvent.UserID).AddIfFree(newEvent.GetInterval()) {
+			return logger.AddPrefix(ctx, storage.ErrDateBusy)
+		}
+		s.removeInterval(oldEvent)
 	}
 
 	s.eventMap[id] = newEvent
@@ -86,13 +122,14 @@ func (s *Storage) UpdateEvent(ctx context.Context, id uuid.UUID, newEvent storag
 	return nil
 }
 
-// DeleteEvent removes an event from storage.
-func (s *Storage) DeleteEvent(ctx context.Context, id uuid.UUID) error {
+// DeleteEvent removes an event from storage and returns it.
+func (s *Storage) DeleteEvent(ctx context.Context, id uuid.UUID) (_ storage.Event, err error) {
+	defer observe("DeleteEvent", time.Now(), &err)
 	ctx = s.setLogCompMeth(ctx, "DeleteEvent")
 	s.logger.DebugContext(ctx, "attempting to delete event")
 
 	if err := ctx.Err(); err != nil {
-		return logger.AddPrefix(ctx, err)
+		return storage.Event{}, logger.AddPrefix(ctx, err)
 	}
 
 	s.mu.Lock()
@@ -100,14 +137,14 @@ func (s *Storage) DeleteEvent(ctx context.Context, id uuid.UUID) error {
 
 	event, ok := s.eventMap[id]
 	if !ok {
-		return logger.AddPrefix(ctx, storage.ErrIDNotExist)
+		return storage.Event{}, logger.AddPrefix(ctx, storage.ErrIDNotExist)
 	}
 
-	s.intervals.Remove(event.GetInterval())
+	s.removeInterval(event)
 	delete(s.eventMap, id)
 
 	s.logger.InfoContext(ctx, "event deleted successfully")
-	return nil
+	return event, nil
 }
 
 // GetEventsDay returns events for a day.
@@ -125,7 +162,9 @@ func (s *Storage) GetEventsMonth(ctx context.Context, start time.Time) ([]storag
 	return s.getEvents(ctx, start, "Month")
 }
 
-func (s *Storage) getEvents(ctx context.Context, start time.Time, period string) ([]storage.Event, error) {
+func (s *Storage) getEvents(ctx context.Context, start time.Time, period string) (_ []storage.Event, err error) {
+	defer observe("GetEvents"+period, time.Now(), &err)
+
 	var d time.Duration
 	switch period {
 	case "Day":
@@ -149,22 +188,96 @@ func (s *Storage) getEvents(ctx context.Context, start time.Time, period string)
 	defer s.mu.RUnlock()
 
 	queryInterval := storage.Interval{Start: start, End: start.Add(d)}
-	intervals := s.intervals.GetInInterval(queryInterval)
 
-	res := make([]storage.Event, 0, len(intervals))
-	for _, inter := range intervals {
-		event, ok := s.eventMap[inter.ID]
-		if !ok {
-			return nil, logger.AddPrefix(ctx, storage.ErrGetEvents)
+	var res []storage.Event
+	for _, userIntervals := range s.intervals {
+		for _, inter := range userIntervals.GetInInterval(queryInterval) {
+			event, ok := s.eventMap[inter.ID]
+			if !ok {
+				return nil, logger.AddPrefix(ctx, storage.ErrGetEvents)
+			}
+			res = append(res, event)
 		}
-		res = append(res, event)
 	}
 
 	s.logger.InfoContext(ctx, "events retrieved successfully", "count", len(res))
 	return res, nil
 }
 
+// GetNotifications returns events whose notification time falls within [currTime, currTime+tick).
+func (s *Storage) GetNotifications(
+	ctx context.Context,
+	currTime time.Time,
+	tick time.Duration,
+) (_ []storage.Notification, err error) {
+	defer observe("GetNotifications", time.Now(), &err)
+	ctx = s.setLogCompMeth(ctx, "GetNotifications")
+	ctx = logger.WithLogStart(ctx, currTime)
+
+	s.logger.DebugContext(ctx, "attempting to get notifications for interval")
+
+	if err := ctx.Err(); err != nil {
+		return nil, logger.AddPrefix(ctx, err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var notifications []storage.Notification
+	for _, event := range s.eventMap {
+		notifyAt := event.Start.Add(-event.TimeBefore)
+		if !notifyAt.Before(currTime) && notifyAt.Before(currTime.Add(tick)) {
+			notifications = append(notifications, storage.Notification{
+				ID:     event.ID,
+				Title:  event.Title,
+				Start:  event.Start,
+				UserID: event.UserID,
+			})
+		}
+	}
+
+	s.logger.InfoContext(ctx, "notifications retrieved successfully", "count", len(notifications))
+	return notifications, nil
+}
+
+// DeleteOldEvents removes events that ended before delTime.
+func (s *Storage) DeleteOldEvents(ctx context.Context, delTime time.Time) (err error) {
+	defer observe("DeleteOldEvents", time.Now(), &err)
+	ctx = s.setLogCompMeth(ctx, "DeleteOldEvents")
+	ctx = logger.WithLogStart(ctx, delTime)
+
+	s.logger.DebugContext(ctx, "attempting to delete old events")
+
+	if err := ctx.Err(); err != nil {
+		return logger.AddPrefix(ctx, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for id, event := range s.eventMap {
+		if event.End.Before(delTime) {
+			s.removeInterval(event)
+			delete(s.eventMap, id)
+			count++
+		}
+	}
+
+	if count > 0 {
+		s.logger.InfoContext(ctx, "old events deleted successfully", "count", count)
+	} else {
+		s.logger.InfoContext(ctx, "no old events to delete")
+	}
+	return nil
+}
+
 // Close implements the Storage interface. Nothing to close for memory storage.
 func (s *Storage) Close() error {
 	return nil // ничего закрывать не нужно
 }
+
+// observe records metrics of the storage operation; err points to its named result.
+func observe(operation string, start time.Time, err *error) {
+	metrics.ObserveStorage("memory", operation, start, *err)
+}
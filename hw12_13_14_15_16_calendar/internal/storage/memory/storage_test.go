@@ -52,13 +52,16 @@ func TestStorage_AddUpdateDelete(t *testing.T) {
 	}
 
 	// Удаление.
-	err = store.DeleteEvent(ctx, event.ID)
+	deleted, err := store.DeleteEvent(ctx, event.ID)
 	if err != nil {
 		t.Errorf("не удалось удалить событие: %v", err)
 	}
+	if deleted.ID != event.ID || deleted.UserID != event.UserID {
+		t.Errorf("удалено не то событие: %v", deleted)
+	}
 
 	// Повторное удаление — ожидается ошибка.
-	err = store.DeleteEvent(ctx, event.ID)
+	_, err = store.DeleteEvent(ctx, event.ID)
 	if !errors.Is(err, storage.ErrIDNotExist) {
 		t.Errorf("ожидалась ошибка ErrIDNotExist, получено: %v", err)
 	}
@@ -129,7 +132,7 @@ func TestStorage_ConcurrentAccess(t *testing.T) {
 	for i := 0; i < goroutines; i++ {
 		go func() {
 			id := uuid.New()
-			err := store.DeleteEvent(ctx, id)
+			_, err := store.DeleteEvent(ctx, id)
 			// Ошибка может быть нормальной, если удаление происходит до добавления.
 			if err != nil && !errors.Is(err, storage.ErrIDNotExist) {
 				errCh <- err
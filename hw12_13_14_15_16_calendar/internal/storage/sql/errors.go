@@ -0,0 +1,44 @@
+package sqlstorage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Коды ошибок PostgreSQL, которые соответствуют ошибкам предметной области.
+const (
+	pgExclusionViolation = "23P01"
+	pgUniqueViolation    = "23505"
+)
+
+// sqlStateError is implemented by PostgreSQL driver errors.
+type sqlStateError interface {
+	SQLState() string
+}
+
+// translateError converts constraint violations into storage errors so that
+// the SQL backend reports them the same way as the in-memory one.
+func translateError(err error) error {
+	var pgErr sqlStateError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.SQLState() {
+	case pgExclusionViolation:
+		return fmt.Errorf("%w: %w", storage.ErrDateBusy, err)
+	case pgUniqueViolation:
+		return fmt.Errorf("%w: %w", storage.ErrIDRepeated, err)
+	}
+	return err
+}
+
+// checkAffected returns storage.ErrIDNotExist if the statement changed no rows.
+func checkAffected(tag pgconn.CommandTag) error {
+	if tag.RowsAffected() == 0 {
+		return storage.ErrIDNotExist
+	}
+	return nil
+}
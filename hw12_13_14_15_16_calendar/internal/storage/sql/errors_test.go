@@ -0,0 +1,37 @@
+package sqlstorage
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePgError struct {
+	code string
+}
+
+func (e fakePgError) Error() string {
+	return "SQLSTATE " + e.code
+}
+
+func (e fakePgError) SQLState() string {
+	return e.code
+}
+
+func TestTranslateError(t *testing.T) {
+	err := translateError(fmt.Errorf("exec: %w", fakePgError{code: pgExclusionViolation}))
+	require.ErrorIs(t, err, storage.ErrDateBusy)
+
+	err = translateError(fakePgError{code: pgUniqueViolation})
+	require.ErrorIs(t, err, storage.ErrIDRepeated)
+	require.ErrorAs(t, err, new(fakePgError))
+
+	other := fakePgError{code: "42P01"}
+	require.Equal(t, error(other), translateError(other))
+
+	plain := errors.New("connection reset")
+	require.Equal(t, plain, translateError(plain))
+}
@@ -0,0 +1,132 @@
+package sqlstorage
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// DefaultMigrationDir is the directory of the embedded migrations.
+const DefaultMigrationDir = "migrations"
+
+// ErrNoMigrationToRollback is returned by MigrateDown and MigrateRedo on an empty schema.
+var ErrNoMigrationToRollback = errors.New("no applied migration to roll back")
+
+//go:embed migrations/*.sql
+var embedMigrations embed.FS
+
+// migrator returns a goose provider for the embedded directory dir working over
+// the pool; the caller closes it. Concurrent runs, e.g. several replicas starting
+// at once, wait for each other on an advisory lock.
+func (s *Storage) migrator(dir string) (*goose.Provider, error) {
+	if dir == "" {
+		dir = DefaultMigrationDir
+	}
+	fsys, err := fs.Sub(embedMigrations, dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrations %s: %w", dir, err)
+	}
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, fmt.Errorf("migration lock: %w", err)
+	}
+	db := stdlib.OpenDBFromPool(s.db)
+	p, err := goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithSessionLocker(locker))
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// Migrate applies pending migrations and logs them; it is run at startup.
+func (s *Storage) Migrate(ctx context.Context, dir string) error {
+	ctx = s.setLogCompMeth(ctx, "Migrate")
+
+	results, err := s.MigrateUp(ctx, dir)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		s.logger.InfoContext(ctx, "migration applied", "migration", r.Source.Path, "duration", r.Duration)
+	}
+	return nil
+}
+
+// MigrateUp applies all pending migrations.
+func (s *Storage) MigrateUp(ctx context.Context, dir string) ([]*goose.MigrationResult, error) {
+	ctx = s.setLogCompMeth(ctx, "MigrateUp")
+
+	p, err := s.migrator(dir)
+	if err != nil {
+		return nil, logger.AddPrefix(ctx, err)
+	}
+	defer p.Close()
+	results, err := p.Up(ctx)
+	if err != nil {
+		return results, logger.AddPrefix(ctx, fmt.Errorf("migration error: %w", err))
+	}
+	return results, nil
+}
+
+// MigrateDown rolls back the last applied migration.
+func (s *Storage) MigrateDown(ctx context.Context, dir string) (*goose.MigrationResult, error) {
+	ctx = s.setLogCompMeth(ctx, "MigrateDown")
+
+	p, err := s.migrator(dir)
+	if err != nil {
+		return nil, logger.AddPrefix(ctx, err)
+	}
+	defer p.Close()
+	result, err := p.Down(ctx)
+	if errors.Is(err, goose.ErrNoNextVersion) {
+		return nil, logger.AddPrefix(ctx, ErrNoMigrationToRollback)
+	}
+	if err != nil {
+		return nil, logger.AddPrefix(ctx, fmt.Errorf("migration error: %w", err))
+	}
+	return result, nil
+}
+
+// MigrateRedo rolls back the last applied migration and applies it again.
+func (s *Storage) MigrateRedo(ctx context.Context, dir string) ([]*goose.MigrationResult, error) {
+	down, err := s.MigrateDown(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = s.setLogCompMeth(ctx, "MigrateRedo")
+	p, err := s.migrator(dir)
+	if err != nil {
+		return nil, logger.AddPrefix(ctx, err)
+	}
+	defer p.Close()
+	up, err := p.UpByOne(ctx)
+	if err != nil {
+		return []*goose.MigrationResult{down}, logger.AddPrefix(ctx, fmt.Errorf("migration error: %w", err))
+	}
+	return []*goose.MigrationResult{down, up}, nil
+}
+
+// MigrationStatus reports which migrations are applied.
+func (s *Storage) MigrationStatus(ctx context.Context, dir string) ([]*goose.MigrationStatus, error) {
+	ctx = s.setLogCompMeth(ctx, "MigrationStatus")
+
+	p, err := s.migrator(dir)
+	if err != nil {
+		return nil, logger.AddPrefix(ctx, err)
+	}
+	defer p.Close()
+	status, err := p.Status(ctx)
+	if err != nil {
+		return nil, logger.AddPrefix(ctx, err)
+	}
+	return status, nil
+}
@@ -1,9 +1,8 @@
 -- +goose Up
-DROP TABLE IF EXISTS events;
-
+-- Таблица не пересоздаётся: базы, созданные до учёта миграций, сохраняют данные.
 CREATE EXTENSION IF NOT EXISTS btree_gist;
 
-CREATE TABLE events (
+CREATE TABLE IF NOT EXISTS events (
     id UUID PRIMARY KEY,
     title TEXT NOT NULL,
     description TEXT,
@@ -23,4 +22,4 @@ CREATE TABLE events (
 );
 
 -- +goose Down
-DROP TABLE events;
\ No newline at end of file
+DROP TABLE IF EXISTS events;
\ No newline at end of file
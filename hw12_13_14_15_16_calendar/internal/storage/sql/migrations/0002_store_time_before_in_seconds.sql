@@ -0,0 +1,12 @@
+-- +goose Up
+-- Время уведомления хранится целыми секундами: значение читается без разбора текста INTERVAL.
+ALTER TABLE events
+    ALTER COLUMN time_before TYPE BIGINT USING COALESCE(EXTRACT(EPOCH FROM time_before), 0)::BIGINT,
+    ALTER COLUMN time_before SET DEFAULT 0,
+    ALTER COLUMN time_before SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE events
+    ALTER COLUMN time_before DROP NOT NULL,
+    ALTER COLUMN time_before DROP DEFAULT,
+    ALTER COLUMN time_before TYPE INTERVAL USING make_interval(secs => time_before);
\ No newline at end of file
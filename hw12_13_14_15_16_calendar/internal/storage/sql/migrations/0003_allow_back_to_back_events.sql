@@ -0,0 +1,26 @@
+-- +goose Up
+-- Конец события не входит в его интервал: события одного пользователя могут идти встык.
+-- Генерируемый столбец нельзя изменить, поэтому он создаётся заново вместе с ограничением.
+ALTER TABLE events DROP COLUMN period CASCADE;
+
+ALTER TABLE events
+    ADD COLUMN period TSRANGE GENERATED ALWAYS AS (
+        tsrange(start_time AT TIME ZONE 'UTC', end_time AT TIME ZONE 'UTC', '[)')
+    ) STORED,
+    ADD CONSTRAINT events_user_period_excl EXCLUDE USING GIST (
+        user_id WITH =,
+        period WITH &&
+    );
+
+-- +goose Down
+-- Не выполнится, если в базе уже есть события встык.
+ALTER TABLE events DROP COLUMN period CASCADE;
+
+ALTER TABLE events
+    ADD COLUMN period TSRANGE GENERATED ALWAYS AS (
+        tsrange(start_time AT TIME ZONE 'UTC', end_time AT TIME ZONE 'UTC', '[]')
+    ) STORED,
+    ADD CONSTRAINT events_user_period_excl EXCLUDE USING GIST (
+        user_id WITH =,
+        period WITH &&
+    );
\ No newline at end of file
@@ -0,0 +1,92 @@
+package sqlstorage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// queryExecModes maps configuration names to pgx query execution modes.
+var queryExecModes = map[string]pgx.QueryExecMode{
+	"cache_statement": pgx.QueryExecModeCacheStatement,
+	"cache_describe":  pgx.QueryExecModeCacheDescribe,
+	"describe_exec":   pgx.QueryExecModeDescribeExec,
+	"exec":            pgx.QueryExecModeExec,
+	"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+}
+
+// PoolConfig tunes the connection pool; zero values keep the pgx defaults.
+type PoolConfig struct {
+	// MaxConns ограничивает число соединений, по умолчанию max(4, число CPU).
+	MaxConns int32 `toml:"max_conns" env:"MAX_CONNS"`
+	// MinConns соединений держатся открытыми даже без нагрузки.
+	MinConns          int32         `toml:"min_conns" env:"MIN_CONNS"`
+	MaxConnLifetime   time.Duration `toml:"max_conn_lifetime" env:"MAX_CONN_LIFETIME"`
+	MaxConnIdleTime   time.Duration `toml:"max_conn_idle_time" env:"MAX_CONN_IDLE_TIME"`
+	HealthCheckPeriod time.Duration `toml:"health_check_period" env:"HEALTH_CHECK_PERIOD"`
+	// QueryExecMode: cache_statement (по умолчанию), cache_describe, describe_exec, exec
+	// или simple_protocol. Последние два не используют подготовленные запросы и подходят
+	// для PgBouncer в режиме transaction.
+	QueryExecMode string `toml:"query_exec_mode" env:"QUERY_EXEC_MODE"`
+	// StatementCacheCapacity — размер кэша подготовленных запросов на соединение, 0 — 512.
+	StatementCacheCapacity int `toml:"statement_cache_capacity" env:"STATEMENT_CACHE_CAPACITY"`
+}
+
+// Validate checks the limits and the query execution mode.
+func (c PoolConfig) Validate() error {
+	if c.MaxConns < 0 {
+		return fmt.Errorf("max_conns: must not be negative, got %d", c.MaxConns)
+	}
+	if c.MinConns < 0 {
+		return fmt.Errorf("min_conns: must not be negative, got %d", c.MinConns)
+	}
+	if c.MaxConns > 0 && c.MinConns > c.MaxConns {
+		return fmt.Errorf("min_conns: must not exceed max_conns %d, got %d", c.MaxConns, c.MinConns)
+	}
+	if c.MaxConnLifetime < 0 || c.MaxConnIdleTime < 0 || c.HealthCheckPeriod < 0 {
+		return errors.New("max_conn_lifetime, max_conn_idle_time and health_check_period must not be negative")
+	}
+	if _, ok := queryExecModes[c.QueryExecMode]; c.QueryExecMode != "" && !ok {
+		return fmt.Errorf("query_exec_mode: unknown mode %q", c.QueryExecMode)
+	}
+	if c.StatementCacheCapacity < 0 {
+		return fmt.Errorf("statement_cache_capacity: must not be negative, got %d", c.StatementCacheCapacity)
+	}
+	return nil
+}
+
+// poolConfig parses dsn and applies the settings of c over the defaults of pgx.
+func poolConfig(dsn string, c PoolConfig) (*pgxpool.Config, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parsing dsn: %w", err)
+	}
+	if c.MaxConns > 0 {
+		cfg.MaxConns = c.MaxConns
+	}
+	if c.MinConns > 0 {
+		cfg.MinConns = c.MinConns
+	}
+	if c.MaxConnLifetime > 0 {
+		cfg.MaxConnLifetime = c.MaxConnLifetime
+	}
+	if c.MaxConnIdleTime > 0 {
+		cfg.MaxConnIdleTime = c.MaxConnIdleTime
+	}
+	if c.HealthCheckPeriod > 0 {
+		cfg.HealthCheckPeriod = c.HealthCheckPeriod
+	}
+	if c.QueryExecMode != "" {
+		cfg.ConnConfig.DefaultQueryExecMode = queryExecModes[c.QueryExecMode]
+	}
+	if c.StatementCacheCapacity > 0 {
+		cfg.ConnConfig.StatementCacheCapacity = c.StatementCacheCapacity
+	}
+	return cfg, nil
+}
@@ -0,0 +1,61 @@
+package sqlstorage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const testDSN = "host=localhost port=5432 user=otus_user password=otus_password dbname=otus"
+
+func TestPoolConfig(t *testing.T) {
+	defaults, err := poolConfig(testDSN, PoolConfig{})
+	require.NoError(t, err)
+	require.Equal(t, pgx.QueryExecModeCacheStatement, defaults.ConnConfig.DefaultQueryExecMode)
+
+	cfg, err := poolConfig(testDSN, PoolConfig{
+		MaxConns:               8,
+		MinConns:               2,
+		MaxConnIdleTime:        time.Minute,
+		QueryExecMode:          "simple_protocol",
+		StatementCacheCapacity: 64,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(8), cfg.MaxConns)
+	require.Equal(t, int32(2), cfg.MinConns)
+	require.Equal(t, time.Minute, cfg.MaxConnIdleTime)
+	require.Equal(t, defaults.MaxConnLifetime, cfg.MaxConnLifetime)
+	require.Equal(t, pgx.QueryExecModeSimpleProtocol, cfg.ConnConfig.DefaultQueryExecMode)
+	require.Equal(t, 64, cfg.ConnConfig.StatementCacheCapacity)
+
+	_, err = poolConfig("host=localhost port=x", PoolConfig{})
+	require.ErrorContains(t, err, "parsing dsn")
+}
+
+func TestPoolConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  PoolConfig
+		err  string
+	}{
+		{name: "defaults", cfg: PoolConfig{}},
+		{name: "only min", cfg: PoolConfig{MinConns: 4}},
+		{name: "negative max", cfg: PoolConfig{MaxConns: -1}, err: "max_conns: must not be negative"},
+		{name: "min over max", cfg: PoolConfig{MaxConns: 2, MinConns: 3}, err: "must not exceed max_conns 2"},
+		{name: "negative lifetime", cfg: PoolConfig{MaxConnLifetime: -time.Second}, err: "must not be negative"},
+		{name: "unknown mode", cfg: PoolConfig{QueryExecMode: "prepared"}, err: `unknown mode "prepared"`},
+		{name: "negative cache", cfg: PoolConfig{StatementCacheCapacity: -1}, err: "statement_cache_capacity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+}
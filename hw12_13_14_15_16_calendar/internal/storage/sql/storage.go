@@ -2,32 +2,37 @@ package sqlstorage
 
 import (
 	"context"
-	"database/sql"
-	"embed"
 	"fmt"
 	"log"
 	"log/slog"
 	"time"
 
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/metrics"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tracing"
 	"github.com/google/uuid"
-	_ "github.com/jackc/pgx/stdlib" //revive:disable:blank-imports
-	"github.com/pressly/goose/v3"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Storage works with PostgreSQL to persist events.
 type Storage struct {
-	dsn    string
-	db     *sql.DB
-	logger *slog.Logger
+	dsn     string
+	poolCfg PoolConfig
+	db      *pgxpool.Pool
+	logger  *slog.Logger
 }
 
-// New creates a new SQL storage with the given DSN.
-func New(logger *slog.Logger, dsn string) *Storage {
+// New creates a new SQL storage with the given DSN and connection pool settings.
+func New(logger *slog.Logger, dsn string, pool PoolConfig) *Storage {
 	return &Storage{
-		dsn:    dsn,
-		logger: logger,
+		dsn:     dsn,
+		poolCfg: pool,
+		logger:  logger,
 	}
 }
 
@@ -42,13 +47,18 @@ func (s *Storage) Connect(ctx context.Context) error {
 	const maxAttempts = 5
 	const retryDelay = 2 * time.Second
 
-	var err error
+	cfg, err := poolConfig(s.dsn, s.poolCfg)
+	if err != nil {
+		// Ошибку конфигурации повторные попытки не исправят
+		return logger.AddPrefix(ctx, err)
+	}
 
 	for i := 1; i <= maxAttempts; i++ {
-		s.db, err = sql.Open("pgx", s.dsn)
+		s.db, err = pgxpool.NewWithConfig(ctx, cfg)
 		if err != nil {
 			err = fmt.Errorf("creating connection pool: %w", err)
-		} else if pingErr := s.db.PingContext(ctx); pingErr != nil {
+		} else if pingErr := s.db.Ping(ctx); pingErr != nil {
+			s.db.Close()
 			err = fmt.Errorf("establishing connection: %w", pingErr)
 		} else {
 			// Успешное подключение
@@ -68,40 +78,33 @@ func (s *Storage) Connect(ctx context.Context) error {
 	return logger.AddPrefix(ctx, fmt.Errorf("could not connect to PostgreSQL after %d attempts: %w", maxAttempts, err))
 }
 
-// Close closes database connection.
-func (s *Storage) Close() error {
-	return s.db.Close()
-}
-
-//go:embed migrations/*.sql
-var embedMigrations embed.FS
-
-// Migrate runs database migrations.
-func (s *Storage) Migrate(ctx context.Context, migrate string) (err error) {
-	goose.SetBaseFS(embedMigrations)
-
-	if err := goose.SetDialect("postgres"); err != nil {
-		return logger.AddPrefix(ctx, fmt.Errorf("setting dialect: %w", err))
-	}
-
-	if err := goose.Up(s.db, migrate); err != nil {
-		return logger.AddPrefix(ctx, fmt.Errorf("migration error: %w", err))
+// Ping checks that the database is reachable.
+func (s *Storage) Ping(ctx context.Context) error {
+	if err := s.db.Ping(ctx); err != nil {
+		return fmt.Errorf("ping PostgreSQL: %w", err)
 	}
+	return nil
+}
 
+// Close closes all connections of the pool.
+func (s *Storage) Close() error {
+	s.db.Close()
 	return nil
 }
 
 // CreateEvent inserts a new event into database.
-func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) error {
+func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) (err error) {
+	ctx, end := startOperation(ctx, "CreateEvent")
+	defer end(&err)
 	ctx = s.setLogCompMeth(ctx, "CreateEvent")
 	s.logger.DebugContext(ctx, "attempting to create event")
 
 	query := `
         INSERT INTO events (id, title, description, user_id, start_time, end_time, time_before)
-        VALUES ($1, $2, $3, $4, $5, $6, make_interval(secs => $7))
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
     `
 
-	_, err := s.db.ExecContext(ctx, query,
+	_, err = s.db.Exec(ctx, query,
 		event.ID,
 		event.Title,
 		event.Description,
@@ -111,25 +114,27 @@ func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) error {
 		int64(event.TimeBefore.Seconds()),
 	)
 	if err != nil {
-		return logger.AddPrefix(ctx, err)
+		return logger.AddPrefix(ctx, translateError(err))
 	}
 	s.logger.InfoContext(ctx, "event created successfully")
 	return nil
 }
 
 // UpdateEvent updates an existing event in database.
-func (s *Storage) UpdateEvent(ctx context.Context, id uuid.UUID, newEvent storage.Event) error {
+func (s *Storage) UpdateEvent(ctx context.Context, id uuid.UUID, newEvent storage.Event) (err error) {
+	ctx, end := startOperation(ctx, "UpdateEvent")
+	defer end(&err)
 	ctx = s.setLogCompMeth(ctx, "UpdateEvent")
 	s.logger.DebugContext(ctx, "attempting to update event")
 
 	query := `
         UPDATE events
         SET title = $1, description = $2, user_id = $3, start_time = $4,
-		end_time = $5, time_before = make_interval(secs => $6)
+		end_time = $5, time_before = $6
         WHERE id = $7
     `
 
-	_, err := s.db.ExecContext(ctx, query,
+	tag, err := s.db.Exec(ctx, query,
 		newEvent.Title,
 		newEvent.Description,
 		newEvent.UserID,
@@ -139,14 +144,19 @@ func (s *Storage) UpdateEvent(ctx context.Context, id uuid.UUID, newEvent storag
 		id,
 	)
 	if err != nil {
+		return logger.AddPrefix(ctx, translateError(err))
+	}
+	if err = checkAffected(tag); err != nil {
 		return logger.AddPrefix(ctx, err)
 	}
 	s.logger.InfoContext(ctx, "event updated successfully")
 	return nil
 }
 
-// DeleteEvent removes an event from database.
-func (s *Storage) DeleteEvent(ctx context.Context, id uuid.UUID) error {
+// DeleteEvent removes an event from database and returns it.
+func (s *Storage) DeleteEvent(ctx context.Context, id uuid.UUID) (_ storage.Event, err error) {
+	ctx, end := startOperation(ctx, "DeleteEvent")
+	defer end(&err)
 	ctx = s.setLogCompMeth(ctx, "DeleteEvent")
 
 	s.logger.DebugContext(ctx, "attempting to delete event")
@@ -154,14 +164,18 @@ func (s *Storage) DeleteEvent(ctx context.Context, id uuid.UUID) error {
 	query := `
         DELETE FROM events
         WHERE id = $1
+        RETURNING id, title, description, user_id, start_time, end_time, time_before
     `
 
-	_, err := s.db.ExecContext(ctx, query, id)
+	events, err := s.queryEvents(ctx, query, id)
 	if err != nil {
-		return logger.AddPrefix(ctx, err)
+		return storage.Event{}, logger.AddPrefix(ctx, err)
+	}
+	if len(events) == 0 {
+		return storage.Event{}, logger.AddPrefix(ctx, storage.ErrIDNotExist)
 	}
 	s.logger.InfoContext(ctx, "event deleted successfully")
-	return nil
+	return events[0], nil
 }
 
 // GetEventsDay selects events for one day.
@@ -179,7 +193,10 @@ func (s *Storage) GetEventsMonth(ctx context.Context, start time.Time) ([]storag
 	return s.getEvents(ctx, start, "Month")
 }
 
-func (s *Storage) getEvents(ctx context.Context, start time.Time, period string) ([]storage.Event, error) {
+func (s *Storage) getEvents(ctx context.Context, start time.Time, period string) (_ []storage.Event, err error) {
+	ctx, end := startOperation(ctx, "GetEvents"+period)
+	defer end(&err)
+
 	var d time.Duration
 	switch period {
 	case "Day":
@@ -198,47 +215,43 @@ func (s *Storage) getEvents(ctx context.Context, start time.Time, period string)
 	query := `
         SELECT id, title, description, user_id, start_time, end_time, time_before
         FROM events
-        WHERE start_time <= $2 AND end_time >= $1
+        WHERE start_time < $2 AND end_time > $1
     `
 
-	rows, err := s.db.QueryContext(ctx, query, start, start.Add(d))
+	events, err := s.queryEvents(ctx, query, start, start.Add(d))
 	if err != nil {
 		return nil, logger.AddPrefix(ctx, err)
 	}
-	defer rows.Close()
-
-	var events []storage.Event
-	for rows.Next() {
-		var event storage.Event
-		var intervalStr string
-		if err := rows.Scan(
-			&event.ID,
-			&event.Title,
-			&event.Description,
-			&event.UserID,
-			&event.Start,
-			&event.End,
-			&intervalStr,
-		); err != nil {
-			return nil, logger.AddPrefix(ctx, err)
-		}
 
-		dur, err := parsePostgresInterval(intervalStr)
-		if err != nil {
-			return nil, logger.AddPrefix(ctx, err)
-		}
-		event.TimeBefore = dur
+	s.logger.InfoContext(ctx, "events retrieved successfully", "count", len(events))
 
-		events = append(events, event)
-	}
+	return events, nil
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, logger.AddPrefix(ctx, err)
+// queryEvents runs a query selecting the columns of an event in the order of getEvents.
+func (s *Storage) queryEvents(ctx context.Context, query string, args ...any) ([]storage.Event, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
 	}
+	return pgx.CollectRows(rows, scanEvent)
+}
 
-	s.logger.InfoContext(ctx, "events retrieved successfully", "count", len(events))
-
-	return events, nil
+func scanEvent(row pgx.CollectableRow) (storage.Event, error) {
+	var event storage.Event
+	var timeBefore int64
+	err := row.Scan(
+		&event.ID,
+		&event.Title,
+		&event.Description,
+		&event.UserID,
+		&event.Start,
+		&event.End,
+		&timeBefore,
+	)
+	// time_before хранится в целых секундах
+	event.TimeBefore = time.Duration(timeBefore) * time.Second
+	return event, err
 }
 
 // GetNotifications returns upcoming event notifications.
@@ -246,7 +259,9 @@ func (s *Storage) GetNotifications(
 	ctx context.Context,
 	currTime time.Time,
 	tick time.Duration,
-) ([]storage.Notification, error) {
+) (_ []storage.Notification, err error) {
+	ctx, end := startOperation(ctx, "GetNotifications")
+	defer end(&err)
 	ctx = s.setLogCompMeth(ctx, "GetNotifications")
 	ctx = logger.WithLogStart(ctx, currTime)
 
@@ -255,31 +270,25 @@ func (s *Storage) GetNotifications(
 	query := `
         SELECT id, title, start_time, user_id
         FROM events
-        WHERE start_time - time_before <= $2 AND start_time - time_before >= $1
+        WHERE start_time - make_interval(secs => time_before) >= $1
+          AND start_time - make_interval(secs => time_before) < $2
     `
 
-	rows, err := s.db.QueryContext(ctx, query, currTime, currTime.Add(tick))
+	rows, err := s.db.Query(ctx, query, currTime, currTime.Add(tick))
 	if err != nil {
 		return nil, logger.AddPrefix(ctx, err)
 	}
-	defer rows.Close()
-
-	var notifications []storage.Notification
-	for rows.Next() {
+	notifications, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (storage.Notification, error) {
 		var notification storage.Notification
-		if err := rows.Scan(
+		err := row.Scan(
 			&notification.ID,
 			&notification.Title,
 			&notification.Start,
 			&notification.UserID,
-		); err != nil {
-			return nil, logger.AddPrefix(ctx, err)
-		}
-
-		notifications = append(notifications, notification)
-	}
-
-	if err := rows.Err(); err != nil {
+		)
+		return notification, err
+	})
+	if err != nil {
 		return nil, logger.AddPrefix(ctx, err)
 	}
 
@@ -289,7 +298,9 @@ func (s *Storage) GetNotifications(
 }
 
 // DeleteOldEvents removes events that ended before delTime.
-func (s *Storage) DeleteOldEvents(ctx context.Context, delTime time.Time) error {
+func (s *Storage) DeleteOldEvents(ctx context.Context, delTime time.Time) (err error) {
+	ctx, end := startOperation(ctx, "DeleteOldEvents")
+	defer end(&err)
 	ctx = s.setLogCompMeth(ctx, "DeleteOldEvents")
 	ctx = logger.WithLogStart(ctx, delTime)
 
@@ -300,18 +311,34 @@ func (s *Storage) DeleteOldEvents(ctx context.Context, delTime time.Time) error
         WHERE end_time < $1
     `
 
-	res, err := s.db.ExecContext(ctx, query, delTime)
+	tag, err := s.db.Exec(ctx, query, delTime)
 	if err != nil {
 		return logger.AddPrefix(ctx, err)
 	}
-	count, err := res.RowsAffected()
-	if err != nil {
-		return logger.AddPrefix(ctx, err)
-	}
-	if count > 0 {
+	if count := tag.RowsAffected(); count > 0 {
 		s.logger.InfoContext(ctx, "old events deleted successfully", "count", count)
 	} else {
 		s.logger.InfoContext(ctx, "no old events to delete")
 	}
 	return nil
 }
+
+// startOperation starts a span of the storage operation. The returned function
+// records its metrics and ends the span; err points to the named result of the operation.
+func startOperation(ctx context.Context, operation string) (context.Context, func(err *error)) {
+	start := time.Now()
+	ctx, span := tracing.Tracer().Start(ctx, "sql."+operation, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", operation),
+		))
+
+	return ctx, func(err *error) {
+		metrics.ObserveStorage("sql", operation, start, *err)
+		if *err != nil {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+		span.End()
+	}
+}
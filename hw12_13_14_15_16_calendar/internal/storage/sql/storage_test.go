@@ -13,6 +13,7 @@ import (
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
 	sqlstorage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage/sql"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage/storagetest"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
@@ -20,13 +21,16 @@ import (
 )
 
 func setupStorage(t *testing.T) *sqlstorage.Storage {
-	if testing.Short() {
-		t.Skip("-short: пропускаем интеграцию")
-	}
-
 	t.Helper()
+	return newStorage(t, startPostgres(t), sqlstorage.PoolConfig{})
+}
 
-	logger := logger.New("info", os.Stdout, false)
+// startPostgres starts a PostgreSQL container and returns its DSN.
+func startPostgres(tb testing.TB) string {
+	tb.Helper()
+	if testing.Short() {
+		tb.Skip("-short: пропускаем интеграцию")
+	}
 
 	ctx := context.Background()
 	pg, err := testcontainers.GenericContainer(ctx,
@@ -43,28 +47,35 @@ func setupStorage(t *testing.T) *sqlstorage.Storage {
 			},
 			Started: true,
 		})
-	require.NoError(t, err)
-	t.Cleanup(func() { _ = pg.Terminate(ctx) })
+	require.NoError(tb, err)
+	tb.Cleanup(func() { _ = pg.Terminate(ctx) })
 
 	host, _ := pg.Host(ctx)
 	port, _ := pg.MappedPort(ctx, "5432")
 	dsn := fmt.Sprintf("host=%s port=%s user=otus_user password=otus_password dbname=otus sslmode=disable",
 		host, port.Port())
 	fmt.Println(dsn)
-	st := sqlstorage.New(logger, dsn)
+	return dsn
+}
+
+// newStorage connects to dsn with the pool settings and applies migrations.
+func newStorage(tb testing.TB, dsn string, pool sqlstorage.PoolConfig) *sqlstorage.Storage {
+	tb.Helper()
+
+	st := sqlstorage.New(logger.New("info", os.Stdout, false), dsn, pool)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := st.Connect(ctx); err != nil {
-		t.Fatalf("ошибка подключения: %v", err)
+		tb.Fatalf("ошибка подключения: %v", err)
 	}
 
 	if err := st.Migrate(ctx, "migrations"); err != nil {
-		t.Fatalf("ошибка миграции: %v", err)
+		tb.Fatalf("ошибка миграции: %v", err)
 	}
 
-	t.Cleanup(func() {
+	tb.Cleanup(func() {
 		_ = st.Close()
 	})
 
@@ -141,10 +152,13 @@ func TestDeleteEvent(t *testing.T) {
 	event := makeTestEvent()
 	_ = st.CreateEvent(ctx, event)
 
-	err := st.DeleteEvent(ctx, event.ID)
+	deleted, err := st.DeleteEvent(ctx, event.ID)
 	if err != nil {
 		t.Fatalf("DeleteEvent: %v", err)
 	}
+	if deleted.UserID != event.UserID {
+		t.Errorf("DeleteEvent вернул событие пользователя %s, ожидался %s", deleted.UserID, event.UserID)
+	}
 
 	events, _ := st.GetEventsDay(ctx, event.Start)
 	for _, e := range events {
@@ -202,3 +216,143 @@ func TestDeleteOldEvents(t *testing.T) {
 		t.Errorf("unexpected event remaining: %v", events[0].ID)
 	}
 }
+
+func TestConformance(t *testing.T) {
+	st := setupStorage(t)
+
+	// Один контейнер на весь набор: перед каждой проверкой база очищается
+	storagetest.Run(t, func(t *testing.T) storagetest.Storage {
+		t.Helper()
+		require.NoError(t, st.DeleteOldEvents(context.Background(), time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC)))
+		return st
+	})
+}
+
+func TestMigrateRedoKeepsSchema(t *testing.T) {
+	st := setupStorage(t)
+	ctx := context.Background()
+
+	// Повторный up ничего не применяет и не трогает данные
+	event := makeTestEvent()
+	require.NoError(t, st.CreateEvent(ctx, event))
+	results, err := st.MigrateUp(ctx, sqlstorage.DefaultMigrationDir)
+	require.NoError(t, err)
+	require.Empty(t, results)
+	events, err := st.AllEvents(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	results, err = st.MigrateRedo(ctx, sqlstorage.DefaultMigrationDir)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.NoError(t, st.CreateEvent(ctx, makeTestEvent()))
+
+	statuses, err := st.MigrationStatus(ctx, sqlstorage.DefaultMigrationDir)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		require.False(t, s.AppliedAt.IsZero(), s.Source.Path)
+	}
+}
+
+func TestImportEvents(t *testing.T) {
+	st := setupStorage(t)
+	ctx := context.Background()
+
+	existing := makeTestEvent()
+	require.NoError(t, st.CreateEvent(ctx, existing))
+
+	// Прошедшие события из выгрузки тоже загружаются
+	fresh := makeTestEvent()
+	fresh.Start = existing.Start.Add(-48 * time.Hour)
+	fresh.End = existing.End.Add(-48 * time.Hour)
+	renamed := existing
+	renamed.Title = "Renamed"
+
+	n, err := st.ImportEvents(ctx, []storage.Event{renamed, fresh})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	events, err := st.AllEvents(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, fresh.ID, events[0].ID)
+	require.Equal(t, existing.ID, events[1].ID)
+	require.Equal(t, existing.Title, events[1].Title)
+
+	// Ошибка в одном событии отменяет весь импорт
+	invalid := makeTestEvent()
+	invalid.End = invalid.Start.Add(-time.Hour)
+	_, err = st.ImportEvents(ctx, []storage.Event{makeTestEvent(), invalid})
+	require.Error(t, err)
+	events, err = st.AllEvents(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+}
+
+func TestTimeBeforeRoundTrip(t *testing.T) {
+	st := setupStorage(t)
+	ctx := context.Background()
+
+	// Больше суток: старый разбор INTERVAL терял такие значения
+	event := makeTestEvent()
+	event.Start = time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	event.End = event.Start.Add(time.Hour)
+	event.TimeBefore = 36*time.Hour + 30*time.Second
+	require.NoError(t, st.CreateEvent(ctx, event))
+
+	events, err := st.GetEventsDay(ctx, event.Start)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, event.TimeBefore, events[0].TimeBefore)
+
+	notifyAt := event.Start.Add(-event.TimeBefore)
+	notifications, err := st.GetNotifications(ctx, notifyAt.Add(-time.Second), 2*time.Second)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	require.Equal(t, event.ID, notifications[0].ID)
+}
+
+func TestConnectRejectsInvalidPool(t *testing.T) {
+	st := sqlstorage.New(logger.New("info", os.Stdout, false), "host=localhost",
+		sqlstorage.PoolConfig{QueryExecMode: "prepared"})
+	require.ErrorContains(t, st.Connect(context.Background()), "query_exec_mode")
+}
+
+// BenchmarkStorage compares throughput of pool settings:
+// go test -tags integration -run ^$ -bench . ./internal/storage/sql/
+func BenchmarkStorage(b *testing.B) {
+	dsn := startPostgres(b)
+	pools := []struct {
+		name string
+		cfg  sqlstorage.PoolConfig
+	}{
+		{name: "cache_statement", cfg: sqlstorage.PoolConfig{MaxConns: 8}},
+		{name: "exec", cfg: sqlstorage.PoolConfig{MaxConns: 8, QueryExecMode: "exec"}},
+		{name: "simple_protocol", cfg: sqlstorage.PoolConfig{MaxConns: 8, QueryExecMode: "simple_protocol"}},
+		{name: "single_conn", cfg: sqlstorage.PoolConfig{MaxConns: 1}},
+	}
+	for _, pool := range pools {
+		st := newStorage(b, dsn, pool.cfg)
+		ctx := context.Background()
+
+		b.Run(pool.name+"/CreateEvent", func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := st.CreateEvent(ctx, makeTestEvent()); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+		b.Run(pool.name+"/GetEventsDay", func(b *testing.B) {
+			start := time.Now()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := st.GetEventsDay(ctx, start); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
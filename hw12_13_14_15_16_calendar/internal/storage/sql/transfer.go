@@ -0,0 +1,76 @@
+package sqlstorage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// AllEvents returns every stored event ordered by start time.
+func (s *Storage) AllEvents(ctx context.Context) (_ []storage.Event, err error) {
+	ctx, end := startOperation(ctx, "AllEvents")
+	defer end(&err)
+	ctx = s.setLogCompMeth(ctx, "AllEvents")
+
+	query := `
+        SELECT id, title, description, user_id, start_time, end_time, time_before
+        FROM events
+        ORDER BY start_time, id
+    `
+
+	events, err := s.queryEvents(ctx, query)
+	if err != nil {
+		return nil, logger.AddPrefix(ctx, err)
+	}
+	s.logger.InfoContext(ctx, "events retrieved successfully", "count", len(events))
+	return events, nil
+}
+
+// ImportEvents stores events in one transaction, skipping those whose ID already
+// exists, and returns how many were added. Past events are accepted; invalid or
+// overlapping events abort the import without changes.
+func (s *Storage) ImportEvents(ctx context.Context, events []storage.Event) (_ int, err error) {
+	ctx, end := startOperation(ctx, "ImportEvents")
+	defer end(&err)
+	ctx = s.setLogCompMeth(ctx, "ImportEvents")
+
+	for _, e := range events {
+		if err := e.CheckRestored(); err != nil {
+			return 0, logger.AddPrefix(ctx, fmt.Errorf("event %s: %w", e.ID, err))
+		}
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, logger.AddPrefix(ctx, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	query := `
+        INSERT INTO events (id, title, description, user_id, start_time, end_time, time_before)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (id) DO NOTHING
+    `
+
+	imported := 0
+	for _, e := range events {
+		tag, err := tx.Exec(ctx, query,
+			e.ID, e.Title, e.Description, e.UserID, e.Start, e.End, int64(e.TimeBefore.Seconds()))
+		if err != nil {
+			return 0, logger.AddPrefix(ctx, fmt.Errorf("event %s: %w", e.ID, translateError(err)))
+		}
+		imported += int(tag.RowsAffected())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, logger.AddPrefix(ctx, err)
+	}
+
+	s.logger.InfoContext(ctx, "events imported successfully", "count", imported, "skipped", len(events)-imported)
+	return imported, nil
+}
@@ -0,0 +1,48 @@
+package sqlitestorage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// checkAffected returns storage.ErrIDNotExist if the statement changed no rows.
+func checkAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrIDNotExist
+	}
+	return nil
+}
+
+// Расширенные коды ошибок SQLite, которые соответствуют ошибкам предметной области.
+const (
+	sqliteConstraintPrimaryKey = 1555
+	sqliteConstraintTrigger    = 1811
+)
+
+// sqliteError is implemented by errors of the SQLite driver.
+type sqliteError interface {
+	Code() int
+}
+
+// translateError converts constraint violations into storage errors so that
+// the SQLite backend reports them the same way as the others.
+func translateError(err error) error {
+	var liteErr sqliteError
+	if !errors.As(err, &liteErr) {
+		return err
+	}
+	switch liteErr.Code() {
+	case sqliteConstraintTrigger:
+		return fmt.Errorf("%w: %w", storage.ErrDateBusy, err)
+	case sqliteConstraintPrimaryKey:
+		return fmt.Errorf("%w: %w", storage.ErrIDRepeated, err)
+	}
+	return err
+}
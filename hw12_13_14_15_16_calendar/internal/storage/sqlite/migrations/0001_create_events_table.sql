@@ -0,0 +1,47 @@
+-- +goose Up
+-- Время хранится в наносекундах Unix: целые числа сравниваются без учёта часовых поясов.
+CREATE TABLE IF NOT EXISTS events (
+    id TEXT PRIMARY KEY,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    user_id TEXT NOT NULL,
+    start_time INTEGER NOT NULL,
+    end_time INTEGER NOT NULL,
+    time_before INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS events_user_period ON events (user_id, start_time, end_time);
+CREATE INDEX IF NOT EXISTS events_end_time ON events (end_time);
+
+-- Аналог EXCLUDE USING GIST из PostgreSQL: события одного пользователя не пересекаются,
+-- границы включаются. Событие с тем же ID не проверяется, чтобы повтор ID давал ошибку ключа.
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS events_no_overlap_insert
+BEFORE INSERT ON events
+WHEN EXISTS (
+    SELECT 1 FROM events
+    WHERE user_id = NEW.user_id AND id <> NEW.id
+      AND start_time <= NEW.end_time AND end_time >= NEW.start_time
+)
+BEGIN
+    SELECT RAISE(ABORT, 'event overlaps another event of the user');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS events_no_overlap_update
+BEFORE UPDATE OF user_id, start_time, end_time ON events
+WHEN EXISTS (
+    SELECT 1 FROM events
+    WHERE user_id = NEW.user_id AND id <> OLD.id
+      AND start_time <= NEW.end_time AND end_time >= NEW.start_time
+)
+BEGIN
+    SELECT RAISE(ABORT, 'event overlaps another event of the user');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS events_no_overlap_update;
+DROP TRIGGER IF EXISTS events_no_overlap_insert;
+DROP TABLE IF EXISTS events;
\ No newline at end of file
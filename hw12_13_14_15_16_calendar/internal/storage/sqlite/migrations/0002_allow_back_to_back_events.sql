@@ -0,0 +1,60 @@
+-- +goose Up
+-- Конец события не входит в его интервал: события одного пользователя могут идти встык.
+DROP TRIGGER IF EXISTS events_no_overlap_insert;
+DROP TRIGGER IF EXISTS events_no_overlap_update;
+
+-- +goose StatementBegin
+CREATE TRIGGER events_no_overlap_insert
+BEFORE INSERT ON events
+WHEN EXISTS (
+    SELECT 1 FROM events
+    WHERE user_id = NEW.user_id AND id <> NEW.id
+      AND start_time < NEW.end_time AND end_time > NEW.start_time
+)
+BEGIN
+    SELECT RAISE(ABORT, 'event overlaps another event of the user');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER events_no_overlap_update
+BEFORE UPDATE OF user_id, start_time, end_time ON events
+WHEN EXISTS (
+    SELECT 1 FROM events
+    WHERE user_id = NEW.user_id AND id <> OLD.id
+      AND start_time < NEW.end_time AND end_time > NEW.start_time
+)
+BEGIN
+    SELECT RAISE(ABORT, 'event overlaps another event of the user');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS events_no_overlap_insert;
+DROP TRIGGER IF EXISTS events_no_overlap_update;
+
+-- +goose StatementBegin
+CREATE TRIGGER events_no_overlap_insert
+BEFORE INSERT ON events
+WHEN EXISTS (
+    SELECT 1 FROM events
+    WHERE user_id = NEW.user_id AND id <> NEW.id
+      AND start_time <= NEW.end_time AND end_time >= NEW.start_time
+)
+BEGIN
+    SELECT RAISE(ABORT, 'event overlaps another event of the user');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER events_no_overlap_update
+BEFORE UPDATE OF user_id, start_time, end_time ON events
+WHEN EXISTS (
+    SELECT 1 FROM events
+    WHERE user_id = NEW.user_id AND id <> OLD.id
+      AND start_time <= NEW.end_time AND end_time >= NEW.start_time
+)
+BEGIN
+    SELECT RAISE(ABORT, 'event overlaps another event of the user');
+END;
+-- +goose StatementEnd
\ No newline at end of file
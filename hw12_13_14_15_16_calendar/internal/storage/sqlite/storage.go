@@ -0,0 +1,419 @@
+// Package sqlitestorage keeps events in an SQLite file. It needs no server
+// and is meant for local development and tests.
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math"
+	"net/url"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/metrics"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/tracing"
+	"github.com/google/uuid"
+	"github.com/pressly/goose/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	_ "modernc.org/sqlite" //revive:disable:blank-imports
+)
+
+//go:embed migrations/*.sql
+var embedMigrations embed.FS
+
+// Storage works with an SQLite database file to persist events.
+type Storage struct {
+	path   string
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// New creates a new SQLite storage keeping the database in the file at path.
+func New(logger *slog.Logger, path string) *Storage {
+	return &Storage{
+		path:   path,
+		logger: logger,
+	}
+}
+
+func (s *Storage) setLogCompMeth(ctx context.Context, method string) context.Context {
+	ctx = logger.WithLogComponent(ctx, "storage.sqlite")
+	return logger.WithLogMethod(ctx, method)
+}
+
+// Connect opens the database file, creating it if needed.
+func (s *Storage) Connect(ctx context.Context) error {
+	ctx = s.setLogCompMeth(ctx, "Connect")
+
+	// WAL позволяет планировщику читать файл, пока календарь пишет;
+	// busy_timeout ждёт блокировку другого процесса вместо ошибки SQLITE_BUSY
+	params := url.Values{}
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_txlock", "immediate")
+	db, err := sql.Open("sqlite", "file:"+s.path+"?"+params.Encode())
+	if err != nil {
+		return logger.AddPrefix(ctx, fmt.Errorf("opening database: %w", err))
+	}
+	// SQLite выполняет записи по одной, лишние соединения только ждали бы блокировку
+	db.SetMaxOpenConns(1)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return logger.AddPrefix(ctx, fmt.Errorf("opening %s: %w", s.path, err))
+	}
+	s.db = db
+	return nil
+}
+
+// Migrate applies pending migrations and logs them.
+func (s *Storage) Migrate(ctx context.Context) error {
+	ctx = s.setLogCompMeth(ctx, "Migrate")
+
+	fsys, err := fs.Sub(embedMigrations, "migrations")
+	if err != nil {
+		return logger.AddPrefix(ctx, err)
+	}
+	p, err := goose.NewProvider(goose.DialectSQLite3, s.db, fsys)
+	if err != nil {
+		return logger.AddPrefix(ctx, err)
+	}
+	results, err := p.Up(ctx)
+	if err != nil {
+		return logger.AddPrefix(ctx, fmt.Errorf("migration error: %w", err))
+	}
+	for _, r := range results {
+		s.logger.InfoContext(ctx, "migration applied", "migration", r.Source.Path, "duration", r.Duration)
+	}
+	return nil
+}
+
+// Ping checks that the database is reachable.
+func (s *Storage) Ping(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping SQLite: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database.
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// CreateEvent inserts a new event into database.
+func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) (err error) {
+	ctx, end := startOperation(ctx, "CreateEvent")
+	defer end(&err)
+	ctx = s.setLogCompMeth(ctx, "CreateEvent")
+	s.logger.DebugContext(ctx, "attempting to create event")
+
+	if err = checkTimes(event); err != nil {
+		return logger.AddPrefix(ctx, err)
+	}
+
+	query := `
+        INSERT INTO events (id, title, description, user_id, start_time, end_time, time_before)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `
+
+	_, err = s.db.ExecContext(ctx, query,
+		event.ID,
+		event.Title,
+		event.Description,
+		event.UserID,
+		unixNano(event.Start),
+		unixNano(event.End),
+		int64(event.TimeBefore.Seconds()),
+	)
+	if err != nil {
+		return logger.AddPrefix(ctx, translateError(err))
+	}
+	s.logger.InfoContext(ctx, "event created successfully")
+	return nil
+}
+
+// UpdateEvent updates an existing event in database.
+func (s *Storage) UpdateEvent(ctx context.Context, id uuid.UUID, newEvent storage.Event) (err error) {
+	ctx, end := startOperation(ctx, "UpdateEvent")
+	defer end(&err)
+	ctx = s.setLogCompMeth(ctx, "UpdateEvent")
+	s.logger.DebugContext(ctx, "attempting to update event")
+
+	if err = checkTimes(newEvent); err != nil {
+		return logger.AddPrefix(ctx, err)
+	}
+
+	query := `
+        UPDATE events
+        SET title = ?, description = ?, user_id = ?, start_time = ?, end_time = ?, time_before = ?
+        WHERE id = ?
+    `
+
+	res, err := s.db.ExecContext(ctx, query,
+		newEvent.Title,
+		newEvent.Description,
+		newEvent.UserID,
+		unixNano(newEvent.Start),
+		unixNano(newEvent.End),
+		int64(newEvent.TimeBefore.Seconds()),
+		id,
+	)
+	if err != nil {
+		return logger.AddPrefix(ctx, translateError(err))
+	}
+	if err = checkAffected(res); err != nil {
+		return logger.AddPrefix(ctx, err)
+	}
+	s.logger.InfoContext(ctx, "event updated successfully")
+	return nil
+}
+
+// DeleteEvent removes an event from database and returns it.
+func (s *Storage) DeleteEvent(ctx context.Context, id uuid.UUID) (_ storage.Event, err error) {
+	ctx, end := startOperation(ctx, "DeleteEvent")
+	defer end(&err)
+	ctx = s.setLogCompMeth(ctx, "DeleteEvent")
+	s.logger.DebugContext(ctx, "attempting to delete event")
+
+	query := `
+        DELETE FROM events WHERE id = ?
+        RETURNING id, title, description, user_id, start_time, end_time, time_before
+    `
+
+	var event storage.Event
+	var startNano, endNano, timeBefore int64
+	err = s.db.QueryRowContext(ctx, query, id).Scan(
+		&event.ID,
+		&event.Title,
+		&event.Description,
+		&event.UserID,
+		&startNano,
+		&endNano,
+		&timeBefore,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Event{}, logger.AddPrefix(ctx, storage.ErrIDNotExist)
+	}
+	if err != nil {
+		return storage.Event{}, logger.AddPrefix(ctx, err)
+	}
+	event.Start = time.Unix(0, startNano)
+	event.End = time.Unix(0, endNano)
+	event.TimeBefore = time.Duration(timeBefore) * time.Second
+	s.logger.InfoContext(ctx, "event deleted successfully")
+	return event, nil
+}
+
+// GetEventsDay selects events for one day.
+func (s *Storage) GetEventsDay(ctx context.Context, start time.Time) ([]storage.Event, error) {
+	return s.getEvents(ctx, start, "Day")
+}
+
+// GetEventsWeek selects events for one week.
+func (s *Storage) GetEventsWeek(ctx context.Context, start time.Time) ([]storage.Event, error) {
+	return s.getEvents(ctx, start, "Week")
+}
+
+// GetEventsMonth selects events for one month.
+func (s *Storage) GetEventsMonth(ctx context.Context, start time.Time) ([]storage.Event, error) {
+	return s.getEvents(ctx, start, "Month")
+}
+
+func (s *Storage) getEvents(ctx context.Context, start time.Time, period string) (_ []storage.Event, err error) {
+	ctx, end := startOperation(ctx, "GetEvents"+period)
+	defer end(&err)
+
+	var d time.Duration
+	switch period {
+	case "Day":
+		d = time.Hour * 24
+	case "Week":
+		d = time.Hour * 24 * 7
+	case "Month":
+		d = time.Hour * 24 * 30
+	}
+
+	ctx = s.setLogCompMeth(ctx, "GetEvents"+period)
+	ctx = logger.WithLogStart(ctx, start)
+
+	s.logger.DebugContext(ctx, "attempting to get events for interval")
+
+	query := `
+        SELECT id, title, description, user_id, start_time, end_time, time_before
+        FROM events
+        WHERE start_time < ? AND end_time > ?
+        ORDER BY start_time, id
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, unixNano(start.Add(d)), unixNano(start))
+	if err != nil {
+		return nil, logger.AddPrefix(ctx, err)
+	}
+	defer rows.Close()
+
+	var events []storage.Event
+	for rows.Next() {
+		var event storage.Event
+		var startNano, endNano, timeBefore int64
+		if err := rows.Scan(
+			&event.ID,
+			&event.Title,
+			&event.Description,
+			&event.UserID,
+			&startNano,
+			&endNano,
+			&timeBefore,
+		); err != nil {
+			return nil, logger.AddPrefix(ctx, err)
+		}
+		event.Start = time.Unix(0, startNano)
+		event.End = time.Unix(0, endNano)
+		event.TimeBefore = time.Duration(timeBefore) * time.Second
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logger.AddPrefix(ctx, err)
+	}
+
+	s.logger.InfoContext(ctx, "events retrieved successfully", "count", len(events))
+
+	return events, nil
+}
+
+// GetNotifications returns upcoming event notifications.
+func (s *Storage) GetNotifications(
+	ctx context.Context,
+	currTime time.Time,
+	tick time.Duration,
+) (_ []storage.Notification, err error) {
+	ctx, end := startOperation(ctx, "GetNotifications")
+	defer end(&err)
+	ctx = s.setLogCompMeth(ctx, "GetNotifications")
+	ctx = logger.WithLogStart(ctx, currTime)
+
+	s.logger.DebugContext(ctx, "attempting to get notifications for interval")
+
+	query := `
+        SELECT id, title, start_time, user_id
+        FROM events
+        WHERE start_time - time_before * 1000000000 >= ?
+          AND start_time - time_before * 1000000000 < ?
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, unixNano(currTime), unixNano(currTime.Add(tick)))
+	if err != nil {
+		return nil, logger.AddPrefix(ctx, err)
+	}
+	defer rows.Close()
+
+	var notifications []storage.Notification
+	for rows.Next() {
+		var notification storage.Notification
+		var startNano int64
+		if err := rows.Scan(
+			&notification.ID,
+			&notification.Title,
+			&startNano,
+			&notification.UserID,
+		); err != nil {
+			return nil, logger.AddPrefix(ctx, err)
+		}
+		notification.Start = time.Unix(0, startNano)
+		notifications = append(notifications, notification)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, logger.AddPrefix(ctx, err)
+	}
+
+	s.logger.InfoContext(ctx, "notifications retrieved successfully", "count", len(notifications))
+
+	return notifications, nil
+}
+
+// DeleteOldEvents removes events that ended before delTime.
+func (s *Storage) DeleteOldEvents(ctx context.Context, delTime time.Time) (err error) {
+	ctx, end := startOperation(ctx, "DeleteOldEvents")
+	defer end(&err)
+	ctx = s.setLogCompMeth(ctx, "DeleteOldEvents")
+	ctx = logger.WithLogStart(ctx, delTime)
+
+	s.logger.DebugContext(ctx, "attempting to delete old events")
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM events WHERE end_time < ?`, unixNano(delTime))
+	if err != nil {
+		return logger.AddPrefix(ctx, err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return logger.AddPrefix(ctx, err)
+	}
+	if count > 0 {
+		s.logger.InfoContext(ctx, "old events deleted successfully", "count", count)
+	} else {
+		s.logger.InfoContext(ctx, "no old events to delete")
+	}
+	return nil
+}
+
+// Время хранится в наносекундах Unix, поэтому помещается только в этот диапазон.
+var (
+	minTime = time.Unix(0, math.MinInt64)
+	maxTime = time.Unix(0, math.MaxInt64)
+)
+
+// checkTimes rejects events whose times cannot be stored as Unix nanoseconds,
+// otherwise UnixNano silently overflows and the event is saved with wrong times.
+func checkTimes(e storage.Event) error {
+	for _, f := range []struct {
+		name string
+		t    time.Time
+	}{{"start", e.Start}, {"end", e.End}} {
+		if f.t.Before(minTime) || f.t.After(maxTime) {
+			return &storage.ErrInvalidEvent{
+				Field:   f.name,
+				Message: fmt.Sprintf("time must be between %s and %s", minTime.UTC(), maxTime.UTC()),
+			}
+		}
+	}
+	return nil
+}
+
+// unixNano returns t in Unix nanoseconds, clamping query bounds outside the stored range.
+func unixNano(t time.Time) int64 {
+	switch {
+	case t.Before(minTime):
+		return math.MinInt64
+	case t.After(maxTime):
+		return math.MaxInt64
+	}
+	return t.UnixNano()
+}
+
+// startOperation starts a span of the storage operation. The returned function
+// records its metrics and ends the span; err points to the named result of the operation.
+func startOperation(ctx context.Context, operation string) (context.Context, func(err *error)) {
+	start := time.Now()
+	ctx, span := tracing.Tracer().Start(ctx, "sqlite."+operation, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "sqlite"),
+			attribute.String("db.operation", operation),
+		))
+
+	return ctx, func(err *error) {
+		metrics.ObserveStorage("sqlite", operation, start, *err)
+		if *err != nil {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,218 @@
+package sqlitestorage_test
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	sqlitestorage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage/sqlite"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage/storagetest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func setupStorage(t *testing.T) *sqlitestorage.Storage {
+	t.Helper()
+
+	st := sqlitestorage.New(logger.New("info", os.Stdout, false), filepath.Join(t.TempDir(), "calendar.db"))
+	ctx := context.Background()
+	require.NoError(t, st.Connect(ctx))
+	t.Cleanup(func() { _ = st.Close() })
+	require.NoError(t, st.Migrate(ctx))
+	return st
+}
+
+func makeTestEvent() storage.Event {
+	return storage.Event{
+		ID:          uuid.New(),
+		Title:       "Test Event",
+		Description: "Test Description",
+		UserID:      uuid.New(),
+		Start:       time.Now().Add(time.Hour),
+		End:         time.Now().Add(3 * time.Hour),
+		TimeBefore:  15 * time.Minute,
+	}
+}
+
+func TestCreateAndGetEvent(t *testing.T) {
+	st := setupStorage(t)
+	ctx := context.Background()
+
+	event := makeTestEvent()
+	require.NoError(t, st.CreateEvent(ctx, event))
+	require.ErrorIs(t, st.CreateEvent(ctx, event), storage.ErrIDRepeated)
+
+	events, err := st.GetEventsDay(ctx, event.Start)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	got := events[0]
+	require.Equal(t, event.ID, got.ID)
+	require.Equal(t, event.UserID, got.UserID)
+	require.Equal(t, event.Title, got.Title)
+	require.Equal(t, event.Description, got.Description)
+	require.True(t, event.Start.Equal(got.Start))
+	require.True(t, event.End.Equal(got.End))
+	require.Equal(t, event.TimeBefore, got.TimeBefore)
+}
+
+func TestUpdateEvent(t *testing.T) {
+	st := setupStorage(t)
+	ctx := context.Background()
+
+	event := makeTestEvent()
+	require.NoError(t, st.CreateEvent(ctx, event))
+
+	event.Title = "Updated Title"
+	event.End = event.End.Add(time.Hour)
+	require.NoError(t, st.UpdateEvent(ctx, event.ID, event))
+
+	events, err := st.GetEventsDay(ctx, event.Start)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "Updated Title", events[0].Title)
+
+	require.ErrorIs(t, st.UpdateEvent(ctx, uuid.New(), event), storage.ErrIDNotExist)
+}
+
+func TestDeleteEvent(t *testing.T) {
+	st := setupStorage(t)
+	ctx := context.Background()
+
+	event := makeTestEvent()
+	require.NoError(t, st.CreateEvent(ctx, event))
+	deleted, err := st.DeleteEvent(ctx, event.ID)
+	require.NoError(t, err)
+	require.Equal(t, event.UserID, deleted.UserID)
+	require.True(t, event.Start.Equal(deleted.Start))
+	_, err = st.DeleteEvent(ctx, event.ID)
+	require.ErrorIs(t, err, storage.ErrIDNotExist)
+
+	events, err := st.GetEventsDay(ctx, event.Start)
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func TestOverlap(t *testing.T) {
+	st := setupStorage(t)
+	ctx := context.Background()
+
+	event := makeTestEvent()
+	require.NoError(t, st.CreateEvent(ctx, event))
+
+	// Пересечение с событием того же пользователя запрещено
+	overlapping := makeTestEvent()
+	overlapping.UserID = event.UserID
+	overlapping.Start = event.End.Add(-time.Minute)
+	overlapping.End = event.End.Add(time.Hour)
+	require.ErrorIs(t, st.CreateEvent(ctx, overlapping), storage.ErrDateBusy)
+
+	// Другому пользователю то же время доступно
+	overlapping.UserID = uuid.New()
+	require.NoError(t, st.CreateEvent(ctx, overlapping))
+
+	// Событие можно сдвигать в пределах своего же времени
+	later := makeTestEvent()
+	later.UserID = event.UserID
+	later.Start = event.End.Add(time.Hour)
+	later.End = event.End.Add(2 * time.Hour)
+	require.NoError(t, st.CreateEvent(ctx, later))
+
+	event.End = event.End.Add(30 * time.Minute)
+	require.NoError(t, st.UpdateEvent(ctx, event.ID, event))
+	event.End = later.Start.Add(time.Minute)
+	require.ErrorIs(t, st.UpdateEvent(ctx, event.ID, event), storage.ErrDateBusy)
+
+	// Встык к следующему событию — можно
+	event.End = later.Start
+	require.NoError(t, st.UpdateEvent(ctx, event.ID, event))
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Storage {
+		return setupStorage(t)
+	})
+}
+
+func TestNotificationsAndDeleteOldEvents(t *testing.T) {
+	st := setupStorage(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	oldEvent := makeTestEvent()
+	oldEvent.Start = now.Add(-2 * time.Hour)
+	oldEvent.End = now.Add(-time.Hour)
+	newEvent := makeTestEvent()
+	newEvent.Start = now.Add(time.Hour)
+	newEvent.End = now.Add(2 * time.Hour)
+	newEvent.TimeBefore = 36 * time.Hour
+	require.NoError(t, st.CreateEvent(ctx, oldEvent))
+	require.NoError(t, st.CreateEvent(ctx, newEvent))
+
+	notifyAt := newEvent.Start.Add(-newEvent.TimeBefore)
+	notifications, err := st.GetNotifications(ctx, notifyAt.Add(-time.Second), 2*time.Second)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	require.Equal(t, newEvent.ID, notifications[0].ID)
+	require.Equal(t, newEvent.UserID, notifications[0].UserID)
+	require.True(t, newEvent.Start.Equal(notifications[0].Start))
+
+	require.NoError(t, st.DeleteOldEvents(ctx, now))
+	events, err := st.GetEventsMonth(ctx, now.Add(-3*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, newEvent.ID, events[0].ID)
+}
+
+func TestTimesOutOfRange(t *testing.T) {
+	st := setupStorage(t)
+	ctx := context.Background()
+
+	// После 2262-04-11 наносекунды Unix не помещаются в int64
+	farFuture := time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC)
+	event := makeTestEvent()
+	event.End = farFuture
+	var invalid *storage.ErrInvalidEvent
+	require.ErrorAs(t, st.CreateEvent(ctx, event), &invalid)
+	require.Equal(t, "end", invalid.Field)
+
+	event = makeTestEvent()
+	require.NoError(t, st.CreateEvent(ctx, event))
+	updated := event
+	updated.Start = farFuture
+	updated.End = farFuture.Add(time.Hour)
+	require.ErrorAs(t, st.UpdateEvent(ctx, event.ID, updated), &invalid)
+	require.Equal(t, "start", invalid.Field)
+
+	// Границы выборок за пределами диапазона ограничиваются, а не переполняются
+	events, err := st.GetEventsMonth(ctx, farFuture)
+	require.NoError(t, err)
+	require.Empty(t, events)
+	notifications, err := st.GetNotifications(ctx, event.Start.Add(-time.Hour), time.Duration(math.MaxInt64))
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+}
+
+func TestDataSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.db")
+	ctx := context.Background()
+	event := makeTestEvent()
+
+	for i := 0; i < 2; i++ {
+		st := sqlitestorage.New(logger.New("info", os.Stdout, false), path)
+		require.NoError(t, st.Connect(ctx))
+		require.NoError(t, st.Migrate(ctx))
+		if i == 0 {
+			require.NoError(t, st.CreateEvent(ctx, event))
+		} else {
+			events, err := st.GetEventsDay(ctx, event.Start)
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+		}
+		require.NoError(t, st.Close())
+	}
+}
@@ -0,0 +1,338 @@
+// Package storagetest provides the conformance suite every event storage
+// backend runs, so that all of them follow the same rules:
+//
+//   - an event occupies [Start, End): events of one user may not overlap but
+//     may follow each other back to back, events of different users may overlap;
+//   - GetEventsDay, GetEventsWeek and GetEventsMonth return events intersecting
+//     [start, start+1, 7 or 30 days);
+//   - GetNotifications returns events notified within [currTime, currTime+tick),
+//     the notification time being Start-TimeBefore;
+//   - DeleteOldEvents removes events that ended before the given time;
+//   - a repeated ID yields storage.ErrIDRepeated, a missing one storage.ErrIDNotExist
+//     and an occupied time storage.ErrDateBusy.
+package storagetest
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// Storage is the behaviour checked by the suite: the union of the storages
+// required by the calendar and the scheduler.
+type Storage interface {
+	CreateEvent(context.Context, storage.Event) error
+	UpdateEvent(context.Context, uuid.UUID, storage.Event) error
+	DeleteEvent(context.Context, uuid.UUID) (storage.Event, error)
+	GetEventsDay(context.Context, time.Time) ([]storage.Event, error)
+	GetEventsWeek(context.Context, time.Time) ([]storage.Event, error)
+	GetEventsMonth(context.Context, time.Time) ([]storage.Event, error)
+	GetNotifications(ctx context.Context, start time.Time, tick time.Duration) ([]storage.Notification, error)
+	DeleteOldEvents(ctx context.Context, before time.Time) error
+}
+
+// base is the start of the periods used by the suite; it lies in the future,
+// as the API accepts only future events, and is whole seconds for every backend.
+var base = time.Date(2035, 3, 1, 9, 0, 0, 0, time.UTC)
+
+// Run runs the suite; newStorage returns an empty storage for each subtest.
+func Run(t *testing.T, newStorage func(t *testing.T) Storage) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, s Storage)
+	}{
+		{name: "CreateAndGet", test: testCreateAndGet},
+		{name: "RepeatedID", test: testRepeatedID},
+		{name: "Update", test: testUpdate},
+		{name: "MissingID", test: testMissingID},
+		{name: "Delete", test: testDelete},
+		{name: "OverlapPerUser", test: testOverlapPerUser},
+		{name: "BackToBack", test: testBackToBack},
+		{name: "UpdateOverlap", test: testUpdateOverlap},
+		{name: "PeriodBoundaries", test: testPeriodBoundaries},
+		{name: "Notifications", test: testNotifications},
+		{name: "DeleteOldEvents", test: testDeleteOldEvents},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t))
+		})
+	}
+}
+
+// newEvent returns an event of the user lasting [start, end).
+func newEvent(user uuid.UUID, start, end time.Time) storage.Event {
+	return storage.Event{
+		ID:          uuid.New(),
+		Title:       "Event",
+		Description: "Description",
+		UserID:      user,
+		Start:       start,
+		End:         end,
+		TimeBefore:  15 * time.Minute,
+	}
+}
+
+func create(t *testing.T, s Storage, events ...storage.Event) {
+	t.Helper()
+	for _, e := range events {
+		require.NoError(t, s.CreateEvent(context.Background(), e))
+	}
+}
+
+func ids[T any](items []T, id func(T) uuid.UUID) []uuid.UUID {
+	res := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		res = append(res, id(item))
+	}
+	return res
+}
+
+func eventIDs(events []storage.Event) []uuid.UUID {
+	return ids(events, func(e storage.Event) uuid.UUID { return e.ID })
+}
+
+// requireEvents checks that the period of get starting at start holds exactly want.
+func requireEvents(
+	t *testing.T,
+	get func(context.Context, time.Time) ([]storage.Event, error),
+	start time.Time,
+	want ...storage.Event,
+) {
+	t.Helper()
+	events, err := get(context.Background(), start)
+	require.NoError(t, err)
+	require.ElementsMatch(t, eventIDs(want), eventIDs(events))
+}
+
+// requireEvent checks that the stored event equals want.
+func requireEvent(t *testing.T, s Storage, want storage.Event) {
+	t.Helper()
+	events, err := s.GetEventsMonth(context.Background(), want.Start)
+	require.NoError(t, err)
+	for _, got := range events {
+		if got.ID == want.ID {
+			requireSameEvent(t, want, got)
+			return
+		}
+	}
+	require.Failf(t, "event not found", "event %s", want.ID)
+}
+
+// requireSameEvent checks that got has the fields of want.
+func requireSameEvent(t *testing.T, want, got storage.Event) {
+	t.Helper()
+	require.Equal(t, want.ID, got.ID)
+	require.Equal(t, want.UserID, got.UserID)
+	require.Equal(t, want.Title, got.Title)
+	require.Equal(t, want.Description, got.Description)
+	require.True(t, want.Start.Equal(got.Start), "start: want %s, got %s", want.Start, got.Start)
+	require.True(t, want.End.Equal(got.End), "end: want %s, got %s", want.End, got.End)
+	require.Equal(t, want.TimeBefore, got.TimeBefore)
+}
+
+func testCreateAndGet(t *testing.T, s Storage) {
+	e := newEvent(uuid.New(), base, base.Add(time.Hour))
+	e.TimeBefore = 36*time.Hour + 30*time.Second
+	create(t, s, e)
+	requireEvent(t, s, e)
+}
+
+func testRepeatedID(t *testing.T, s Storage) {
+	ctx := context.Background()
+	e := newEvent(uuid.New(), base, base.Add(time.Hour))
+	create(t, s, e)
+
+	require.ErrorIs(t, s.CreateEvent(ctx, e), storage.ErrIDRepeated)
+
+	other := newEvent(uuid.New(), base.Add(24*time.Hour), base.Add(25*time.Hour))
+	other.ID = e.ID
+	require.ErrorIs(t, s.CreateEvent(ctx, other), storage.ErrIDRepeated)
+	requireEvent(t, s, e)
+}
+
+func testUpdate(t *testing.T, s Storage) {
+	e := newEvent(uuid.New(), base, base.Add(time.Hour))
+	create(t, s, e)
+
+	// ID события задаёт аргумент, а не поле нового значения
+	updated := newEvent(uuid.New(), base.Add(2*time.Hour), base.Add(4*time.Hour))
+	updated.Title = "Updated"
+	updated.Description = "Updated description"
+	updated.TimeBefore = time.Hour
+	require.NoError(t, s.UpdateEvent(context.Background(), e.ID, updated))
+
+	updated.ID = e.ID
+	requireEvent(t, s, updated)
+	requireEvents(t, s.GetEventsMonth, base, updated)
+
+	// Прежнее время владельца освобождается
+	create(t, s, newEvent(e.UserID, e.Start, e.End))
+}
+
+func testMissingID(t *testing.T, s Storage) {
+	ctx := context.Background()
+	e := newEvent(uuid.New(), base, base.Add(time.Hour))
+	require.ErrorIs(t, s.UpdateEvent(ctx, e.ID, e), storage.ErrIDNotExist)
+	_, err := s.DeleteEvent(ctx, e.ID)
+	require.ErrorIs(t, err, storage.ErrIDNotExist)
+	requireEvents(t, s.GetEventsMonth, base)
+}
+
+func testDelete(t *testing.T, s Storage) {
+	ctx := context.Background()
+	e := newEvent(uuid.New(), base, base.Add(time.Hour))
+	kept := newEvent(e.UserID, base.Add(2*time.Hour), base.Add(3*time.Hour))
+	create(t, s, e, kept)
+
+	deleted, err := s.DeleteEvent(ctx, e.ID)
+	require.NoError(t, err)
+	requireSameEvent(t, e, deleted)
+	_, err = s.DeleteEvent(ctx, e.ID)
+	require.ErrorIs(t, err, storage.ErrIDNotExist)
+	requireEvents(t, s.GetEventsDay, base, kept)
+
+	// Время удалённого события снова свободно
+	create(t, s, newEvent(e.UserID, e.Start, e.End))
+}
+
+func testOverlapPerUser(t *testing.T, s Storage) {
+	ctx := context.Background()
+	user := uuid.New()
+	e := newEvent(user, base, base.Add(2*time.Hour))
+	create(t, s, e)
+
+	for name, overlapping := range map[string]storage.Event{
+		"same time":  newEvent(user, e.Start, e.End),
+		"start":      newEvent(user, base.Add(-time.Hour), base.Add(time.Hour)),
+		"end":        newEvent(user, base.Add(time.Hour), base.Add(3*time.Hour)),
+		"inside":     newEvent(user, base.Add(30*time.Minute), base.Add(90*time.Minute)),
+		"containing": newEvent(user, base.Add(-time.Hour), base.Add(3*time.Hour)),
+	} {
+		require.ErrorIs(t, s.CreateEvent(ctx, overlapping), storage.ErrDateBusy, name)
+	}
+
+	// Другой пользователь может занять то же время
+	create(t, s, newEvent(uuid.New(), e.Start, e.End))
+}
+
+func testBackToBack(t *testing.T, s Storage) {
+	user := uuid.New()
+	e := newEvent(user, base, base.Add(time.Hour))
+	before := newEvent(user, base.Add(-time.Hour), base)
+	after := newEvent(user, base.Add(time.Hour), base.Add(2*time.Hour))
+	create(t, s, e, before, after)
+	requireEvents(t, s.GetEventsDay, base.Add(-time.Hour), before, e, after)
+}
+
+func testUpdateOverlap(t *testing.T, s Storage) {
+	ctx := context.Background()
+	user := uuid.New()
+	e := newEvent(user, base, base.Add(time.Hour))
+	next := newEvent(user, base.Add(2*time.Hour), base.Add(3*time.Hour))
+	create(t, s, e, next)
+
+	// Событие не конфликтует само с собой
+	moved := e
+	moved.End = base.Add(2 * time.Hour)
+	require.NoError(t, s.UpdateEvent(ctx, e.ID, moved))
+	requireEvent(t, s, moved)
+
+	conflicting := e
+	conflicting.End = base.Add(150 * time.Minute)
+	require.ErrorIs(t, s.UpdateEvent(ctx, e.ID, conflicting), storage.ErrDateBusy)
+	requireEvent(t, s, moved)
+
+	// При смене владельца проверяется время нового владельца
+	other := newEvent(uuid.New(), base, base.Add(time.Hour))
+	create(t, s, other)
+	reassigned := moved
+	reassigned.UserID = other.UserID
+	require.ErrorIs(t, s.UpdateEvent(ctx, e.ID, reassigned), storage.ErrDateBusy)
+
+	reassigned.Start = base.Add(time.Hour)
+	require.NoError(t, s.UpdateEvent(ctx, e.ID, reassigned))
+	requireEvent(t, s, reassigned)
+	// Прежний владелец освободил время
+	create(t, s, newEvent(user, base, base.Add(2*time.Hour)))
+}
+
+func testPeriodBoundaries(t *testing.T, s Storage) {
+	user := uuid.New()
+	endsAtStart := newEvent(user, base.Add(-time.Hour), base)
+	crossesStart := newEvent(uuid.New(), base.Add(-time.Hour), base.Add(time.Minute))
+	atStart := newEvent(user, base, base.Add(time.Hour))
+	spanning := newEvent(uuid.New(), base.Add(-24*time.Hour), base.Add(48*time.Hour))
+	create(t, s, endsAtStart, crossesStart, atStart, spanning)
+
+	day := base.Add(24 * time.Hour)
+	lastInDay := newEvent(user, day.Add(-time.Minute), day)
+	startsAtDayEnd := newEvent(user, day, day.Add(time.Hour))
+	week := base.Add(7 * 24 * time.Hour)
+	lastInWeek := newEvent(user, week.Add(-time.Hour), week.Add(-time.Minute))
+	startsAtWeekEnd := newEvent(user, week, week.Add(time.Hour))
+	month := base.Add(30 * 24 * time.Hour)
+	lastInMonth := newEvent(user, month.Add(-time.Hour), month.Add(time.Hour))
+	startsAtMonthEnd := newEvent(uuid.New(), month, month.Add(time.Hour))
+	create(t, s, lastInDay, startsAtDayEnd, lastInWeek, startsAtWeekEnd, lastInMonth, startsAtMonthEnd)
+
+	inDay := []storage.Event{crossesStart, atStart, spanning, lastInDay}
+	requireEvents(t, s.GetEventsDay, base, inDay...)
+	inWeek := slices.Concat(inDay, []storage.Event{startsAtDayEnd, lastInWeek})
+	requireEvents(t, s.GetEventsWeek, base, inWeek...)
+	inMonth := slices.Concat(inWeek, []storage.Event{startsAtWeekEnd, lastInMonth})
+	requireEvents(t, s.GetEventsMonth, base, inMonth...)
+}
+
+func testNotifications(t *testing.T, s Storage) {
+	ctx := context.Background()
+	tick := time.Minute
+
+	first := newEvent(uuid.New(), base.Add(time.Hour), base.Add(2*time.Hour))
+	first.TimeBefore = time.Hour
+	last := newEvent(uuid.New(), base.Add(time.Hour), base.Add(2*time.Hour))
+	last.TimeBefore = time.Hour - tick + time.Second
+	next := newEvent(uuid.New(), base.Add(time.Hour), base.Add(2*time.Hour))
+	next.TimeBefore = time.Hour - tick
+	atStart := newEvent(uuid.New(), base.Add(30*time.Second), base.Add(time.Hour))
+	atStart.TimeBefore = 0
+	create(t, s, first, last, next, atStart)
+
+	notifications, err := s.GetNotifications(ctx, base, tick)
+	require.NoError(t, err)
+	require.ElementsMatch(t, eventIDs([]storage.Event{first, last, atStart}),
+		ids(notifications, func(n storage.Notification) uuid.UUID { return n.ID }))
+	for _, n := range notifications {
+		if n.ID == first.ID {
+			require.Equal(t, first.Title, n.Title)
+			require.Equal(t, first.UserID, n.UserID)
+			require.True(t, first.Start.Equal(n.Start))
+		}
+	}
+
+	// Следующий тик получает событие на своей границе, но не повторяет предыдущие
+	notifications, err = s.GetNotifications(ctx, base.Add(tick), tick)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{next.ID}, ids(notifications, func(n storage.Notification) uuid.UUID { return n.ID }))
+}
+
+func testDeleteOldEvents(t *testing.T, s Storage) {
+	user := uuid.New()
+	ended := newEvent(user, base, base.Add(time.Hour))
+	endsAtCutoff := newEvent(user, base.Add(time.Hour), base.Add(2*time.Hour))
+	running := newEvent(uuid.New(), base.Add(time.Hour), base.Add(3*time.Hour))
+	create(t, s, ended, endsAtCutoff, running)
+
+	require.NoError(t, s.DeleteOldEvents(context.Background(), base.Add(2*time.Hour)))
+	requireEvents(t, s.GetEventsDay, base, endsAtCutoff, running)
+
+	// Время удалённого события снова свободно
+	create(t, s, newEvent(user, ended.Start, ended.End))
+}
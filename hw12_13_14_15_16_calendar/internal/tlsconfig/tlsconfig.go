@@ -0,0 +1,252 @@
+// Package tlsconfig настраивает TLS и mutual TLS для серверов календаря
+// и перечитывает сертификаты при изменении файлов без перезапуска.
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+)
+
+// defaultReloadInterval is used when Config.ReloadInterval is not set.
+const defaultReloadInterval = 10 * time.Second
+
+// Client authentication modes.
+const (
+	// ClientAuthRequire rejects clients without a certificate signed by the client CA.
+	ClientAuthRequire = "require"
+	// ClientAuthOptional verifies a client certificate only if one is presented,
+	// e.g. to let probes without certificates through.
+	ClientAuthOptional = "optional"
+)
+
+var (
+	// ErrNoKeyPair is returned when only one of the certificate and the key is set.
+	ErrNoKeyPair = errors.New("both cert_file and key_file must be set")
+	// ErrInvalidClientAuth is returned for an unknown client_auth mode.
+	ErrInvalidClientAuth = errors.New("client_auth must be \"require\" or \"optional\"")
+	// ErrNoClientCA is returned when client_ca_file contains no certificates.
+	ErrNoClientCA = errors.New("no certificates found in client CA file")
+)
+
+// Config defines TLS of a listener. Empty CertFile and KeyFile disable TLS.
+type Config struct {
+	CertFile string `toml:"cert_file" env:"CERT_FILE"`
+	KeyFile  string `toml:"key_file" env:"KEY_FILE"`
+	// ClientCAFile enables mutual TLS: client certificates are verified against these CAs.
+	ClientCAFile string `toml:"client_ca_file" env:"CLIENT_CA_FILE"`
+	// ClientAuth is "require" (default) or "optional".
+	ClientAuth string `toml:"client_auth" env:"CLIENT_AUTH"`
+	// ReloadInterval is how often the files are checked for changes.
+	ReloadInterval time.Duration `toml:"reload_interval" env:"RELOAD_INTERVAL"`
+}
+
+// Enabled reports whether TLS is configured.
+func (c Config) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// Validate checks the settings without reading the files.
+func (c Config) Validate() error {
+	if !c.Enabled() {
+		if c.ClientCAFile != "" {
+			return errors.New("client_ca_file: requires cert_file and key_file")
+		}
+		return nil
+	}
+	if c.CertFile == "" {
+		return fmt.Errorf("cert_file: %w", ErrNoKeyPair)
+	}
+	if c.KeyFile == "" {
+		return fmt.Errorf("key_file: %w", ErrNoKeyPair)
+	}
+	if c.ClientAuth != "" && c.ClientCAFile == "" {
+		return errors.New("client_auth: requires client_ca_file")
+	}
+	if _, err := c.clientAuth(); err != nil {
+		return err
+	}
+	if c.ReloadInterval < 0 {
+		return fmt.Errorf("reload_interval: must not be negative, got %s", c.ReloadInterval)
+	}
+	return nil
+}
+
+func (c Config) clientAuth() (tls.ClientAuthType, error) {
+	if c.ClientCAFile == "" {
+		return tls.NoClientCert, nil
+	}
+	switch c.ClientAuth {
+	case "", ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert, nil
+	case ClientAuthOptional:
+		return tls.VerifyClientCertIfGiven, nil
+	default:
+		return tls.NoClientCert, ErrInvalidClientAuth
+	}
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	modTime int64
+	size    int64
+}
+
+// Reloader keeps the certificate and the client CAs loaded from files and
+// replaces them when the files change. Connections already established keep
+// the certificate they were negotiated with.
+type Reloader struct {
+	cfg        Config
+	clientAuth tls.ClientAuthType
+	logger     *slog.Logger
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	stamps    map[string]fileStamp
+}
+
+func (r *Reloader) setLogCompMeth(ctx context.Context, method string) context.Context {
+	ctx = logger.WithLogComponent(ctx, "tls")
+	return logger.WithLogMethod(ctx, method)
+}
+
+// NewReloader loads the files of cfg; it fails if they are missing or invalid.
+func NewReloader(cfg Config, logger *slog.Logger) (*Reloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, ErrNoKeyPair
+	}
+	clientAuth, err := cfg.clientAuth()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ReloadInterval <= 0 {
+		cfg.ReloadInterval = defaultReloadInterval
+	}
+
+	r := &Reloader{cfg: cfg, clientAuth: clientAuth, logger: logger}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig returns a server configuration that always uses the current certificate and client CAs.
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*r.cert},
+				ClientAuth:   r.clientAuth,
+				ClientCAs:    r.clientCAs,
+				// Сервер gRPC требует ALPN h2, HTTP-сервер дополняет список сам
+				NextProtos: []string{"h2", "http/1.1"},
+			}, nil
+		},
+	}
+}
+
+// Reload reads the files if any of them changed since the last load and
+// reports whether the certificates were replaced. On error the previous
+// certificates stay in use.
+func (r *Reloader) Reload() (bool, error) {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+
+	stamps := make(map[string]fileStamp, len(files))
+	for _, name := range files {
+		info, err := os.Stat(name)
+		if err != nil {
+			return false, fmt.Errorf("stat %s: %w", name, err)
+		}
+		stamps[name] = fileStamp{modTime: info.ModTime().UnixNano(), size: info.Size()}
+	}
+
+	r.mu.RLock()
+	changed := !maps.Equal(stamps, r.stamps)
+	r.mu.RUnlock()
+	if !changed {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return false, fmt.Errorf("load key pair: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return false, fmt.Errorf("read client CA: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return false, ErrNoClientCA
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.clientCAs, r.stamps = &cert, clientCAs, stamps
+	r.mu.Unlock()
+	return true, nil
+}
+
+// Run checks the files every ReloadInterval until ctx is done.
+func (r *Reloader) Run(ctx context.Context) {
+	ctx = r.setLogCompMeth(ctx, "Run")
+
+	ticker := time.NewTicker(r.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			if err != nil {
+				// Файлы могут быть записаны не полностью: попробуем на следующей проверке
+				r.logger.ErrorContext(ctx, "failed to reload certificates, keeping previous ones", "error", err)
+				continue
+			}
+			if reloaded {
+				r.logger.InfoContext(ctx, "certificates reloaded", "cert", r.cfg.CertFile)
+			}
+		}
+	}
+}
+
+// Identity returns the identity of the client certificate verified during the
+// handshake: its first URI SAN (e.g. a SPIFFE ID), else the subject common name,
+// else the first DNS SAN. An empty string means the client is not authenticated.
+func Identity(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	leaf := state.VerifiedChains[0][0]
+	switch {
+	case len(leaf.URIs) > 0:
+		return leaf.URIs[0].String()
+	case leaf.Subject.CommonName != "":
+		return leaf.Subject.CommonName
+	case len(leaf.DNSNames) > 0:
+		return leaf.DNSNames[0]
+	}
+	return ""
+}
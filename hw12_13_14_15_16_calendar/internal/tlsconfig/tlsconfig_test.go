@@ -0,0 +1,239 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// issue creates a certificate signed by parent; a nil parent makes it a self-signed CA.
+func issue(t *testing.T, serial int64, tmpl x509.Certificate, parent *testCert) testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl.SerialNumber = big.NewInt(serial)
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	signer, signerKey := &tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return testCert{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (c testCert) keyPEM(t *testing.T) []byte {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func (c testCert) keyPair(t *testing.T) tls.Certificate {
+	t.Helper()
+	pair, err := tls.X509KeyPair(c.pem, c.keyPEM(t))
+	require.NoError(t, err)
+	return pair
+}
+
+// writeFiles stores the certificate and the key, moving their modification time to mtime.
+func (c testCert) writeFiles(t *testing.T, certFile, keyFile string, mtime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(certFile, c.pem, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, c.keyPEM(t), 0o600))
+	require.NoError(t, os.Chtimes(certFile, mtime, mtime))
+	require.NoError(t, os.Chtimes(keyFile, mtime, mtime))
+}
+
+// handshake connects a client to a server over an in-memory pipe.
+func handshake(t *testing.T, server, client *tls.Config) (tls.ConnectionState, error) {
+	t.Helper()
+	sc, cc := net.Pipe()
+	defer sc.Close()
+	defer cc.Close()
+
+	srv := tls.Server(sc, server)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.Handshake()
+		// Клиент TLS 1.3 узнаёт об отказе только при чтении
+		_, _ = srv.Write([]byte{0})
+	}()
+	cl := tls.Client(cc, client)
+	err := cl.Handshake()
+	if err == nil {
+		_, err = cl.Read(make([]byte, 1))
+	}
+	if srvErr := <-errc; srvErr != nil {
+		return tls.ConnectionState{}, srvErr
+	}
+	return srv.ConnectionState(), err
+}
+
+type fixture struct {
+	ca, server, client testCert
+	cfg                Config
+	roots              *x509.CertPool
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	dir := t.TempDir()
+	ca := issue(t, 1, x509.Certificate{Subject: pkix.Name{CommonName: "calendar CA"}}, nil)
+	server := issue(t, 2, x509.Certificate{
+		Subject:     pkix.Name{CommonName: "calendar"},
+		DNSNames:    []string{"calendar"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, &ca)
+	spiffe, err := url.Parse("spiffe://calendar/scheduler")
+	require.NoError(t, err)
+	client := issue(t, 3, x509.Certificate{
+		Subject:     pkix.Name{CommonName: "scheduler"},
+		URIs:        []*url.URL{spiffe},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, &ca)
+
+	cfg := Config{
+		CertFile:     filepath.Join(dir, "tls.crt"),
+		KeyFile:      filepath.Join(dir, "tls.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+	}
+	server.writeFiles(t, cfg.CertFile, cfg.KeyFile, time.Now())
+	require.NoError(t, os.WriteFile(cfg.ClientCAFile, ca.pem, 0o600))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	return fixture{ca: ca, server: server, client: client, cfg: cfg, roots: roots}
+}
+
+func (f fixture) clientConfig() *tls.Config {
+	return &tls.Config{ServerName: "calendar", RootCAs: f.roots, MinVersion: tls.VersionTLS12}
+}
+
+func TestReloader_MutualTLS(t *testing.T) {
+	f := newFixture(t)
+	lg := logger.New("debug", os.Stdout, false)
+
+	r, err := NewReloader(f.cfg, lg)
+	require.NoError(t, err)
+
+	withCert := f.clientConfig()
+	withCert.Certificates = []tls.Certificate{f.client.keyPair(t)}
+	state, err := handshake(t, r.TLSConfig(), withCert)
+	require.NoError(t, err)
+	require.Equal(t, "spiffe://calendar/scheduler", Identity(&state))
+
+	// Без клиентского сертификата соединение отклоняется
+	_, err = handshake(t, r.TLSConfig(), f.clientConfig())
+	require.Error(t, err)
+
+	// В режиме optional клиент без сертификата допускается, но не аутентифицирован
+	f.cfg.ClientAuth = ClientAuthOptional
+	r, err = NewReloader(f.cfg, lg)
+	require.NoError(t, err)
+	state, err = handshake(t, r.TLSConfig(), f.clientConfig())
+	require.NoError(t, err)
+	require.Empty(t, Identity(&state))
+}
+
+func TestReloader_Reload(t *testing.T) {
+	f := newFixture(t)
+	f.cfg.ClientCAFile = ""
+
+	r, err := NewReloader(f.cfg, logger.New("debug", os.Stdout, false))
+	require.NoError(t, err)
+
+	serverSerial := func() int64 {
+		t.Helper()
+		var serial int64
+		cfg := f.clientConfig()
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			serial = cs.PeerCertificates[0].SerialNumber.Int64()
+			return nil
+		}
+		_, err := handshake(t, r.TLSConfig(), cfg)
+		require.NoError(t, err)
+		return serial
+	}
+	require.Equal(t, int64(2), serverSerial())
+
+	reloaded, err := r.Reload()
+	require.NoError(t, err)
+	require.False(t, reloaded)
+
+	renewed := issue(t, 4, x509.Certificate{
+		Subject:  pkix.Name{CommonName: "calendar"},
+		DNSNames: []string{"calendar"},
+	}, &f.ca)
+	renewed.writeFiles(t, f.cfg.CertFile, f.cfg.KeyFile, time.Now().Add(time.Minute))
+	reloaded, err = r.Reload()
+	require.NoError(t, err)
+	require.True(t, reloaded)
+	require.Equal(t, int64(4), serverSerial())
+
+	// Повреждённый файл не заменяет рабочий сертификат
+	require.NoError(t, os.WriteFile(f.cfg.CertFile, []byte("garbage"), 0o600))
+	_, err = r.Reload()
+	require.Error(t, err)
+	require.Equal(t, int64(4), serverSerial())
+}
+
+func TestNewReloader_Errors(t *testing.T) {
+	f := newFixture(t)
+	lg := logger.New("debug", os.Stdout, false)
+
+	_, err := NewReloader(Config{CertFile: f.cfg.CertFile}, lg)
+	require.ErrorIs(t, err, ErrNoKeyPair)
+
+	cfg := f.cfg
+	cfg.ClientAuth = "sometimes"
+	_, err = NewReloader(cfg, lg)
+	require.ErrorIs(t, err, ErrInvalidClientAuth)
+
+	cfg = f.cfg
+	cfg.ClientCAFile = f.cfg.KeyFile
+	_, err = NewReloader(cfg, lg)
+	require.ErrorIs(t, err, ErrNoClientCA)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	require.NoError(t, Config{}.Validate())
+	require.NoError(t, Config{CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "ca.crt"}.Validate())
+
+	require.ErrorIs(t, Config{CertFile: "tls.crt"}.Validate(), ErrNoKeyPair)
+	require.EqualError(t, Config{ClientCAFile: "ca.crt"}.Validate(), "client_ca_file: requires cert_file and key_file")
+	require.EqualError(t, Config{CertFile: "tls.crt", KeyFile: "tls.key", ClientAuth: ClientAuthOptional}.Validate(),
+		"client_auth: requires client_ca_file")
+	require.ErrorIs(t, Config{
+		CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "ca.crt", ClientAuth: "sometimes",
+	}.Validate(), ErrInvalidClientAuth)
+}
@@ -0,0 +1,148 @@
+// Package tracing настраивает OpenTelemetry и переносит контекст трассировки
+// через HTTP, gRPC и заголовки сообщений брокера.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Supported exporters.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// DefaultSampleRatio records every trace when sample_ratio is not set.
+const DefaultSampleRatio = 1.0
+
+// ErrUnknownExporter is returned for unsupported exporter names.
+var ErrUnknownExporter = errors.New("unknown trace exporter")
+
+// instrumentationName names tracers created by this module.
+const instrumentationName = "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar"
+
+// Config defines where spans are exported.
+type Config struct {
+	// Exporter is "none" (default), "stdout" or "otlp".
+	Exporter string `toml:"exporter" env:"EXPORTER"`
+	// Endpoint is the OTLP gRPC collector address, e.g. "otel-collector:4317".
+	Endpoint string `toml:"endpoint" env:"ENDPOINT"`
+	Insecure bool   `toml:"insecure" env:"INSECURE"`
+	// SampleRatio is the share of traces started by this service that are recorded,
+	// 0 records none of them.
+	SampleRatio float64 `toml:"sample_ratio" env:"SAMPLE_RATIO"`
+}
+
+// Validate checks that the exporter is known and the sample ratio is a share.
+func (c Config) Validate() error {
+	switch c.Exporter {
+	case "", ExporterNone, ExporterStdout:
+	case ExporterOTLP:
+		if c.Endpoint == "" {
+			return errors.New("endpoint: required by the otlp exporter")
+		}
+	default:
+		return fmt.Errorf("exporter: %w %q, want none, stdout or otlp", ErrUnknownExporter, c.Exporter)
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("sample_ratio: must be between 0 and 1, got %v", c.SampleRatio)
+	}
+	return nil
+}
+
+// Setup installs the global tracer provider and propagator. The returned function
+// flushes and stops the exporter.
+func Setup(ctx context.Context, cfg Config, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exp, err := stdouttrace.New()
+		if err != nil {
+			return nil, fmt.Errorf("stdout exporter: %w", err)
+		}
+		exporter = exp
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exp, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("otlp exporter: %w", err)
+		}
+		exporter = exp
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownExporter, cfg.Exporter)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
+
+// Tracer returns the tracer of the module from the global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Inject writes the trace context of ctx into message headers.
+func Inject(ctx context.Context, headers map[string]interface{}) {
+	otel.GetTextMapPropagator().Inject(ctx, HeadersCarrier(headers))
+}
+
+// Extract returns ctx with the trace context read from message headers.
+func Extract(ctx context.Context, headers map[string]interface{}) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, HeadersCarrier(headers))
+}
+
+// HeadersCarrier adapts message headers to propagation.TextMapCarrier.
+type HeadersCarrier map[string]interface{}
+
+// Get returns the header value if it is a string.
+func (c HeadersCarrier) Get(key string) string {
+	switch v := c[key].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return ""
+	}
+}
+
+// Set stores the header value.
+func (c HeadersCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys lists header names.
+func (c HeadersCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
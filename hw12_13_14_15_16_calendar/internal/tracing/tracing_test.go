@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInjectExtract(t *testing.T) {
+	_, err := Setup(context.Background(), Config{}, "test")
+	require.NoError(t, err)
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	headers := map[string]interface{}{"x-schema-version": int32(1)}
+	Inject(ctx, headers)
+	require.Contains(t, headers, "traceparent")
+
+	got := trace.SpanContextFromContext(Extract(context.Background(), headers))
+	require.Equal(t, sc.TraceID(), got.TraceID())
+	require.Equal(t, sc.SpanID(), got.SpanID())
+	require.True(t, got.IsRemote())
+
+	// AMQP может вернуть строковый заголовок как []byte
+	headers["traceparent"] = []byte(headers["traceparent"].(string))
+	got = trace.SpanContextFromContext(Extract(context.Background(), headers))
+	require.Equal(t, sc.TraceID(), got.TraceID())
+}
+
+func TestSetup_ZeroSampleRatio(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{Exporter: ExporterStdout, SampleRatio: 0}, "test")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, shutdown(context.Background())) }()
+
+	_, span := Tracer().Start(context.Background(), "op")
+	defer span.End()
+	require.False(t, span.SpanContext().IsSampled())
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), Config{Exporter: "jaeger"}, "test")
+	require.ErrorIs(t, err, ErrUnknownExporter)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	require.NoError(t, Config{}.Validate())
+	require.NoError(t, Config{Exporter: ExporterOTLP, Endpoint: "collector:4317", SampleRatio: 0.5}.Validate())
+	require.ErrorIs(t, Config{Exporter: "jaeger"}.Validate(), ErrUnknownExporter)
+	require.EqualError(t, Config{Exporter: ExporterOTLP}.Validate(), "endpoint: required by the otlp exporter")
+	require.EqualError(t, Config{SampleRatio: 2}.Validate(), "sample_ratio: must be between 0 and 1, got 2")
+}
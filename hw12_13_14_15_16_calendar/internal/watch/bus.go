@@ -0,0 +1,219 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidToken means the resume token was not issued by this bus.
+	ErrInvalidToken = errors.New("invalid resume token")
+	// ErrTokenExpired means changes after the resume token are no longer kept.
+	ErrTokenExpired = errors.New("resume token expired")
+)
+
+// ChangeType tells what happened to an event.
+type ChangeType string
+
+// Типы изменений событий.
+const (
+	Created ChangeType = "created"
+	Updated ChangeType = "updated"
+	Deleted ChangeType = "deleted"
+)
+
+// Change is a modification of an event published on the Bus.
+type Change struct {
+	// Token allows to resume watching right after this change.
+	Token string
+	Type  ChangeType
+	// Event holds the new state of the event, or the removed event for Deleted.
+	Event storage.Event
+	Time  time.Time
+
+	seq uint64
+}
+
+// Filter selects changes delivered to a subscriber. Zero fields match anything.
+type Filter struct {
+	UserID uuid.UUID
+	From   time.Time
+	To     time.Time
+}
+
+// Match reports whether the change passes the filter.
+func (f Filter) Match(c Change) bool {
+	e := c.Event
+	if f.UserID != uuid.Nil && e.UserID != f.UserID {
+		return false
+	}
+	if !f.From.IsZero() && e.End.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && e.Start.After(f.To) {
+		return false
+	}
+	return true
+}
+
+type subscriber struct {
+	ch     chan Change
+	filter Filter
+}
+
+// Bus fans out event changes to subscribers and keeps the latest changes
+// so that a subscriber can resume after reconnect.
+type Bus struct {
+	mu sync.Mutex
+	// epoch отличает токены разных запусков процесса
+	epoch   string
+	seq     uint64
+	journal []Change
+	size    int
+	buffer  int
+	subs    map[*subscriber]struct{}
+	closed  bool
+}
+
+// NewBus creates a bus keeping journalSize latest changes.
+// A subscriber falling behind by more than buffer changes is disconnected.
+func NewBus(journalSize, buffer int) *Bus {
+	return &Bus{
+		epoch:  strconv.FormatInt(time.Now().UnixNano(), 36),
+		size:   journalSize,
+		buffer: buffer,
+		subs:   make(map[*subscriber]struct{}),
+	}
+}
+
+// Publish records the change and delivers it to matching subscribers.
+func (b *Bus) Publish(typ ChangeType, event storage.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.seq++
+	c := Change{
+		Token: b.token(b.seq),
+		Type:  typ,
+		Event: event,
+		Time:  time.Now(),
+		seq:   b.seq,
+	}
+
+	b.journal = append(b.journal, c)
+	if len(b.journal) > b.size {
+		b.journal = b.journal[len(b.journal)-b.size:]
+	}
+
+	for sub := range b.subs {
+		if !sub.filter.Match(c) {
+			continue
+		}
+		select {
+		case sub.ch <- c:
+		default:
+			// Отстающий подписчик отключается и может продолжить с последнего токена
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe returns a channel of changes matching filter. If token is not
+// empty, the retained changes published after it are delivered first.
+// The channel is closed when ctx is done, the bus is closed or the
+// subscriber falls behind.
+func (b *Bus) Subscribe(ctx context.Context, filter Filter, token string) (<-chan Change, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Change
+	if token != "" {
+		after, err := b.parseToken(token)
+		if err != nil {
+			return nil, err
+		}
+		oldest := b.seq - uint64(len(b.journal))
+		if after < oldest {
+			return nil, ErrTokenExpired
+		}
+		for _, c := range b.journal[after-oldest:] {
+			if filter.Match(c) {
+				replay = append(replay, c)
+			}
+		}
+	}
+
+	sub := &subscriber{
+		ch:     make(chan Change, len(replay)+b.buffer),
+		filter: filter,
+	}
+	for _, c := range replay {
+		sub.ch <- c
+	}
+	if b.closed {
+		close(sub.ch)
+		return sub.ch, nil
+	}
+	b.subs[sub] = struct{}{}
+
+	context.AfterFunc(ctx, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(sub)
+	})
+	return sub.ch, nil
+}
+
+// Close disconnects all subscribers; later changes are not published.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
+
+func (b *Bus) remove(sub *subscriber) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	close(sub.ch)
+}
+
+func (b *Bus) token(seq uint64) string {
+	return b.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+func (b *Bus) parseToken(token string) (uint64, error) {
+	epoch, seqStr, ok := strings.Cut(token, "-")
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	// Токен прошлого запуска: изменения между запусками не сохранились
+	if epoch != b.epoch {
+		return 0, ErrTokenExpired
+	}
+	if seq > b.seq {
+		return 0, ErrInvalidToken
+	}
+	return seq, nil
+}
@@ -0,0 +1,127 @@
+package watch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, ch <-chan Change) Change {
+	t.Helper()
+	select {
+	case c, ok := <-ch:
+		require.True(t, ok, "channel closed")
+		return c
+	case <-time.After(time.Second):
+		t.Fatal("no change received")
+		return Change{}
+	}
+}
+
+func TestBus_PublishFilter(t *testing.T) {
+	b := NewBus(10, 10)
+	user := uuid.New()
+	ch, err := b.Subscribe(context.Background(), Filter{UserID: user}, "")
+	require.NoError(t, err)
+
+	other := storage.Event{ID: uuid.New(), UserID: uuid.New()}
+	mine := storage.Event{ID: uuid.New(), UserID: user}
+	b.Publish(Created, other)
+	b.Publish(Created, mine)
+	b.Publish(Deleted, other)
+	b.Publish(Deleted, mine)
+
+	c := receive(t, ch)
+	require.Equal(t, Created, c.Type)
+	require.Equal(t, mine.ID, c.Event.ID)
+
+	// Удаление чужого события не доставляется
+	c = receive(t, ch)
+	require.Equal(t, Deleted, c.Type)
+	require.Equal(t, mine.ID, c.Event.ID)
+	select {
+	case c := <-ch:
+		t.Fatalf("unexpected change %s of event %s", c.Type, c.Event.ID)
+	default:
+	}
+}
+
+func TestFilter_Window(t *testing.T) {
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := Filter{From: from, To: from.Add(24 * time.Hour)}
+
+	inside := Change{Type: Updated, Event: storage.Event{Start: from.Add(time.Hour), End: from.Add(2 * time.Hour)}}
+	before := Change{Type: Updated, Event: storage.Event{Start: from.Add(-2 * time.Hour), End: from.Add(-time.Hour)}}
+	after := Change{Type: Updated, Event: storage.Event{Start: from.Add(25 * time.Hour), End: from.Add(26 * time.Hour)}}
+
+	require.True(t, f.Match(inside))
+	require.False(t, f.Match(before))
+	require.False(t, f.Match(after))
+
+	after.Type = Deleted
+	require.False(t, f.Match(after))
+}
+
+func TestBus_Resume(t *testing.T) {
+	b := NewBus(2, 10)
+	events := make([]storage.Event, 4)
+	for i := range events {
+		events[i] = storage.Event{ID: uuid.New()}
+	}
+
+	ch, err := b.Subscribe(context.Background(), Filter{}, "")
+	require.NoError(t, err)
+	b.Publish(Created, events[0])
+	token := receive(t, ch).Token
+
+	b.Publish(Created, events[1])
+	b.Publish(Created, events[2])
+
+	// После token в журнале остались оба изменения
+	resumed, err := b.Subscribe(context.Background(), Filter{}, token)
+	require.NoError(t, err)
+	require.Equal(t, events[1].ID, receive(t, resumed).Event.ID)
+	require.Equal(t, events[2].ID, receive(t, resumed).Event.ID)
+
+	b.Publish(Created, events[3])
+	_, err = b.Subscribe(context.Background(), Filter{}, token)
+	require.ErrorIs(t, err, ErrTokenExpired)
+
+	_, err = b.Subscribe(context.Background(), Filter{}, "garbage")
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = NewBus(2, 10).Subscribe(context.Background(), Filter{}, token)
+	require.ErrorIs(t, err, ErrTokenExpired)
+}
+
+func TestBus_Disconnect(t *testing.T) {
+	b := NewBus(10, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled, err := b.Subscribe(ctx, Filter{}, "")
+	require.NoError(t, err)
+	cancel()
+	require.Eventually(t, func() bool {
+		_, ok := <-cancelled
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+
+	// Подписчик с переполненным буфером отключается
+	slow, err := b.Subscribe(context.Background(), Filter{}, "")
+	require.NoError(t, err)
+	b.Publish(Created, storage.Event{ID: uuid.New()})
+	b.Publish(Created, storage.Event{ID: uuid.New()})
+	receive(t, slow)
+	_, ok := <-slow
+	require.False(t, ok)
+
+	closed, err := b.Subscribe(context.Background(), Filter{}, "")
+	require.NoError(t, err)
+	b.Close()
+	_, ok = <-closed
+	require.False(t, ok)
+}
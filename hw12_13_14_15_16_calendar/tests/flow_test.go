@@ -0,0 +1,119 @@
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker"
+	memorybroker "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/broker/memory"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/rabbitmq/consumer"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/rabbitmq/producer"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/scheduler"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/sender"
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
+	memorystorage "github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// notifierMock fails the first fails deliveries and records the successful ones.
+type notifierMock struct {
+	mu        sync.Mutex
+	fails     int
+	attempts  int
+	delivered []storage.Notification
+}
+
+func (n *notifierMock) Notify(_ context.Context, _ string, notif storage.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.attempts++
+	if n.attempts <= n.fails {
+		return errors.New("temporary failure")
+	}
+	n.delivered = append(n.delivered, notif)
+	return nil
+}
+
+func (n *notifierMock) deliveries() []storage.Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]storage.Notification(nil), n.delivered...)
+}
+
+// TestSchedulerToSender runs the scheduler, the producer, the consumer and the sender
+// over the in-memory broker, so the notification flow is checked without RabbitMQ.
+func TestSchedulerToSender(t *testing.T) {
+	ctx := context.Background()
+	lg := logger.New("debug", io.Discard, false)
+
+	st := memorystorage.New(lg)
+	userID := uuid.New()
+	event := storage.Event{
+		ID:         uuid.New(),
+		Title:      "updated title",
+		Start:      time.Now().Add(90 * time.Minute),
+		End:        time.Now().Add(2 * time.Hour),
+		UserID:     userID,
+		TimeBefore: time.Hour,
+	}
+	require.NoError(t, st.CreateEvent(ctx, event))
+
+	b := memorybroker.New(lg)
+	defer b.Close()
+
+	consumerCfg := consumer.RabbitMQConf{
+		Exchange:     "calendar",
+		ExchangeType: broker.ExchangeDirect,
+		Queue:        "notifications",
+		BindingKey:   "notify",
+		MaxRetries:   3,
+		RetryDelay:   10 * time.Millisecond,
+		Workers:      2,
+	}
+	cons, err := consumer.NewConsumer(ctx, b, consumerCfg, lg)
+	require.NoError(t, err)
+
+	prod, err := producer.NewProducer(ctx, b, producer.RabbitMQConf{
+		Exchange:     consumerCfg.Exchange,
+		ExchangeType: consumerCfg.ExchangeType,
+		RoutingKey:   consumerCfg.BindingKey,
+		Reliable:     true,
+	}, lg)
+	require.NoError(t, err)
+
+	// Почта сначала недоступна, поэтому сообщение проходит через очередь повторов
+	email := &notifierMock{fails: 1}
+	webhook := &notifierMock{}
+	dispatcher := sender.NewDispatcher(lg, []sender.Preference{
+		{UserID: userID, Email: "user@example.com", Webhook: "http://example.com/hook"},
+	}, email, webhook)
+
+	done := make(chan error, 1)
+	go func() { done <- cons.Handle(ctx, dispatcher) }()
+
+	sched := scheduler.NewScheduler(lg, st, prod, scheduler.NotificationsConf{Tick: time.Hour, Encoding: "protobuf"})
+	sched.PublishNotifications(ctx)
+
+	require.Eventually(t, func() bool { return len(email.deliveries()) == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	require.NoError(t, cons.Shutdown(shutdownCtx))
+	require.NoError(t, <-done)
+	require.NoError(t, prod.Shutdown())
+
+	require.Equal(t, event.ID, email.deliveries()[0].ID)
+	require.Equal(t, event.Title, email.deliveries()[0].Title)
+	// Повтор не отправляет вебхук второй раз
+	require.Len(t, webhook.deliveries(), 1)
+
+	dlq, _, err := b.QueueLen(consumerCfg.DeadLetterQueue())
+	require.NoError(t, err)
+	require.Zero(t, dlq)
+}
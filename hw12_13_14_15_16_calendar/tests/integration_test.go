@@ -3,12 +3,11 @@
 package integration_test
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"net/http"
-	"os/exec"
 	"time"
 
+	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/api/httpclient"
 	"github.com/EvGesh4And/golang-homework/hw12_13_14_15_16_calendar/internal/storage"
 	"github.com/google/uuid"
 
@@ -16,7 +15,37 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+const calendarURL = "http://localhost:8888"
+
+func toClientEvent(e storage.Event) httpclient.Event {
+	timeBefore := int64(e.TimeBefore.Seconds())
+	return httpclient.Event{
+		Id:          &e.ID,
+		Title:       e.Title,
+		Description: &e.Description,
+		UserId:      e.UserID,
+		Start:       e.Start,
+		End:         e.End,
+		TimeBefore:  &timeBefore,
+	}
+}
+
+func titles(events *[]httpclient.Event) []string {
+	Expect(events).NotTo(BeNil())
+	res := make([]string, 0, len(*events))
+	for _, e := range *events {
+		res = append(res, e.Title)
+	}
+	return res
+}
+
 var _ = Describe("Calendar", func() {
+	client, err := httpclient.NewClientWithResponses(calendarURL)
+	if err != nil {
+		panic(err)
+	}
+	ctx := context.Background()
+
 	eventsGroup := []storage.Event{
 		{
 			ID:          uuid.New(),
@@ -41,20 +70,9 @@ var _ = Describe("Calendar", func() {
 		Context("create event", func() {
 			It("creates an event successfully", func() {
 				for _, event := range eventsGroup {
-					eventDTO := storage.ToDTO(event)
-
-					body, err := json.Marshal(eventDTO)
+					resp, err := client.CreateEventWithResponse(ctx, toClientEvent(event))
 					Expect(err).To(BeNil())
-
-					req, _ := http.NewRequest(http.MethodPost, "http://localhost:8888/event", bytes.NewReader(body))
-					req.Header.Set("Content-Type", "application/json")
-
-					resp, err := http.DefaultClient.Do(req)
-
-					Expect(err).To(BeNil())
-					defer resp.Body.Close()
-
-					Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+					Expect(resp.StatusCode()).To(Equal(http.StatusCreated))
 				}
 			})
 
@@ -68,20 +86,11 @@ var _ = Describe("Calendar", func() {
 					TimeBefore:  10 * time.Minute,
 				}
 
-				eventDTO := storage.ToDTO(invalidEvent)
-
-				body, err := json.Marshal(eventDTO)
+				resp, err := client.CreateEventWithResponse(ctx, toClientEvent(invalidEvent))
 				Expect(err).To(BeNil())
-
-				req, _ := http.NewRequest(http.MethodPost, "http://localhost:8888/event", bytes.NewReader(body))
-				req.Header.Set("Content-Type", "application/json")
-
-				resp, err := http.DefaultClient.Do(req)
-
-				Expect(err).To(BeNil())
-				defer resp.Body.Close()
-
-				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(resp.StatusCode()).To(Equal(http.StatusBadRequest))
+				Expect(resp.ApplicationproblemJSON400).NotTo(BeNil())
+				Expect(*resp.ApplicationproblemJSON400.InvalidField).To(Equal("id"))
 			})
 
 			It("creates an event invalid event: start time", func() {
@@ -95,19 +104,9 @@ var _ = Describe("Calendar", func() {
 					TimeBefore:  10 * time.Minute,
 				}
 
-				eventDTO := storage.ToDTO(invalidEvent)
-
-				body, err := json.Marshal(eventDTO)
+				resp, err := client.CreateEventWithResponse(ctx, toClientEvent(invalidEvent))
 				Expect(err).To(BeNil())
-
-				req, _ := http.NewRequest(http.MethodPost, "http://localhost:8888/event", bytes.NewReader(body))
-				req.Header.Set("Content-Type", "application/json")
-
-				resp, err := http.DefaultClient.Do(req)
-				Expect(err).To(BeNil())
-				defer resp.Body.Close()
-
-				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(resp.StatusCode()).To(Equal(http.StatusBadRequest))
 			})
 		})
 
@@ -119,102 +118,34 @@ var _ = Describe("Calendar", func() {
 				event.Description = "updated description"
 				event.TimeBefore = 2 * time.Second
 
-				eventDTO := storage.ToDTO(event)
-
-				body, err := json.Marshal(eventDTO)
-				Expect(err).To(BeNil())
-
-				req, _ := http.NewRequest(http.MethodPut,
-					"http://localhost:8888/event?id="+eventsGroup[0].ID.String(), bytes.NewReader(body))
-				req.Header.Set("Content-Type", "application/json")
-
-				resp, err := http.DefaultClient.Do(req)
+				resp, err := client.UpdateEventWithResponse(ctx,
+					&httpclient.UpdateEventParams{Id: eventsGroup[0].ID}, toClientEvent(event))
 				Expect(err).To(BeNil())
-				defer resp.Body.Close()
-
-				Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+				Expect(resp.StatusCode()).To(Equal(http.StatusNoContent))
 			})
 		})
 
 		Context("get events", func() {
-			It("get an event day successfully", func() {
-				req, _ := http.NewRequest(http.MethodGet,
-					"http://localhost:8888/event/day?start="+time.Now().Add(-2*time.Hour).Format(time.RFC3339), nil)
-				resp, err := http.DefaultClient.Do(req)
-				Expect(err).To(BeNil())
-				defer resp.Body.Close()
+			start := time.Now().Add(-2 * time.Hour)
 
-				var eventsDTO []storage.EventDTO
-				err = json.NewDecoder(resp.Body).Decode(&eventsDTO)
+			It("get an event day successfully", func() {
+				resp, err := client.GetEventsDayWithResponse(ctx, &httpclient.GetEventsDayParams{Start: start})
 				Expect(err).To(BeNil())
-
-				var events []storage.Event
-
-				for _, eventDTO := range eventsDTO {
-					events = append(events, storage.FromDTO(eventDTO))
-				}
-
-				Expect(events).To(HaveLen(2))
-				Expect(events[0].Title).To(Equal("test event 2"))
-				Expect(events[1].Title).To(Equal("updated title"))
-
-				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.StatusCode()).To(Equal(http.StatusOK))
+				Expect(titles(resp.JSON200)).To(Equal([]string{"test event 2", "updated title"}))
 			})
 			It("get an event month successfully", func() {
-				req, _ := http.NewRequest(http.MethodGet,
-					"http://localhost:8888/event/month?start="+time.Now().Add(-2*time.Hour).Format(time.RFC3339), nil)
-				resp, err := http.DefaultClient.Do(req)
-				Expect(err).To(BeNil())
-				defer resp.Body.Close()
-
-				var eventsDTO []storage.EventDTO
-				err = json.NewDecoder(resp.Body).Decode(&eventsDTO)
+				resp, err := client.GetEventsMonthWithResponse(ctx, &httpclient.GetEventsMonthParams{Start: start})
 				Expect(err).To(BeNil())
-
-				var events []storage.Event
-
-				for _, eventDTO := range eventsDTO {
-					events = append(events, storage.FromDTO(eventDTO))
-				}
-
-				Expect(events).To(HaveLen(2))
-				Expect(events[0].Title).To(Equal("test event 2"))
-				Expect(events[1].Title).To(Equal("updated title"))
-
-				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.StatusCode()).To(Equal(http.StatusOK))
+				Expect(titles(resp.JSON200)).To(Equal([]string{"test event 2", "updated title"}))
 			})
 			It("get an event week successfully", func() {
-				req, _ := http.NewRequest(http.MethodGet,
-					"http://localhost:8888/event/week?start="+time.Now().Add(-2*time.Hour).Format(time.RFC3339), nil)
-				resp, err := http.DefaultClient.Do(req)
+				resp, err := client.GetEventsWeekWithResponse(ctx, &httpclient.GetEventsWeekParams{Start: start})
 				Expect(err).To(BeNil())
-				defer resp.Body.Close()
-
-				var eventsDTO []storage.EventDTO
-				err = json.NewDecoder(resp.Body).Decode(&eventsDTO)
-				Expect(err).To(BeNil())
-
-				var events []storage.Event
-
-				for _, eventDTO := range eventsDTO {
-					events = append(events, storage.FromDTO(eventDTO))
-				}
-
-				Expect(events).To(HaveLen(2))
-				Expect(events[0].Title).To(Equal("test event 2"))
-				Expect(events[1].Title).To(Equal("updated title"))
-
-				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.StatusCode()).To(Equal(http.StatusOK))
+				Expect(titles(resp.JSON200)).To(Equal([]string{"test event 2", "updated title"}))
 			})
 		})
 	})
-
-	Context("Scheduler and Sender", func() {
-		It("prints message on notification in console", func(ctx SpecContext) {
-			Eventually(func() string {
-				out, _ := exec.CommandContext(ctx, "docker", "logs", "sender", "--since", "1s").CombinedOutput()
-				return string(out)
-			}).WithTimeout(15 * time.Second).Should(ContainSubstring("updated title"))
-		})
-	})
 })